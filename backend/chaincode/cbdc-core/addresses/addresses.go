@@ -0,0 +1,60 @@
+// Package addresses is the pseudonymous wallet address scheme shared by the cbdc-core
+// chaincode and the backend services. An address is derived from the owner's public
+// key, so the ledger never carries usernames, phone numbers or other identifiers.
+package addresses
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefix starts every wallet address
+const Prefix = "cb1"
+
+const (
+	bodyLength     = 20 // Bytes of the public key hash
+	checksumLength = 4  // Bytes of the checksum over prefix and body
+
+	// Length is the number of characters in an address
+	Length = len(Prefix) + 2*(bodyLength+checksumLength)
+)
+
+// FromPublicKey derives the wallet address of a public key
+func FromPublicKey(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	body := hex.EncodeToString(sum[:bodyLength])
+	return Prefix + body + checksum(body)
+}
+
+// ForIntermediary returns the settlement wallet address of an intermediary. Intermediary
+// IDs are public, so their wallets use a well-known address instead of a key.
+func ForIntermediary(intermediaryID string) string {
+	return FromPublicKey([]byte("intermediary/" + intermediaryID))
+}
+
+// Validate checks the format and checksum of an address
+func Validate(address string) error {
+	if len(address) != Length || !strings.HasPrefix(address, Prefix) {
+		return fmt.Errorf("invalid wallet address %q: expected %s followed by %d hex characters", address, Prefix, Length-len(Prefix))
+	}
+	rest := address[len(Prefix):]
+	if strings.ToLower(rest) != rest {
+		return fmt.Errorf("invalid wallet address %q: must be lower case", address)
+	}
+	if _, err := hex.DecodeString(rest); err != nil {
+		return fmt.Errorf("invalid wallet address %q: not hex", address)
+	}
+	body := rest[:2*bodyLength]
+	if rest[2*bodyLength:] != checksum(body) {
+		return fmt.Errorf("invalid wallet address %q: checksum mismatch", address)
+	}
+	return nil
+}
+
+// checksum catches mistyped addresses before funds are sent to them
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(Prefix + body))
+	return hex.EncodeToString(sum[:checksumLength])
+}
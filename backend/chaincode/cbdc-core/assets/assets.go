@@ -0,0 +1,26 @@
+// Package assets is the asset code vocabulary shared by the cbdc-core chaincode
+// and the backend services.
+package assets
+
+import "regexp"
+
+// Default is the retail eNaira. Calls that take no asset code (Issue, Transfer,
+// Redeem, ...) operate on it, and tier limits, holds, escrow and offline purses
+// are denominated in it.
+const Default = "NGN"
+
+// codePattern accepts upper-case codes such as NGN, USD or WNGN
+var codePattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{2,11}$`)
+
+// ValidCode reports whether code is a well-formed asset code
+func ValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+// OrDefault returns code, or Default when code is empty
+func OrDefault(code string) string {
+	if code == "" {
+		return Default
+	}
+	return code
+}
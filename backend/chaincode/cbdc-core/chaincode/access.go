@@ -0,0 +1,126 @@
+package chaincode
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Organisations on the CBDC channel
+const (
+	MSPCentralBank    = "CentralBankMSP"
+	MSPRegulator      = "RegulatorMSP"
+	MSPBankConsortium = "BankConsortiumMSP"
+)
+
+// Client certificate attributes issued by the Fabric CA
+const (
+	AttrRole           = "role"            // e.g. admin
+	AttrIntermediaryID = "intermediary_id" // Bank within the consortium MSP; other MSPs are their own intermediary
+	RoleAdmin          = "admin"
+)
+
+// caller is the identity of the client submitting the transaction
+type caller struct {
+	ID             string
+	MSPID          string
+	Role           string
+	IntermediaryID string
+}
+
+func getCaller(ctx contractapi.TransactionContextInterface) (*caller, error) {
+	identity := ctx.GetClientIdentity()
+
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSP ID: %v", err)
+	}
+	id, err := identity.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+	role, _, err := identity.GetAttributeValue(AttrRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s attribute: %v", AttrRole, err)
+	}
+
+	// Only the consortium CA vouches for which bank an identity belongs to. Any other
+	// organisation is its own intermediary, so an intermediary_id it issues is ignored
+	// rather than letting it act for a consortium bank.
+	intermediaryID := mspID
+	if mspID == MSPBankConsortium {
+		attr, found, err := identity.GetAttributeValue(AttrIntermediaryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s attribute: %v", AttrIntermediaryID, err)
+		}
+		if !found || attr == "" {
+			return nil, fmt.Errorf("unauthorized: %s identities must carry the %s attribute", MSPBankConsortium, AttrIntermediaryID)
+		}
+		if slices.Contains([]string{MSPCentralBank, MSPRegulator, MSPBankConsortium}, attr) {
+			return nil, fmt.Errorf("unauthorized: %s %q is reserved", AttrIntermediaryID, attr)
+		}
+		intermediaryID = attr
+	}
+
+	return &caller{ID: id, MSPID: mspID, Role: role, IntermediaryID: intermediaryID}, nil
+}
+
+// isSupervisor reports whether the caller belongs to the Central Bank or the Regulator
+func (c *caller) isSupervisor() bool {
+	return c.MSPID == MSPCentralBank || c.MSPID == MSPRegulator
+}
+
+// manages reports whether the caller is the intermediary responsible for the wallet
+func (c *caller) manages(wallet *Wallet) bool {
+	return wallet.IntermediaryID == c.IntermediaryID
+}
+
+// requireMSP allows only callers from one of the given organisations
+func requireMSP(ctx contractapi.TransactionContextInterface, action string, mspIDs ...string) (*caller, error) {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(mspIDs, c.MSPID) {
+		return nil, fmt.Errorf("unauthorized: %s is restricted to %v", action, mspIDs)
+	}
+	return c, nil
+}
+
+// requireCentralBankAdmin allows only Central Bank identities carrying role=admin
+func requireCentralBankAdmin(ctx contractapi.TransactionContextInterface, action string) (*caller, error) {
+	c, err := requireMSP(ctx, action, MSPCentralBank)
+	if err != nil {
+		return nil, err
+	}
+	if c.Role != RoleAdmin {
+		return nil, fmt.Errorf("unauthorized: %s requires the %s role", action, RoleAdmin)
+	}
+	return c, nil
+}
+
+// requireWalletIntermediary allows only the intermediary that manages the wallet,
+// so one bank cannot create or move funds for another bank's customers
+func requireWalletIntermediary(ctx contractapi.TransactionContextInterface, wallet *Wallet) error {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return err
+	}
+	if !c.manages(wallet) {
+		return fmt.Errorf("unauthorized: wallet %s is not managed by %s", wallet.ID, c.IntermediaryID)
+	}
+	return nil
+}
+
+// requireWalletReader allows the managing intermediary plus the Central Bank and Regulator
+func requireWalletReader(ctx contractapi.TransactionContextInterface, wallet *Wallet) error {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return err
+	}
+	if !c.isSupervisor() && !c.manages(wallet) {
+		return fmt.Errorf("unauthorized: wallet %s is not visible to %s", wallet.ID, c.IntermediaryID)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+)
+
+func TestConformanceIntermediaryIdentity(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	// Only the consortium CA can say which bank an identity acts for
+	regulatorAsBank := newIdentity(t, MSPRegulator, map[string]string{AttrIntermediaryID: "bank-a"})
+	corridorAsBank := newIdentity(t, "CorridorBankMSP", map[string]string{AttrIntermediaryID: "bank-a"})
+	unlabelled := newIdentity(t, MSPBankConsortium, map[string]string{})
+	reserved := newIdentity(t, MSPBankConsortium, map[string]string{AttrIntermediaryID: MSPCentralBank})
+
+	l.fund(p, "zane", "Tier1", 5000)
+	l.fund(p, "yara", "Tier1", 0)
+	l.createWallet(p.bank, "shop", "bank-a", "Tier2")
+	l.submit(p.bank, nil, false, "RegisterMerchant", "m-1", "Corner Store", "5411", addr("shop"), fees.P2B)
+
+	for _, impostor := range []identity{regulatorAsBank, corridorAsBank, unlabelled, reserved} {
+		l.submit(impostor, l.pay("zane", "yara", 100), true, "Transfer", addr("zane"), addr("yara"), "100")
+		l.submit(impostor, nil, true, "CreatePaymentRequest", "m-1", "inv-1", "100", strconv.FormatInt(l.clock.Add(time.Hour).Unix(), 10))
+		l.submit(impostor, nil, true, "RecordIntermediaryPosition", "bank-a")
+	}
+	if got := l.endorse(unlabelled, "query", nil, "GetWallet", []string{addr("zane")}); got.status == 200 {
+		t.Errorf("an identity without %s read a wallet", AttrIntermediaryID)
+	}
+
+	l.submit(p.bank, l.pay("zane", "yara", 100), false, "Transfer", addr("zane"), addr("yara"), "100")
+	if got := l.wallet(addr("yara")).Balance; got != 100 {
+		t.Errorf("yara balance = %d", got)
+	}
+}
+
+func TestConformanceReaderAccess(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	corridor := newIdentity(t, "CorridorBankMSP", map[string]string{})
+
+	reads := []struct {
+		fn      string
+		args    []string
+		allowed []identity
+	}{
+		{"GetSupply", nil, []identity{p.centralBank, p.regulator}},
+		{"GetSupplyHistory", []string{"", ""}, []identity{p.centralBank, p.regulator}},
+		{"GetTotalSupply", nil, []identity{p.centralBank, p.regulator}},
+		{"GetAssetSupply", []string{"NGN"}, []identity{p.centralBank, p.regulator}},
+		{"QuoteFee", []string{fees.P2P, "1000"}, []identity{p.centralBank, p.regulator, p.bank}},
+		{"GetPauses", nil, []identity{p.centralBank, p.regulator, p.bank}},
+		{"GetPauseHistory", nil, []identity{p.centralBank, p.regulator, p.bank}},
+	}
+	for _, read := range reads {
+		for _, reader := range []identity{p.centralBank, p.regulator, p.bank, corridor} {
+			allowed := false
+			for _, id := range read.allowed {
+				allowed = allowed || id.id == reader.id
+			}
+			if got := l.endorse(reader, "query", nil, read.fn, read.args); (got.status == 200) != allowed {
+				t.Errorf("%s by %s: status %d, message %q", read.fn, reader.id, got.status, got.message)
+			}
+		}
+	}
+}
+
+func TestConformanceInterbankSettlement(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	bankA, bankB := addresses.ForIntermediary("bank-a"), addresses.ForIntermediary("bank-b")
+
+	owner, _ := json.Marshal(WalletOwner{Attributes: []WalletAttribute{{Name: AttributeOwnerID, Value: "settlement", Salt: ownerSalt}}})
+	l.submit(p.bank, map[string][]byte{TransientWalletOwner: owner}, false, "CreateWallet", bankA, "bank-a", "Tier2", "")
+	l.submit(p.otherBank, map[string][]byte{TransientWalletOwner: owner}, false, "CreateWallet", bankB, "bank-b", "Tier2", "")
+	l.submit(p.centralBank, nil, false, "Issue", "5000", bankA)
+
+	// The Central Bank does not manage either wallet, so it cannot use Transfer for RTGS
+	l.submit(p.centralBank, nil, true, "Transfer", bankA, bankB, "1000")
+	for _, other := range []identity{p.bank, p.otherBank, p.regulator} {
+		l.submit(other, nil, true, "SettleInterbank", "bank-a", "bank-b", "1000")
+	}
+	l.submit(p.centralBank, nil, true, "SettleInterbank", "bank-a", "bank-a", "1000")
+	l.submit(p.centralBank, nil, true, "SettleInterbank", "bank-a", "bank-c", "1000")
+
+	// The settlement ID keys the move, so a retried settlement is not paid twice
+	key := map[string][]byte{TransientIdempotencyKey: []byte("RTGS-IB-1")}
+	l.submit(p.centralBank, key, false, "SettleInterbank", "bank-a", "bank-b", "1000")
+	l.replay(p.centralBank, key, "SettleInterbank", "bank-a", "bank-b", "1000")
+
+	if got := l.wallet(bankA).Balance; got != 4000 {
+		t.Errorf("bank-a settlement balance = %d", got)
+	}
+	if got := l.wallet(bankB).Balance; got != 1000 {
+		t.Errorf("bank-b settlement balance = %d", got)
+	}
+}
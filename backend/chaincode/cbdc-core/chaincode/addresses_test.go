@@ -0,0 +1,63 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+)
+
+func TestConformanceWalletAddresses(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	create := func(id string, ownerKey string, wantErr bool) {
+		t.Helper()
+		owner, _ := json.Marshal(WalletOwner{Attributes: []WalletAttribute{
+			{Name: AttributeOwnerID, Value: "owner-opal", Salt: ownerSalt},
+		}})
+		l.submit(p.bank, map[string][]byte{TransientWalletOwner: owner}, wantErr, "CreateWallet", id, "bank-a", "Tier1", ownerKey)
+	}
+
+	valid := addr("opal")
+	if len(valid) != addresses.Length || !strings.HasPrefix(valid, addresses.Prefix) {
+		t.Fatalf("address %q does not match the scheme", valid)
+	}
+	body := valid[len(addresses.Prefix):]
+	flip := func(s string, i int) string {
+		c := byte('0')
+		if s[i] == '0' {
+			c = '1'
+		}
+		return s[:i] + string(c) + s[i+1:]
+	}
+	for name, id := range map[string]string{
+		"mistyped checksum": flip(valid, len(valid)-1),
+		"mistyped body":     flip(valid, len(addresses.Prefix)),
+		"upper case":        addresses.Prefix + strings.ToUpper(body),
+		"wrong prefix":      "cb2" + body,
+		"truncated":         valid[:len(valid)-2],
+		"not hex":           addresses.Prefix + "zz" + body[2:],
+		"another owner's":   addr("pia"),
+		"empty":             "",
+	} {
+		if err := addresses.Validate(id); err == nil && name != "another owner's" {
+			t.Errorf("%s address %q validated", name, id)
+		}
+		create(id, ownerKeyHex("opal"), true)
+	}
+
+	// An intermediary's settlement wallet is the only one opened without an owner key
+	create(addresses.ForIntermediary("bank-a"), ownerKeyHex("opal"), true)
+	create(addresses.ForIntermediary("bank-b"), "", true)
+	create(addresses.ForIntermediary("bank-a"), "", false)
+
+	create(valid, "", true)
+	create(valid, ownerKeyHex("opal"), false)
+	create(valid, ownerKeyHex("opal"), true) // Already exists
+	if got := l.wallet(valid); got.OwnerKey != ownerKeyHex("opal") {
+		t.Errorf("owner key = %q", got.OwnerKey)
+	}
+}
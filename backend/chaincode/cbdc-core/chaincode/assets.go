@@ -1,26 +1,127 @@
 package chaincode
 
+import "encoding/json"
+
 // Wallet represents a user's holding capability
 type Wallet struct {
 	ID             string `json:"id"`
-	OwnerID        string `json:"owner_id"` // Pseudonymous ID
+	OwnerID        string `json:"owner_id,omitempty" metadata:",optional"` // Legacy wallets only; owners are now kept in pdc-retail-wallets
 	IntermediaryID string `json:"intermediary_id"`
-	Tier           string `json:"tier"`   // Tier0, Tier1, Tier2
-	Status         string `json:"status"` // Active, Frozen
-	Balance        int64  `json:"balance"`
+	Tier           string `json:"tier"`      // Tier0, Tier1, Tier2
+	Status         string `json:"status"`    // Active, Frozen, Suspended, Dormant, Closed
+	Balance        int64  `json:"balance"`   // Total default-asset (NGN) funds owned, including held funds
+	Held           int64  `json:"held"`      // Sum of active holds
+	Available      int64  `json:"available"` // Balance - Held, maintained by MarshalJSON
+
+	StatusReason    string `json:"status_reason,omitempty" metadata:",optional"`     // Reason code of the last status change
+	StatusUpdatedAt int64  `json:"status_updated_at,omitempty" metadata:",optional"` // Unix seconds of the last status change
+	StatusSetBy     string `json:"status_set_by,omitempty" metadata:",optional"`     // MSP of the caller behind the last status change
+
+	Balances map[string]int64 `json:"balances,omitempty" metadata:",optional"` // Non-zero balance per asset code; the NGN entry mirrors Balance
+
+	OwnerKey string `json:"owner_key,omitempty" metadata:",optional"` // Hex Ed25519 key that signs debits; the wallet ID is its address
+	Nonce    int64  `json:"nonce,omitempty" metadata:",optional"`     // Last nonce used in an owner authorization
+}
+
+// MarshalJSON keeps Available and Balances in step with Balance and Held on every write
+func (w Wallet) MarshalJSON() ([]byte, error) {
+	type walletJSON Wallet
+	w.Available = w.available()
+	w.Balances = w.assetBalances()
+	return json.Marshal(walletJSON(w))
+}
+
+// available returns the funds that can be spent, excluding holds and reservations
+func (w *Wallet) available() int64 {
+	return w.Balance - w.Held
 }
 
 // Transaction represents a movement of funds
 type Transaction struct {
 	ID        string `json:"id"`
-	Type      string `json:"type"` // Mint, Transfer, Redeem
+	Type      string `json:"type"` // Mint, Transfer, Redeem, OfflineReconcile, Fee, Refund, Chargeback
 	From      string `json:"from"`
 	To        string `json:"to"`
 	Amount    int64  `json:"amount"`
 	Timestamp int64  `json:"timestamp"`
+	Signature []byte `json:"signature,omitempty" metadata:",optional"` // Added for Phase 4
+	Asset     string `json:"asset,omitempty" metadata:",optional"`     // Empty on records written before multi-asset support, meaning NGN
+
+	PaymentType string `json:"payment_type,omitempty" metadata:",optional"` // P2P, P2B, ... for transfers
+	Fee         int64  `json:"fee,omitempty" metadata:",optional"`          // Charged to From on top of Amount; see the matching "<id>-fee" record
+	Reference   string `json:"reference,omitempty" metadata:",optional"`    // What the transfer settles, e.g. merchant/invoice for PayRequest or the original TxID of a refund
+}
+
+// WalletUsage tracks a wallet's cumulative spend for the current UTC day
+type WalletUsage struct {
+	WalletID   string `json:"wallet_id"`
+	Day        string `json:"day"` // YYYY-MM-DD (UTC, from the tx timestamp)
+	DailySpent int64  `json:"daily_spent"`
+	DailyCount int64  `json:"daily_count"`
+}
+
+// WalletHeadroom is the read model returned by GetWalletUsage
+type WalletHeadroom struct {
+	WalletUsage
+	Tier             string `json:"tier"`
+	MaxTransaction   int64  `json:"max_transaction"`
+	DailyLimit       int64  `json:"daily_limit"`
+	DailyRemaining   int64  `json:"daily_remaining"`
+	MaxDailyCount    int64  `json:"max_daily_count"`
+	CountRemaining   int64  `json:"count_remaining"`
+	MaxBalance       int64  `json:"max_balance"`       // 0 means no ceiling
+	BalanceRemaining int64  `json:"balance_remaining"` // -1 when there is no ceiling
+}
+
+// SupplyRecord is the authoritative running total of an asset issued and redeemed
+type SupplyRecord struct {
+	TotalIssued   int64 `json:"total_issued"`
+	TotalRedeemed int64 `json:"total_redeemed"`
+	Outstanding   int64 `json:"outstanding"`
+	Seeded        int64 `json:"seeded,omitempty" metadata:",optional"` // In circulation before the counter; added to TotalIssued by MigrateSupply
+}
+
+// SupplyPeriod aggregates mint/burn activity for one UTC day
+type SupplyPeriod struct {
+	Period      string `json:"period"` // YYYY-MM-DD
+	Issued      int64  `json:"issued"`
+	Redeemed    int64  `json:"redeemed"`
+	MintCount   int64  `json:"mint_count"`
+	BurnCount   int64  `json:"burn_count"`
+	Outstanding int64  `json:"outstanding"` // Outstanding supply after the last operation of the period
+}
+
+// HistoryPage is a page of a wallet's on-chain transaction history
+type HistoryPage struct {
+	Records      []*Transaction `json:"records"`
+	FetchedCount int32          `json:"fetched_count"`
+	Bookmark     string         `json:"bookmark"`
+}
+
+// BatchTransferLeg is a single credit within a BatchTransfer
+type BatchTransferLeg struct {
+	ToWalletID string `json:"to_wallet_id"`
+	Amount     int64  `json:"amount"`
+}
+
+// BatchTransferResult summarises a settled BatchTransfer; it is also the BatchTransferEvent payload
+type BatchTransferResult struct {
+	BatchID      string   `json:"batch_id"`
+	FromWalletID string   `json:"from_wallet_id"`
+	LegCount     int      `json:"leg_count"`
+	TotalAmount  int64    `json:"total_amount"`
+	TxIDs        []string `json:"tx_ids"`
+	Timestamp    int64    `json:"timestamp"`
 }
 
 const (
-	DocTypeWallet = "WALLET"
-	DocTypeTx     = "TX"
+	DocTypeWallet       = "WALLET"
+	DocTypeTx           = "TX"
+	DocTypeUsage        = "USAGE"
+	DocTypeSupplyPeriod = "SUPPLY_PERIOD" // Simple key prefix, so a day range is one range query
+	DocTypeWalletTx     = "WALLET_TX"     // walletID~timestamp~txID index
+	DocTypeAsset        = "ASSET"
+	DocTypeAssetSupply  = "ASSET_SUPPLY" // Supply of assets other than NGN, which keeps SupplyKey
+
+	SupplyKey = "SUPPLY"
 )
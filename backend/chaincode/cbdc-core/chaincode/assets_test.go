@@ -0,0 +1,37 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformanceMultiAsset(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	corridorBank := newIdentity(t, "CorridorBankMSP", map[string]string{AttrRole: RoleAdmin})
+
+	l.fund(p, "kate", "Tier0", 1000)
+	l.fund(p, "leo", "Tier0", 0)
+
+	l.submit(p.bank, nil, true, "RegisterAsset", "USD", "US Dollar", "2", "CorridorBankMSP")
+	l.submit(p.centralBank, nil, false, "RegisterAsset", "USD", "US Dollar", "2", "CorridorBankMSP")
+	l.submit(p.centralBank, nil, true, "IssueAsset", "USD", "500000", addr("kate"))
+	l.submit(corridorBank, nil, false, "IssueAsset", "USD", "500000", addr("kate"))
+
+	// Tier limits are denominated in NGN, so a Tier0 wallet can move larger USD amounts
+	l.submit(p.bank, l.signed("kate", intents.Transfer{To: addr("leo"), Asset: "USD", Amount: 200000}), false, "TransferAsset", "USD", addr("kate"), addr("leo"), "200000")
+	l.submit(p.bank, l.pay("kate", "leo", 400), false, "Transfer", addr("kate"), addr("leo"), "400")
+	l.submit(corridorBank, nil, false, "RedeemAsset", "USD", "100000", addr("leo"))
+	l.submit(p.bank, nil, true, "CloseWallet", addr("leo"), StatusReasonCustomerRequest)
+
+	kate, leo := l.wallet(addr("kate")), l.wallet(addr("leo"))
+	if kate.Balance != 600 || kate.balanceOf("USD") != 300000 {
+		t.Errorf("kate balances = %v", kate.Balances)
+	}
+	if leo.Balances[assets.Default] != 400 || leo.balanceOf("USD") != 100000 {
+		t.Errorf("leo balances = %v", leo.Balances)
+	}
+}
@@ -0,0 +1,68 @@
+package chaincode
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// checkOwnerKey validates the owner key registered by CreateWallet. Customer wallets must
+// be addressed by their owner key; an intermediary's settlement wallet, at its well-known
+// address, is the only wallet opened without one.
+func checkOwnerKey(id string, intermediaryID string, ownerKey string) error {
+	if id == addresses.ForIntermediary(intermediaryID) {
+		if ownerKey != "" {
+			return fmt.Errorf("settlement wallet %s takes no owner key", id)
+		}
+		return nil
+	}
+
+	publicKey, err := hex.DecodeString(ownerKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("owner key must be a hex-encoded Ed25519 public key")
+	}
+	if addresses.FromPublicKey(publicKey) != id {
+		return fmt.Errorf("wallet %s is not the address of the owner key", id)
+	}
+	return nil
+}
+
+// authorizeDebit verifies the owner's signature over a debit of sender, passed in the
+// transient map under "authorization", and consumes the wallet's next nonce. The caller
+// writes sender. Wallets without an owner key (settlement wallets and wallets opened
+// before owner keys) remain authorized by their intermediary alone.
+func authorizeDebit(ctx contractapi.TransactionContextInterface, sender *Wallet, intent intents.Transfer) error {
+	if sender.OwnerKey == "" {
+		return nil
+	}
+
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to get transient data: %v", err)
+	}
+	authorizationJSON, ok := transient[intents.TransientKey]
+	if !ok {
+		return fmt.Errorf("debits of wallet %s must be authorized by its owner", sender.ID)
+	}
+	var authorization intents.Authorization
+	if err := json.Unmarshal(authorizationJSON, &authorization); err != nil {
+		return fmt.Errorf("failed to parse authorization: %v", err)
+	}
+	if authorization.Nonce != sender.Nonce+1 {
+		return fmt.Errorf("authorization nonce %d for wallet %s, expected %d", authorization.Nonce, sender.ID, sender.Nonce+1)
+	}
+
+	publicKey, _ := hex.DecodeString(sender.OwnerKey)
+	intent.From = sender.ID
+	intent.Nonce = authorization.Nonce
+	if !intents.Verify(publicKey, intent, authorization.Signature) {
+		return fmt.Errorf("invalid owner signature for wallet %s", sender.ID)
+	}
+	sender.Nonce = authorization.Nonce
+	return nil
+}
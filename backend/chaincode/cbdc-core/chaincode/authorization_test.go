@@ -0,0 +1,49 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformanceOwnerAuthorization(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "ada", "Tier1", 5000)
+	l.fund(p, "ben", "Tier1", 0)
+
+	owner, _ := json.Marshal(WalletOwner{Attributes: []WalletAttribute{{Name: AttributeOwnerID, Value: "owner-cara", Salt: ownerSalt}}})
+	create := map[string][]byte{TransientWalletOwner: owner}
+	l.submit(p.bank, create, true, "CreateWallet", addr("cara"), "bank-a", "Tier1", "")
+	l.submit(p.bank, create, true, "CreateWallet", addr("cara"), "bank-a", "Tier1", ownerKeyHex("ben"))
+	settlement := addresses.ForIntermediary("bank-a")
+	l.submit(p.bank, create, true, "CreateWallet", settlement, "bank-a", "Tier2", ownerKeyHex("cara"))
+	l.submit(p.bank, create, false, "CreateWallet", settlement, "bank-a", "Tier2", "")
+
+	forged, _ := json.Marshal(intents.Sign(ownerKey("ben"), intents.Transfer{From: addr("ada"), To: addr("ben"), Asset: assets.Default, Amount: 100, Nonce: 1}))
+	l.submit(p.bank, nil, true, "Transfer", addr("ada"), addr("ben"), "100")
+	l.submit(p.bank, map[string][]byte{intents.TransientKey: forged}, true, "Transfer", addr("ada"), addr("ben"), "100")
+	l.submit(p.bank, l.pay("ada", "ben", 100), true, "Transfer", addr("ada"), addr("ben"), "200")
+	l.submit(p.bank, l.pay("ada", "ben", 100), true, "TransferWithType", addr("ada"), settlement, "100", "P2B")
+
+	authorized := l.pay("ada", "ben", 100)
+	l.submit(p.bank, authorized, false, "Transfer", addr("ada"), addr("ben"), "100")
+	l.submit(p.bank, authorized, true, "Transfer", addr("ada"), addr("ben"), "100")
+	l.submit(p.bank, l.pay("ada", "ben", 100), false, "Transfer", addr("ada"), addr("ben"), "100")
+
+	// The settlement wallet has no owner key and is moved by its intermediary alone
+	l.submit(p.centralBank, nil, false, "Issue", "1000", settlement)
+	l.submit(p.bank, nil, false, "Transfer", settlement, addr("ben"), "400")
+
+	if got := l.wallet(addr("ada")); got.Nonce != 2 || got.Balance != 4800 {
+		t.Errorf("ada nonce = %d, balance = %d", got.Nonce, got.Balance)
+	}
+	if got := l.wallet(addr("ben")).Balance; got != 600 {
+		t.Errorf("ben balance = %d", got)
+	}
+}
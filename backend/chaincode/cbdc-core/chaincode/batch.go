@@ -0,0 +1,213 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// MaxBatchLegs bounds the size of a BatchTransfer so a single proposal stays endorsable
+const MaxBatchLegs = 500
+
+// BatchTransfer debits the sender once and credits every recipient in transfersJSON.
+// It is all-or-nothing: any invalid leg fails the whole transaction and nothing is written.
+// Used by payments-service for payroll and G2P disbursements. A retry carrying the same
+// idempotency key returns the original result instead of paying the batch again.
+func (s *SmartContract) BatchTransfer(ctx contractapi.TransactionContextInterface, fromWalletID string, transfersJSON string) (*BatchTransferResult, error) {
+	var legs []BatchTransferLeg
+	if err := json.Unmarshal([]byte(transfersJSON), &legs); err != nil {
+		return nil, fmt.Errorf("failed to parse transfers: %v", err)
+	}
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("empty batch")
+	}
+	if len(legs) > MaxBatchLegs {
+		return nil, fmt.Errorf("batch of %d legs exceeds maximum %d", len(legs), MaxBatchLegs)
+	}
+
+	// 1. Validate legs and compute the aggregate
+	var total int64
+	for i, leg := range legs {
+		if leg.Amount <= 0 {
+			return nil, fmt.Errorf("leg %d: amount must be positive", i)
+		}
+		if leg.ToWalletID == fromWalletID {
+			return nil, fmt.Errorf("leg %d: cannot transfer to the sending wallet", i)
+		}
+		if leg.Amount > math.MaxInt64-total {
+			return nil, fmt.Errorf("leg %d: batch total overflows", i)
+		}
+		total += leg.Amount
+	}
+
+	// 2. Get Sender
+	senderBytes, err := ctx.GetStub().GetState(fromWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if senderBytes == nil {
+		return nil, fmt.Errorf("sender wallet %s not found", fromWalletID)
+	}
+	var sender Wallet
+	if err := json.Unmarshal(senderBytes, &sender); err != nil {
+		return nil, err
+	}
+
+	// Only the sender's intermediary can disburse its funds
+	if err := requireWalletIntermediary(ctx, &sender); err != nil {
+		return nil, err
+	}
+	idem, err := beginIdempotent(ctx, "BatchTransfer", fromWalletID, transfersJSON)
+	if err != nil {
+		return nil, err
+	}
+	if idem.replay != nil {
+		// The key recorded the first leg, from which the rest of the batch follows
+		return newBatchResult(strings.TrimSuffix(idem.replay.ID, "-leg-0"), fromWalletID, len(legs), total, idem.replay.Timestamp), nil
+	}
+	if err := sender.canSend(); err != nil {
+		return nil, err
+	}
+	if err := authorizeDebit(ctx, &sender, intents.Transfer{Legs: intents.LegsHash(transfersJSON), Asset: assets.Default, Amount: total}); err != nil {
+		return nil, err
+	}
+	if sender.available() < total {
+		return nil, fmt.Errorf("insufficient funds: batch total %d, available %d", total, sender.available())
+	}
+
+	// Every leg is a payment in its own right and must fit the scheme and single
+	// transaction limits; daily limits apply to the batch as a single debit
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, leg := range legs {
+		if err := checkTransactionLimits(doc, &sender, leg.Amount); err != nil {
+			return nil, fmt.Errorf("leg %d: %v", i, err)
+		}
+	}
+	usage, err := checkDailyLimits(ctx, doc, &sender, total)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Get Receivers, accumulating repeated recipients so ceilings see the full credit.
+	// receiverIDs keeps first-seen order so every endorser processes legs identically.
+	receivers := map[string]*Wallet{}
+	receiverIDs := []string{}
+	credits := map[string]int64{}
+	for i, leg := range legs {
+		if _, ok := receivers[leg.ToWalletID]; !ok {
+			receiverBytes, err := ctx.GetStub().GetState(leg.ToWalletID)
+			if err != nil {
+				return nil, err
+			}
+			if receiverBytes == nil {
+				return nil, fmt.Errorf("leg %d: receiver wallet %s not found", i, leg.ToWalletID)
+			}
+			var receiver Wallet
+			if err := json.Unmarshal(receiverBytes, &receiver); err != nil {
+				return nil, err
+			}
+			if err := receiver.canReceive(); err != nil {
+				return nil, fmt.Errorf("leg %d: %v", i, err)
+			}
+			receivers[leg.ToWalletID] = &receiver
+			receiverIDs = append(receiverIDs, leg.ToWalletID)
+		}
+		credits[leg.ToWalletID] += leg.Amount
+	}
+
+	intermediaryIDs := []string{sender.IntermediaryID}
+	for _, id := range receiverIDs {
+		intermediaryIDs = append(intermediaryIDs, receivers[id].IntermediaryID)
+	}
+	if err := checkNotPaused(ctx, OperationTransfer, intermediaryIDs...); err != nil {
+		return nil, err
+	}
+
+	// 4. Update Balances
+	for _, id := range receiverIDs {
+		receiver := receivers[id]
+		if err := checkBalanceCeiling(doc, receiver, credits[id]); err != nil {
+			return nil, err
+		}
+		receiver.Balance += credits[id]
+
+		receiverUpdated, _ := json.Marshal(receiver)
+		if err := ctx.GetStub().PutState(id, receiverUpdated); err != nil {
+			return nil, err
+		}
+	}
+
+	sender.Balance -= total
+	senderUpdated, _ := json.Marshal(sender)
+	if err := ctx.GetStub().PutState(fromWalletID, senderUpdated); err != nil {
+		return nil, err
+	}
+	if err := putWalletUsage(ctx, usage); err != nil {
+		return nil, err
+	}
+
+	// 5. Save one Transaction Record per leg
+	batchID := ctx.GetStub().GetTxID()
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := now.Unix()
+	result := newBatchResult(batchID, fromWalletID, len(legs), total, timestamp)
+	for i, leg := range legs {
+		tx := Transaction{
+			ID:        result.TxIDs[i],
+			Type:      "BatchTransfer",
+			From:      fromWalletID,
+			To:        leg.ToWalletID,
+			Amount:    leg.Amount,
+			Timestamp: timestamp,
+			Asset:     assets.Default,
+		}
+		if _, err := putTransaction(ctx, &tx); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			if err := idem.complete(ctx, &tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 6. Emit a single batch event
+	if err := emitEvent(ctx, events.NameBatchTransfer, events.BatchTransfer{
+		FromWalletID: fromWalletID,
+		LegCount:     result.LegCount,
+		TotalAmount:  total,
+		TxIDs:        result.TxIDs,
+	}); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// newBatchResult describes a batch whose legs are recorded as <batchID>-leg-<i>
+func newBatchResult(batchID string, fromWalletID string, legCount int, total int64, timestamp int64) *BatchTransferResult {
+	result := &BatchTransferResult{
+		BatchID:      batchID,
+		FromWalletID: fromWalletID,
+		LegCount:     legCount,
+		TotalAmount:  total,
+		TxIDs:        make([]string, 0, legCount),
+		Timestamp:    timestamp,
+	}
+	for i := 0; i < legCount; i++ {
+		result.TxIDs = append(result.TxIDs, fmt.Sprintf("%s-leg-%d", batchID, i))
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package chaincode
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
+)
+
+func TestConformanceBatchTransfer(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "olga", "Tier1", 50000)
+	recipients := []string{"pete", "ruth", "sven"}
+	for _, name := range recipients {
+		l.fund(p, name, "Tier2", 0)
+	}
+
+	batch := func(amounts ...int64) (string, int64) {
+		legs, total := "", int64(0)
+		for i, amount := range amounts {
+			if legs != "" {
+				legs += ","
+			}
+			legs += fmt.Sprintf(`{"to_wallet_id":%q,"amount":%d}`, addr(recipients[i%len(recipients)]), amount)
+			total += amount
+		}
+		return "[" + legs + "]", total
+	}
+	send := func(wantErr bool, amounts ...int64) {
+		t.Helper()
+		legs, total := batch(amounts...)
+		l.submit(p.bank, l.signed("olga", intents.Transfer{Legs: intents.LegsHash(legs), Amount: total}), wantErr, "BatchTransfer", addr("olga"), legs)
+	}
+
+	send(true, math.MaxInt64, math.MaxInt64, 3) // The total wraps around to 1
+	send(true, 100001, 200)                     // Above the Tier1 single transaction limit
+	send(false, 2000, 3000)
+
+	if got := l.wallet(addr("olga")).Balance; got != 45000 {
+		t.Errorf("olga balance = %d", got)
+	}
+	if got := l.wallet(addr("ruth")).Balance; got != 3000 {
+		t.Errorf("ruth balance = %d", got)
+	}
+}
+
+func TestConformanceBatchLimits(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Tiers[tiers.Tier1] = tiers.Limits{MaxTransaction: 2000, DailyLimit: 5000, MaxDailyCount: 10, MaxBalance: 1000000}
+
+	l.fund(p, "uma", "Tier1", 10000)
+	l.fund(p, "vic", "Tier2", 0)
+	l.fund(p, "walt", "Tier2", 0)
+
+	send := func(wantErr bool, toVic int64, toWalt int64) {
+		t.Helper()
+		legs := fmt.Sprintf(`[{"to_wallet_id":%q,"amount":%d},{"to_wallet_id":%q,"amount":%d}]`, addr("vic"), toVic, addr("walt"), toWalt)
+		l.submit(p.bank, l.signed("uma", intents.Transfer{Legs: intents.LegsHash(legs), Amount: toVic + toWalt}), wantErr, "BatchTransfer", addr("uma"), legs)
+	}
+
+	send(false, 1500, 1500) // Each leg fits the 2000 maximum though the batch total does not
+	send(true, 2500, 100)   // A single leg above the maximum
+	send(true, 1500, 1000)  // 3000 + 2500 is over the daily 5000
+
+	if got := l.wallet(addr("uma")).Balance; got != 7000 {
+		t.Errorf("uma balance = %d", got)
+	}
+	if got := l.usage(addr("uma")); got.DailySpent != 3000 || got.DailyCount != 1 {
+		t.Errorf("uma usage = %+v", got)
+	}
+	if vic, walt := l.wallet(addr("vic")).Balance, l.wallet(addr("walt")).Balance; vic != 1500 || walt != 1500 {
+		t.Errorf("vic balance = %d, walt balance = %d", vic, walt)
+	}
+}
@@ -0,0 +1,438 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DocTypeEscrow keys escrow objects in world state
+const DocTypeEscrow = "ESCROW"
+
+// Escrow release condition types
+const (
+	ConditionTimeLock = "TimeLock" // Releasable once ReleaseAfter has passed
+	ConditionArbiter  = "Arbiter"  // Releasable once the arbiter approves
+	ConditionMultiSig = "MultiSig" // Releasable once Threshold of Signers approve
+)
+
+// Escrow statuses
+const (
+	EscrowHeld     = "Held"
+	EscrowReleased = "Released"
+	EscrowRefunded = "Refunded"
+)
+
+// ReleaseCondition describes when held funds may be paid out to the payee.
+// Arbiter and Signers are client identity IDs as returned by GetClientIdentity().GetID().
+type ReleaseCondition struct {
+	Type         string   `json:"type"`
+	ReleaseAfter int64    `json:"release_after,omitempty" metadata:",optional"`
+	Arbiter      string   `json:"arbiter,omitempty" metadata:",optional"`
+	Signers      []string `json:"signers,omitempty" metadata:",optional"`
+	Threshold    int      `json:"threshold,omitempty" metadata:",optional"`
+}
+
+// Escrow holds funds debited from the payer until its condition is met or it expires
+type Escrow struct {
+	ID           string           `json:"id"`
+	FromWalletID string           `json:"from_wallet_id"`
+	ToWalletID   string           `json:"to_wallet_id"`
+	Amount       int64            `json:"amount"`
+	Condition    ReleaseCondition `json:"condition"`
+	Approvals    []string         `json:"approvals"`
+	Expiry       int64            `json:"expiry"` // Unix seconds; after this the payer can be refunded
+	Status       string           `json:"status"` // Held, Released, Refunded
+	CreatedAt    int64            `json:"created_at"`
+	SettledAt    int64            `json:"settled_at"`
+}
+
+func (c ReleaseCondition) validate(now int64, expiry int64) error {
+	switch c.Type {
+	case ConditionTimeLock:
+		if c.ReleaseAfter <= now || c.ReleaseAfter >= expiry {
+			return fmt.Errorf("time-lock release_after must be in the future and before expiry")
+		}
+	case ConditionArbiter:
+		if c.Arbiter == "" {
+			return fmt.Errorf("arbiter condition requires an arbiter")
+		}
+	case ConditionMultiSig:
+		if c.Threshold <= 0 || c.Threshold > len(c.Signers) {
+			return fmt.Errorf("multi-sig threshold must be between 1 and the number of signers")
+		}
+	default:
+		return fmt.Errorf("unknown release condition %q", c.Type)
+	}
+	return nil
+}
+
+// satisfied reports whether the escrow may be released at time now
+func (e *Escrow) satisfied(now int64) bool {
+	switch e.Condition.Type {
+	case ConditionTimeLock:
+		return now >= e.Condition.ReleaseAfter
+	case ConditionArbiter:
+		return slices.Contains(e.Approvals, e.Condition.Arbiter)
+	case ConditionMultiSig:
+		return len(e.Approvals) >= e.Condition.Threshold
+	}
+	return false
+}
+
+func escrowKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeEscrow, []string{id})
+}
+
+func readEscrow(ctx contractapi.TransactionContextInterface, id string) (*Escrow, error) {
+	key, err := escrowKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	escrowBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read escrow: %v", err)
+	}
+	if escrowBytes == nil {
+		return nil, fmt.Errorf("escrow %s does not exist", id)
+	}
+
+	var escrow Escrow
+	if err := json.Unmarshal(escrowBytes, &escrow); err != nil {
+		return nil, err
+	}
+	return &escrow, nil
+}
+
+// putEscrow saves the escrow and emits it as an EscrowEvent
+func putEscrow(ctx contractapi.TransactionContextInterface, escrow *Escrow) error {
+	key, err := escrowKey(ctx, escrow.ID)
+	if err != nil {
+		return err
+	}
+	escrowBytes, _ := json.Marshal(escrow)
+	if err := ctx.GetStub().PutState(key, escrowBytes); err != nil {
+		return err
+	}
+	return emitEvent(ctx, events.NameEscrow, escrowEvent(escrow))
+}
+
+// CreateEscrow debits the payer and holds the funds on the ledger until releaseConditionJSON
+// is met (ReleaseEscrow) or the escrow expires (RefundEscrow). The escrow ID is the TxID.
+func (s *SmartContract) CreateEscrow(ctx contractapi.TransactionContextInterface, fromWalletID string, toWalletID string, amount int64, releaseConditionJSON string, expiry int64) (*Escrow, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if fromWalletID == toWalletID {
+		return nil, fmt.Errorf("payer and payee must differ")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if expiry <= now.Unix() {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+
+	var condition ReleaseCondition
+	if err := json.Unmarshal([]byte(releaseConditionJSON), &condition); err != nil {
+		return nil, fmt.Errorf("failed to parse release condition: %v", err)
+	}
+	if err := condition.validate(now.Unix(), expiry); err != nil {
+		return nil, err
+	}
+
+	// 1. Get Sender
+	sender, err := readWallet(ctx, fromWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWalletIntermediary(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := sender.canSend(); err != nil {
+		return nil, err
+	}
+	if err := authorizeDebit(ctx, sender, intents.Transfer{To: toWalletID, Escrow: true, Asset: assets.Default, Amount: amount}); err != nil {
+		return nil, err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := checkDebit(ctx, doc, sender, assets.Default, amount, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. The payee must be able to take the funds now; it is checked again on release
+	receiver, err := readWallet(ctx, toWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCredit(doc, receiver, assets.Default, amount); err != nil {
+		return nil, err
+	}
+	if err := checkNotPaused(ctx, OperationTransfer, sender.IntermediaryID, receiver.IntermediaryID); err != nil {
+		return nil, err
+	}
+
+	// 3. Move funds into escrow
+	sender.adjustBalance(assets.Default, -amount)
+	if err := putWallet(ctx, sender); err != nil {
+		return nil, err
+	}
+	if err := putWalletUsage(ctx, usage); err != nil {
+		return nil, err
+	}
+
+	escrow := Escrow{
+		ID:           ctx.GetStub().GetTxID(),
+		FromWalletID: fromWalletID,
+		ToWalletID:   toWalletID,
+		Amount:       amount,
+		Condition:    condition,
+		Approvals:    []string{},
+		Expiry:       expiry,
+		Status:       EscrowHeld,
+		CreatedAt:    now.Unix(),
+	}
+	if err := putEscrow(ctx, &escrow); err != nil {
+		return nil, err
+	}
+
+	tx := Transaction{
+		ID:        escrow.ID,
+		Type:      "EscrowHold",
+		From:      fromWalletID,
+		To:        escrow.ID,
+		Amount:    amount,
+		Timestamp: now.Unix(),
+		Asset:     assets.Default,
+	}
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, err
+	}
+
+	return &escrow, nil
+}
+
+// ApproveEscrow records the caller's approval for an Arbiter or MultiSig escrow
+func (s *SmartContract) ApproveEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	escrow, err := readEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.Status != EscrowHeld {
+		return nil, fmt.Errorf("escrow %s is already %s", escrowID, escrow.Status)
+	}
+
+	callerID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+
+	switch escrow.Condition.Type {
+	case ConditionArbiter:
+		if callerID != escrow.Condition.Arbiter {
+			return nil, fmt.Errorf("unauthorized: only the arbiter can approve this escrow")
+		}
+	case ConditionMultiSig:
+		if !slices.Contains(escrow.Condition.Signers, callerID) {
+			return nil, fmt.Errorf("unauthorized: caller is not a signer of this escrow")
+		}
+	default:
+		return nil, fmt.Errorf("%s escrows do not take approvals", escrow.Condition.Type)
+	}
+
+	if slices.Contains(escrow.Approvals, callerID) {
+		return nil, fmt.Errorf("caller has already approved escrow %s", escrowID)
+	}
+	escrow.Approvals = append(escrow.Approvals, callerID)
+
+	if err := putEscrow(ctx, escrow); err != nil {
+		return nil, err
+	}
+	return escrow, nil
+}
+
+// requireEscrowParty allows the intermediaries of the payer and payee, plus the
+// arbiter and signers named in the release condition
+func requireEscrowParty(ctx contractapi.TransactionContextInterface, escrow *Escrow) error {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return err
+	}
+	if c.ID == escrow.Condition.Arbiter || slices.Contains(escrow.Condition.Signers, c.ID) {
+		return nil
+	}
+	for _, walletID := range []string{escrow.FromWalletID, escrow.ToWalletID} {
+		wallet, err := readWallet(ctx, walletID)
+		if err != nil {
+			return err
+		}
+		if c.manages(wallet) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unauthorized: %s is not a party to escrow %s", c.IntermediaryID, escrow.ID)
+}
+
+// ReleaseEscrow pays the held funds to the payee once the release condition is met
+func (s *SmartContract) ReleaseEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	escrow, err := readEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.Status != EscrowHeld {
+		return nil, fmt.Errorf("escrow %s is already %s", escrowID, escrow.Status)
+	}
+	if err := requireEscrowParty(ctx, escrow); err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now.Unix() >= escrow.Expiry {
+		return nil, fmt.Errorf("escrow %s has expired and can only be refunded", escrowID)
+	}
+	if !escrow.satisfied(now.Unix()) {
+		return nil, fmt.Errorf("release condition for escrow %s is not met", escrowID)
+	}
+
+	receiver, err := readWallet(ctx, escrow.ToWalletID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCredit(doc, receiver, assets.Default, escrow.Amount); err != nil {
+		return nil, err
+	}
+	if err := checkEscrowNotPaused(ctx, escrow); err != nil {
+		return nil, err
+	}
+
+	receiver.adjustBalance(assets.Default, escrow.Amount)
+	if err := putWallet(ctx, receiver); err != nil {
+		return nil, err
+	}
+
+	return settleEscrow(ctx, escrow, EscrowReleased, "EscrowRelease", escrow.ToWalletID, now.Unix())
+}
+
+// RefundEscrow returns the held funds to the payer. Any escrow party may trigger it after
+// expiry; before expiry only the arbiter of an Arbiter escrow can refund (e.g. a dispute upheld).
+func (s *SmartContract) RefundEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	escrow, err := readEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	if escrow.Status != EscrowHeld {
+		return nil, fmt.Errorf("escrow %s is already %s", escrowID, escrow.Status)
+	}
+	if err := requireEscrowParty(ctx, escrow); err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if now.Unix() < escrow.Expiry {
+		callerID, err := ctx.GetClientIdentity().GetID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get client identity: %v", err)
+		}
+		if escrow.Condition.Type != ConditionArbiter || callerID != escrow.Condition.Arbiter {
+			return nil, fmt.Errorf("escrow %s has not expired", escrowID)
+		}
+	}
+
+	// The refund is a credit like any other: a frozen or closed payer cannot take it, and
+	// it must not lift the payer over its tier's balance ceiling
+	sender, err := readWallet(ctx, escrow.FromWalletID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCredit(doc, sender, assets.Default, escrow.Amount); err != nil {
+		return nil, err
+	}
+	if err := checkEscrowNotPaused(ctx, escrow); err != nil {
+		return nil, err
+	}
+	sender.adjustBalance(assets.Default, escrow.Amount)
+	if err := putWallet(ctx, sender); err != nil {
+		return nil, err
+	}
+
+	return settleEscrow(ctx, escrow, EscrowRefunded, "EscrowRefund", escrow.FromWalletID, now.Unix())
+}
+
+// checkEscrowNotPaused fails when transfers are halted for the payer's or payee's intermediary
+func checkEscrowNotPaused(ctx contractapi.TransactionContextInterface, escrow *Escrow) error {
+	intermediaryIDs := []string{}
+	for _, walletID := range []string{escrow.FromWalletID, escrow.ToWalletID} {
+		wallet, err := readWallet(ctx, walletID)
+		if err != nil {
+			return err
+		}
+		intermediaryIDs = append(intermediaryIDs, wallet.IntermediaryID)
+	}
+	return checkNotPaused(ctx, OperationTransfer, intermediaryIDs...)
+}
+
+// settleEscrow closes the escrow and records the payout transaction
+func settleEscrow(ctx contractapi.TransactionContextInterface, escrow *Escrow, status string, txType string, toWalletID string, now int64) (*Escrow, error) {
+	escrow.Status = status
+	escrow.SettledAt = now
+	if err := putEscrow(ctx, escrow); err != nil {
+		return nil, err
+	}
+
+	tx := Transaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Type:      txType,
+		From:      escrow.ID,
+		To:        toWalletID,
+		Amount:    escrow.Amount,
+		Timestamp: now,
+		Asset:     assets.Default,
+	}
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, err
+	}
+	return escrow, nil
+}
+
+// GetEscrow returns an escrow by ID to its parties, the Central Bank and the Regulator
+func (s *SmartContract) GetEscrow(ctx contractapi.TransactionContextInterface, escrowID string) (*Escrow, error) {
+	escrow, err := readEscrow(ctx, escrowID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !c.isSupervisor() {
+		if err := requireEscrowParty(ctx, escrow); err != nil {
+			return nil, err
+		}
+	}
+	return escrow, nil
+}
@@ -0,0 +1,43 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestConformanceEscrow(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "heidi", "Tier1", 10000)
+	l.fund(p, "ivan", "Tier1", 0)
+	l.fund(p, "judd", "Tier0", 48000)
+
+	expiry := strconv.FormatInt(l.clock.Add(24*time.Hour).Unix(), 10)
+	condition, _ := json.Marshal(ReleaseCondition{Type: ConditionArbiter, Arbiter: p.regulator.id})
+
+	l.submit(p.bank, l.escrow("heidi", "judd", 3000), true, "CreateEscrow", addr("heidi"), addr("judd"), "3000", string(condition), expiry) // Above judd's Tier0 ceiling
+	l.submit(p.bank, nil, true, "CreateEscrow", addr("heidi"), addr("ivan"), "4000", string(condition), expiry)
+	l.submit(p.bank, l.pay("heidi", "ivan", 4000), true, "CreateEscrow", addr("heidi"), addr("ivan"), "4000", string(condition), expiry) // A transfer authorization
+
+	var escrow Escrow
+	json.Unmarshal(l.submit(p.bank, l.escrow("heidi", "ivan", 4000), false, "CreateEscrow", addr("heidi"), addr("ivan"), "4000", string(condition), expiry), &escrow)
+	l.submit(p.regulator, nil, false, "ApproveEscrow", escrow.ID)
+	l.submit(p.bank, nil, false, "ReleaseEscrow", escrow.ID)
+
+	json.Unmarshal(l.submit(p.bank, l.escrow("heidi", "ivan", 1000), false, "CreateEscrow", addr("heidi"), addr("ivan"), "1000", string(condition), expiry), &escrow)
+	l.submit(p.regulator, nil, false, "FreezeWallet", addr("heidi"), StatusReasonAMLInvestigation)
+	l.submit(p.regulator, nil, true, "RefundEscrow", escrow.ID)
+	l.submit(p.centralBank, nil, false, "UnfreezeWallet", addr("heidi"), StatusReasonResolved)
+	l.submit(p.regulator, nil, false, "RefundEscrow", escrow.ID)
+
+	if got := l.wallet(addr("ivan")).Balance; got != 4000 {
+		t.Errorf("ivan balance = %d", got)
+	}
+	if got := l.wallet(addr("heidi")).Balance; got != 6000 {
+		t.Errorf("heidi balance = %d", got)
+	}
+}
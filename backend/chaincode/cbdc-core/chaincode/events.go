@@ -0,0 +1,44 @@
+package chaincode
+
+import (
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// emitEvent sets the transaction's chaincode event to a versioned envelope around data.
+// Fabric keeps only the last event set in a transaction, so each mutating function emits once.
+func emitEvent(ctx contractapi.TransactionContextInterface, name string, data any) error {
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	payload, err := events.Marshal(name, ctx.GetStub().GetTxID(), now.Unix(), data)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().SetEvent(name, payload)
+}
+
+func holdEvent(hold *Hold) events.Hold {
+	return events.Hold{
+		HoldID:     hold.ID,
+		WalletID:   hold.WalletID,
+		Amount:     hold.Amount,
+		Purpose:    hold.Purpose,
+		Status:     hold.Status,
+		Captured:   hold.Captured,
+		CapturedTo: hold.CapturedTo,
+		CaseRef:    hold.CaseRef,
+	}
+}
+
+func escrowEvent(escrow *Escrow) events.Escrow {
+	return events.Escrow{
+		EscrowID:     escrow.ID,
+		FromWalletID: escrow.FromWalletID,
+		ToWalletID:   escrow.ToWalletID,
+		Amount:       escrow.Amount,
+		Status:       escrow.Status,
+		Approvals:    len(escrow.Approvals),
+	}
+}
@@ -0,0 +1,85 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformanceEvents(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	// decode checks the last event is name and decodes its typed payload into v
+	decode := func(name string, v any) {
+		t.Helper()
+		if l.lastEvent.Name != name {
+			t.Fatalf("event = %s, want %s", l.lastEvent.Name, name)
+		}
+		if err := l.lastEvent.Decode(v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	l.createWallet(p.bank, "max", "bank-a", "Tier1")
+	var created events.WalletCreated
+	decode(events.NameWalletCreated, &created)
+	if created != (events.WalletCreated{WalletID: addr("max"), IntermediaryID: "bank-a", Tier: "Tier1"}) {
+		t.Errorf("WalletCreated = %+v", created)
+	}
+
+	l.submit(p.centralBank, nil, false, "Issue", "5000", addr("max"))
+	var mint events.Mint
+	decode(events.NameMint, &mint)
+	if mint != (events.Mint{WalletID: addr("max"), Asset: assets.Default, Amount: 5000}) {
+		t.Errorf("Mint = %+v", mint)
+	}
+
+	l.fund(p, "ned", "Tier1", 0)
+	l.submit(p.bank, l.pay("max", "ned", 700), false, "Transfer", addr("max"), addr("ned"), "700")
+	var transfer events.Transfer
+	decode(events.NameTransfer, &transfer)
+	if transfer != (events.Transfer{From: addr("max"), To: addr("ned"), Asset: assets.Default, Amount: 700, PaymentType: fees.P2P}) {
+		t.Errorf("Transfer = %+v", transfer)
+	}
+
+	legs := `[{"to_wallet_id":"` + addr("ned") + `","amount":100},{"to_wallet_id":"` + addr("ned") + `","amount":200}]`
+	l.submit(p.bank, l.signed("max", intents.Transfer{Legs: intents.LegsHash(legs), Amount: 300}), false, "BatchTransfer", addr("max"), legs)
+	var batch events.BatchTransfer
+	decode(events.NameBatchTransfer, &batch)
+	if batch.FromWalletID != addr("max") || batch.LegCount != 2 || batch.TotalAmount != 300 || len(batch.TxIDs) != 2 || batch.TxIDs[0] != l.lastEvent.TxID+"-leg-0" {
+		t.Errorf("BatchTransfer = %+v", batch)
+	}
+
+	l.submit(p.bank, nil, false, "SuspendWallet", addr("ned"), StatusReasonKYCReview)
+	var status events.WalletStatus
+	decode(events.NameWalletStatus, &status)
+	if status != (events.WalletStatus{WalletID: addr("ned"), From: WalletActive, To: WalletSuspended, ReasonCode: StatusReasonKYCReview, Actor: p.bank.id, ActorMSP: MSPBankConsortium}) {
+		t.Errorf("WalletStatus = %+v", status)
+	}
+
+	l.submit(p.bank, nil, false, "UpdateWalletTier", addr("max"), "Tier2", StatusReasonKYCVerified)
+	var tier events.WalletTier
+	decode(events.NameWalletTier, &tier)
+	if tier != (events.WalletTier{WalletID: addr("max"), From: "Tier1", To: "Tier2", ReasonCode: StatusReasonKYCVerified, Actor: p.bank.id, ActorMSP: MSPBankConsortium}) {
+		t.Errorf("WalletTier = %+v", tier)
+	}
+
+	l.submit(p.centralBank, nil, false, "Redeem", "1000", addr("max"))
+	var redeem events.Redeem
+	decode(events.NameRedeem, &redeem)
+	if redeem != (events.Redeem{WalletID: addr("max"), Asset: assets.Default, Amount: 1000}) {
+		t.Errorf("Redeem = %+v", redeem)
+	}
+
+	// Envelopes from a newer schema are refused rather than misread
+	future, _ := json.Marshal(events.Envelope{Name: events.NameMint, Version: events.SchemaVersion + 1, Data: json.RawMessage(`{}`)})
+	if _, err := events.Unmarshal(future); err == nil {
+		t.Error("accepted an envelope from a newer schema")
+	}
+}
@@ -0,0 +1,110 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// GovernanceChaincode publishes the scheme parameters on the same channel
+const GovernanceChaincode = "governance-cc"
+
+// FeeQuote is the fee a transfer would be charged under the current schedule
+type FeeQuote struct {
+	PaymentType       string `json:"payment_type"`
+	Amount            int64  `json:"amount"`
+	Fee               int64  `json:"fee"`
+	CollectorWalletID string `json:"collector_wallet_id"`
+}
+
+// readParams queries governance-cc for the current parameter document. The query runs
+// in this transaction, so the document's key is part of its read set.
+func readParams(ctx contractapi.TransactionContextInterface) (*params.Document, error) {
+	response := ctx.GetStub().InvokeChaincode(GovernanceChaincode, [][]byte{[]byte("GetParams")}, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("failed to read params from %s: %s", GovernanceChaincode, response.Message)
+	}
+
+	var doc params.Document
+	if err := json.Unmarshal(response.Payload, &doc); err != nil {
+		return nil, fmt.Errorf("invalid params: %v", err)
+	}
+	return &doc, nil
+}
+
+// quoteFee prices a payment against the fee schedule in doc
+func quoteFee(doc *params.Document, paymentType string, amount int64) (*FeeQuote, error) {
+	if !slices.Contains(fees.Types, paymentType) {
+		return nil, fmt.Errorf("unknown payment type %q", paymentType)
+	}
+	schedule := &doc.Fees
+	fee, err := schedule.Fee(paymentType, amount)
+	if err != nil {
+		return nil, err
+	}
+	// governance-cc validates schedules, but a negative fee would credit the sender and
+	// debit the collector, so it is never charged
+	if fee < 0 {
+		return nil, fmt.Errorf("fee schedule yields a negative fee %d for %s payments", fee, paymentType)
+	}
+	if fee > 0 && schedule.CollectorWalletID == "" {
+		return nil, fmt.Errorf("fee schedule charges %s payments but has no collector wallet", paymentType)
+	}
+	return &FeeQuote{PaymentType: paymentType, Amount: amount, Fee: fee, CollectorWalletID: schedule.CollectorWalletID}, nil
+}
+
+// collectFee credits the fee of a settled transfer to the collector wallet and records it
+// as a Fee transaction so it appears in both wallets' history. The receiver is passed in
+// because it may be the collector and has already been written in this transaction.
+func collectFee(ctx contractapi.TransactionContextInterface, tx *Transaction, collectorWalletID string, receiver *Wallet) error {
+	collector := receiver
+	if collectorWalletID != receiver.ID {
+		var err error
+		collector, err = readWallet(ctx, collectorWalletID)
+		if err != nil {
+			return fmt.Errorf("fee collector: %v", err)
+		}
+		if err := collector.canReceive(); err != nil {
+			return err
+		}
+	}
+	collector.adjustBalance(assets.Default, tx.Fee)
+	if err := putWallet(ctx, collector); err != nil {
+		return err
+	}
+
+	feeTx := Transaction{
+		ID:          tx.ID + "-fee",
+		Type:        "Fee",
+		From:        tx.From,
+		To:          collectorWalletID,
+		Amount:      tx.Fee,
+		Timestamp:   tx.Timestamp,
+		Asset:       assets.Default,
+		PaymentType: tx.PaymentType,
+	}
+	_, err := putTransaction(ctx, &feeTx)
+	return err
+}
+
+// QuoteFee returns the fee a transfer of amount would be charged for a payment type.
+// Intermediaries quote it to their customers before paying.
+func (s *SmartContract) QuoteFee(ctx contractapi.TransactionContextInterface, paymentType string, amount int64) (*FeeQuote, error) {
+	if _, err := requireMSP(ctx, "fee quotes", MSPCentralBank, MSPRegulator, MSPBankConsortium); err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return quoteFee(doc, paymentType, amount)
+}
@@ -0,0 +1,48 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+)
+
+func TestConformanceFees(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Fees = fees.Schedule{
+		CollectorWalletID: addr("fees"),
+		Default:           fees.Rule{Kind: fees.KindFlat, Flat: 5},
+		Rules: map[string]fees.Rule{
+			fees.P2P: {Kind: fees.KindExempt},
+			fees.P2B: {Kind: fees.KindPercentage, BasisPoints: 100, Min: 10},
+			fees.B2B: {Kind: fees.KindTiered, Bands: []fees.Band{{UpTo: 5000, Flat: 20}, {BasisPoints: 50}}},
+		},
+	}
+
+	l.fund(p, "mike", "Tier1", 100000)
+	l.fund(p, "shop", "Tier2", 0)
+	l.fund(p, "fees", "Tier2", 0)
+
+	l.submit(p.bank, l.pay("mike", "shop", 1000), false, "Transfer", addr("mike"), addr("shop"), "1000")                // P2P is exempt
+	l.submit(p.bank, l.pay("mike", "shop", 500), false, "TransferWithType", addr("mike"), addr("shop"), "500", "P2B")   // 1% is below the 10 minimum
+	l.submit(p.bank, l.pay("mike", "shop", 3000), false, "TransferWithType", addr("mike"), addr("shop"), "3000", "P2B") // 1% = 30
+	l.submit(p.bank, l.pay("shop", "mike", 4000), false, "TransferWithType", addr("shop"), addr("mike"), "4000", "B2B") // First band, flat 20
+	l.submit(p.bank, l.pay("mike", "fees", 100), false, "TransferWithType", addr("mike"), addr("fees"), "100", "G2P")   // Default flat 5, paid to the collector
+	l.submit(p.bank, l.pay("mike", "shop", 100), true, "TransferWithType", addr("mike"), addr("shop"), "100", "X2Y")
+	l.governance.doc.Fees.Rules[fees.B2P] = fees.Rule{Kind: fees.KindFlat, Flat: -50}
+	l.submit(p.bank, l.pay("shop", "mike", 100), true, "TransferWithType", addr("shop"), addr("mike"), "100", "B2P") // A negative fee is never paid out
+
+	if got := l.wallet(addr("fees")).Balance; got != 10+30+20+100+5 {
+		t.Errorf("collector balance = %d", got)
+	}
+	if got := l.wallet(addr("mike")).Balance; got != 100000-1000-510-3030+4000-105 {
+		t.Errorf("mike balance = %d", got)
+	}
+	var tx Transaction
+	json.Unmarshal(l.stub.State["tx0007"], &tx)
+	if tx.Fee != 30 || tx.PaymentType != fees.P2B {
+		t.Errorf("transaction fee = %d, type = %s", tx.Fee, tx.PaymentType)
+	}
+}
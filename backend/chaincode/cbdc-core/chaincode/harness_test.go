@@ -0,0 +1,540 @@
+package chaincode
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The conformance suite endorses every mutating function twice against the same
+// committed state, as two peers would, and requires identical responses, write sets
+// and events. The transaction timestamp is served by the mock stub from the ledger
+// clock; TestNoPeerClock rejects any use of the peer clock in the chaincode itself.
+
+// endorsingStub simulates a single endorsement: reads come from committed state and
+// writes are collected instead of applied, like a Fabric read/write set.
+type endorsingStub struct {
+	*shimtest.MockStub
+	fn        string
+	args      []string
+	transient map[string][]byte
+
+	writes        map[string][]byte
+	privateWrites map[string][]byte
+	eventName     string
+	eventPayload  []byte
+}
+
+func (s *endorsingStub) GetFunctionAndParameters() (string, []string) {
+	return s.fn, s.args
+}
+
+func (s *endorsingStub) GetArgs() [][]byte {
+	args := [][]byte{[]byte(s.fn)}
+	for _, arg := range s.args {
+		args = append(args, []byte(arg))
+	}
+	return args
+}
+
+func (s *endorsingStub) GetStringArgs() []string {
+	return append([]string{s.fn}, s.args...)
+}
+
+func (s *endorsingStub) GetTransient() (map[string][]byte, error) {
+	return s.transient, nil
+}
+
+func (s *endorsingStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+func (s *endorsingStub) DelState(key string) error {
+	s.writes[key] = nil
+	return nil
+}
+
+func (s *endorsingStub) PutPrivateData(collection string, key string, value []byte) error {
+	s.privateWrites[collection+"/"+key] = value
+	return nil
+}
+
+// GetPrivateDataHash serves the hash of committed private data, which MockStub does not implement
+func (s *endorsingStub) GetPrivateDataHash(collection string, key string) ([]byte, error) {
+	value, ok := s.PvtState[collection][key]
+	if !ok {
+		return nil, nil
+	}
+	sum := sha256.Sum256(value)
+	return sum[:], nil
+}
+
+// GetStateByPartialCompositeKeyWithPagination pages through committed state as a peer
+// does, which MockStub does not implement. The bookmark is the first key of the next
+// page, and empty after the last page.
+func (s *endorsingStub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	all, err := s.GetStateByPartialCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer all.Close()
+
+	page := &pageIterator{}
+	metadata := &peer.QueryResponseMetadata{}
+	for all.HasNext() {
+		kv, err := all.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kv.Key < bookmark {
+			continue
+		}
+		if int32(len(page.kvs)) == pageSize {
+			metadata.Bookmark = kv.Key
+			break
+		}
+		page.kvs = append(page.kvs, kv)
+	}
+	metadata.FetchedRecordsCount = int32(len(page.kvs))
+	return page, metadata, nil
+}
+
+// pageIterator serves one page of query results
+type pageIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (it *pageIterator) HasNext() bool {
+	return len(it.kvs) > 0
+}
+
+func (it *pageIterator) Next() (*queryresult.KV, error) {
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func (it *pageIterator) Close() error {
+	return nil
+}
+
+func (s *endorsingStub) SetEvent(name string, payload []byte) error {
+	s.eventName = name
+	s.eventPayload = payload
+	return nil
+}
+
+// endorsement is what a peer returns for a proposal
+type endorsement struct {
+	status        int32
+	message       string
+	payload       []byte
+	writes        map[string][]byte
+	privateWrites map[string][]byte
+	eventName     string
+	eventPayload  []byte
+}
+
+// identity is a client certificate with Fabric CA attributes
+type identity struct {
+	creator []byte
+	id      string
+}
+
+type ledger struct {
+	t          *testing.T
+	cc         *contractapi.ContractChaincode
+	stub       *shimtest.MockStub
+	governance *governanceStub
+	clock      time.Time // Transaction timestamps, advanced one minute per transaction
+	txCount    int
+	lastEvent  *events.Envelope // Event of the last committed transaction
+}
+
+// governanceStub stands in for governance-cc and serves a fixed parameter document
+type governanceStub struct {
+	doc   params.Document
+	reads int // GetParams calls served
+}
+
+func (g *governanceStub) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (g *governanceStub) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if fn, _ := stub.GetFunctionAndParameters(); fn != "GetParams" {
+		return shim.Error("unexpected governance call " + fn)
+	}
+	g.reads++
+	docBytes, _ := json.Marshal(g.doc)
+	return shim.Success(docBytes)
+}
+
+func newLedger(t *testing.T) *ledger {
+	t.Helper()
+	cc, err := contractapi.NewChaincode(&SmartContract{})
+	if err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+	stub := shimtest.NewMockStub("cbdc-core", cc)
+	governance := &governanceStub{doc: params.Default()}
+	stub.MockPeerChaincode(GovernanceChaincode, shimtest.NewMockStub(GovernanceChaincode, governance), "")
+	return &ledger{
+		t:          t,
+		cc:         cc,
+		stub:       stub,
+		governance: governance,
+		clock:      time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func newIdentity(t *testing.T, mspID string, attrs map[string]string) identity {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrsJSON, _ := json.Marshal(map[string]map[string]string{"attrs": attrs})
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("%s-%s-%s", mspID, attrs[AttrRole], attrs[AttrIntermediaryID])},
+		NotBefore:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}, Value: attrsJSON},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := shimtest.NewMockStub("identity", nil)
+	stub.Creator = creator
+	client, err := cid.New(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := client.GetID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity{creator: creator, id: id}
+}
+
+func (l *ledger) endorse(caller identity, txID string, transient map[string][]byte, fn string, args []string) endorsement {
+	stub := &endorsingStub{
+		MockStub:      l.stub,
+		fn:            fn,
+		args:          args,
+		transient:     transient,
+		writes:        map[string][]byte{},
+		privateWrites: map[string][]byte{},
+	}
+	l.stub.TxID = txID
+	l.stub.TxTimestamp = timestamppb.New(l.clock)
+	l.stub.Creator = caller.creator
+	response := l.cc.Invoke(stub)
+	return endorsement{
+		status:        response.Status,
+		message:       response.Message,
+		payload:       response.Payload,
+		writes:        stub.writes,
+		privateWrites: stub.privateWrites,
+		eventName:     stub.eventName,
+		eventPayload:  stub.eventPayload,
+	}
+}
+
+// submit endorses fn twice, checks both endorsements match, commits the write set and
+// returns the response payload. wantErr expects the proposal to be rejected.
+func (l *ledger) submit(caller identity, transient map[string][]byte, wantErr bool, fn string, args ...string) []byte {
+	l.t.Helper()
+	l.txCount++
+	l.clock = l.clock.Add(time.Minute)
+	txID := fmt.Sprintf("tx%04d", l.txCount)
+
+	first := l.endorse(caller, txID, transient, fn, args)
+	second := l.endorse(caller, txID, transient, fn, args)
+
+	if wantErr {
+		if first.status == 200 {
+			l.t.Fatalf("%s: expected an error, got success", fn)
+		}
+		return nil
+	}
+	if first.status != 200 {
+		l.t.Fatalf("%s failed: %s", fn, first.message)
+	}
+	compareEndorsements(l.t, fn, first, second)
+	if first.eventName == "" {
+		l.t.Errorf("%s: emitted no event", fn)
+	}
+	env, err := events.Unmarshal(first.eventPayload)
+	if err != nil || env.Name != first.eventName || env.Version != events.SchemaVersion || env.TxID != txID || env.Timestamp != l.clock.Unix() {
+		l.t.Errorf("%s: %s is not a current envelope for this transaction: %s", fn, first.eventName, first.eventPayload)
+	}
+	l.lastEvent = env
+
+	for key, value := range first.writes {
+		if value == nil {
+			l.stub.DelState(key)
+		} else {
+			l.stub.PutState(key, value)
+		}
+	}
+	for key, value := range first.privateWrites {
+		collection, key, _ := strings.Cut(key, "/")
+		l.stub.PutPrivateData(collection, key, value)
+	}
+	return first.payload
+}
+
+// replay endorses a retried fn twice and requires it to succeed without writes or an
+// event, returning the response payload
+func (l *ledger) replay(caller identity, transient map[string][]byte, fn string, args ...string) []byte {
+	l.t.Helper()
+	l.txCount++
+	txID := fmt.Sprintf("tx%04d", l.txCount)
+
+	first := l.endorse(caller, txID, transient, fn, args)
+	second := l.endorse(caller, txID, transient, fn, args)
+	if first.status != 200 {
+		l.t.Fatalf("%s replay failed: %s", fn, first.message)
+	}
+	compareEndorsements(l.t, fn, first, second)
+	if len(first.writes) > 0 || first.eventName != "" {
+		l.t.Errorf("%s replay wrote %d keys and emitted %q", fn, len(first.writes), first.eventName)
+	}
+	return first.payload
+}
+
+func (l *ledger) wallet(id string) Wallet {
+	l.t.Helper()
+	var wallet Wallet
+	if err := json.Unmarshal(l.stub.State[id], &wallet); err != nil {
+		l.t.Fatalf("wallet %s: %v", id, err)
+	}
+	return wallet
+}
+
+// usage returns a wallet's stored spend record; the zero record when it has not spent yet
+func (l *ledger) usage(id string) WalletUsage {
+	l.t.Helper()
+	key, _ := shim.CreateCompositeKey(DocTypeUsage, []string{id})
+	var usage WalletUsage
+	if usageBytes := l.stub.State[key]; usageBytes != nil {
+		if err := json.Unmarshal(usageBytes, &usage); err != nil {
+			l.t.Fatalf("usage %s: %v", id, err)
+		}
+	}
+	return usage
+}
+
+func compareEndorsements(t *testing.T, fn string, first, second endorsement) {
+	t.Helper()
+	if !bytes.Equal(first.payload, second.payload) {
+		t.Errorf("%s: responses differ:\n%s\n%s", fn, first.payload, second.payload)
+	}
+	compareWrites(t, fn, "write set", first.writes, second.writes)
+	compareWrites(t, fn, "private write set", first.privateWrites, second.privateWrites)
+	if first.eventName != second.eventName || !bytes.Equal(first.eventPayload, second.eventPayload) {
+		t.Errorf("%s: events differ:\n%s %s\n%s %s", fn, first.eventName, first.eventPayload, second.eventName, second.eventPayload)
+	}
+}
+
+func compareWrites(t *testing.T, fn string, kind string, first, second map[string][]byte) {
+	t.Helper()
+	keys := map[string]bool{}
+	for key := range first {
+		keys[key] = true
+	}
+	for key := range second {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		if !bytes.Equal(first[key], second[key]) {
+			t.Errorf("%s: %s differs at %q:\n%s\n%s", fn, kind, key, first[key], second[key])
+		}
+	}
+}
+
+// participants of the conformance scenarios
+type participants struct {
+	centralBank identity
+	regulator   identity
+	bank        identity
+	otherBank   identity
+}
+
+func newParticipants(t *testing.T) participants {
+	return participants{
+		centralBank: newIdentity(t, MSPCentralBank, map[string]string{AttrRole: RoleAdmin}),
+		regulator:   newIdentity(t, MSPRegulator, map[string]string{}),
+		bank:        newIdentity(t, MSPBankConsortium, map[string]string{AttrIntermediaryID: "bank-a"}),
+		otherBank:   newIdentity(t, MSPBankConsortium, map[string]string{AttrIntermediaryID: "bank-b"}),
+	}
+}
+
+// ownerKey is the deterministic wallet owner key of a named test participant
+func ownerKey(name string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte("owner/" + name))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// ownerKeyHex is the public owner key registered for a named participant's wallet
+func ownerKeyHex(name string) string {
+	return hex.EncodeToString(ownerKey(name).Public().(ed25519.PublicKey))
+}
+
+// addr returns the wallet address of a named test participant
+func addr(name string) string {
+	return addresses.FromPublicKey(ownerKey(name).Public().(ed25519.PublicKey))
+}
+
+// signed returns the transient owner authorization for the named sender's next debit,
+// merged into any other transient entries
+func (l *ledger) signed(from string, intent intents.Transfer, transient ...map[string][]byte) map[string][]byte {
+	l.t.Helper()
+	intent.From = addr(from)
+	intent.Nonce = l.wallet(intent.From).Nonce + 1
+	if intent.Asset == "" {
+		intent.Asset = assets.Default
+	}
+	authorization, _ := json.Marshal(intents.Sign(ownerKey(from), intent))
+
+	merged := map[string][]byte{intents.TransientKey: authorization}
+	for _, entries := range transient {
+		for key, value := range entries {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// pay returns the authorization for an NGN transfer between named participants
+func (l *ledger) pay(from string, to string, amount int64, transient ...map[string][]byte) map[string][]byte {
+	l.t.Helper()
+	return l.signed(from, intents.Transfer{To: addr(to), Amount: amount}, transient...)
+}
+
+// hold returns the authorization for placing an NGN hold on a named participant's wallet
+func (l *ledger) hold(from string, holdID string, amount int64) map[string][]byte {
+	l.t.Helper()
+	return l.signed(from, intents.Transfer{Hold: holdID, Amount: amount})
+}
+
+// capture returns the authorization for capturing a named participant's hold to another
+func (l *ledger) capture(from string, holdID string, to string, amount int64) map[string][]byte {
+	l.t.Helper()
+	return l.signed(from, intents.Transfer{To: addr(to), Hold: holdID, Amount: amount})
+}
+
+// escrow returns the authorization for an NGN escrow between named participants
+func (l *ledger) escrow(from string, to string, amount int64) map[string][]byte {
+	l.t.Helper()
+	return l.signed(from, intents.Transfer{To: addr(to), Escrow: true, Amount: amount})
+}
+
+// ownerSalt is the fixed attribute salt of test wallets
+const ownerSalt = "0123456789abcdef"
+
+// createWallet opens the wallet of a named owner for an intermediary
+func (l *ledger) createWallet(caller identity, name string, intermediaryID string, tier string) {
+	l.t.Helper()
+	owner, _ := json.Marshal(WalletOwner{Attributes: []WalletAttribute{
+		{Name: AttributeOwnerID, Value: "owner-" + name, Salt: ownerSalt},
+	}})
+	l.submit(caller, map[string][]byte{TransientWalletOwner: owner}, false, "CreateWallet", addr(name), intermediaryID, tier, ownerKeyHex(name))
+}
+
+// fund creates a bank-a wallet for a named owner and issues amount to it
+func (l *ledger) fund(p participants, name string, tier string, amount int64) {
+	l.t.Helper()
+	l.createWallet(p.bank, name, "bank-a", tier)
+	if amount > 0 {
+		l.submit(p.centralBank, nil, false, "Issue", strconv.FormatInt(amount, 10), addr(name))
+	}
+}
+
+// deviceSeed derives a fixed Ed25519 seed so device keys are reproducible
+func deviceSeed(name string) []byte {
+	seed := make([]byte, ed25519.SeedSize)
+	copy(seed, name)
+	return seed
+}
+
+// TestNoPeerClock fails if the chaincode reads the peer's wall clock. Endorsing peers
+// disagree on it, so every time must come from the transaction timestamp (txTime).
+func TestNoPeerClock(t *testing.T) {
+	t.Parallel()
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(n ast.Node) bool {
+				sel, ok := n.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == "time" && (sel.Sel.Name == "Now" || sel.Sel.Name == "Since" || sel.Sel.Name == "Until") {
+					t.Errorf("%s: time.%s reads the peer clock; use txTime", fset.Position(sel.Pos()), sel.Sel.Name)
+				}
+				return true
+			})
+		}
+	}
+}
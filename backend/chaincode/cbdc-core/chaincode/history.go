@@ -0,0 +1,102 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// putTransaction saves a transaction under its ID and indexes it for both
+// parties under walletID~timestamp~txID, returning the serialized record
+func putTransaction(ctx contractapi.TransactionContextInterface, tx *Transaction) ([]byte, error) {
+	txBytes, _ := json.Marshal(tx)
+	if err := ctx.GetStub().PutState(tx.ID, txBytes); err != nil {
+		return nil, err
+	}
+
+	parties := []string{tx.From}
+	if tx.To != tx.From {
+		parties = append(parties, tx.To)
+	}
+
+	// Zero-pad the timestamp so the index sorts chronologically
+	ts := fmt.Sprintf("%020d", tx.Timestamp)
+	for _, walletID := range parties {
+		indexKey, err := ctx.GetStub().CreateCompositeKey(DocTypeWalletTx, []string{walletID, ts, tx.ID})
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.GetStub().PutState(indexKey, txBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	return txBytes, nil
+}
+
+// readTransaction loads a transaction record by ID
+func readTransaction(ctx contractapi.TransactionContextInterface, id string) (*Transaction, error) {
+	txBytes, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, err
+	}
+	if txBytes == nil {
+		return nil, fmt.Errorf("transaction %s does not exist", id)
+	}
+
+	var tx Transaction
+	if err := json.Unmarshal(txBytes, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetWalletHistory returns a page of transactions involving a wallet, oldest first.
+// Pass the bookmark from the previous page to continue; an empty bookmark starts from the beginning.
+func (s *SmartContract) GetWalletHistory(ctx contractapi.TransactionContextInterface, walletID string, pageSize int32, bookmark string) (*HistoryPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size must be positive")
+	}
+
+	// Supervisors can audit any party; intermediaries only their own wallets
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !c.isSupervisor() {
+		wallet, err := readWallet(ctx, walletID)
+		if err != nil {
+			return nil, err
+		}
+		if !c.manages(wallet) {
+			return nil, fmt.Errorf("unauthorized: wallet %s is not visible to %s", walletID, c.IntermediaryID)
+		}
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(DocTypeWalletTx, []string{walletID}, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	page := HistoryPage{Records: []*Transaction{}}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var tx Transaction
+		if err := json.Unmarshal(result.Value, &tx); err != nil {
+			return nil, err
+		}
+		page.Records = append(page.Records, &tx)
+	}
+
+	if metadata != nil {
+		page.FetchedCount = metadata.FetchedRecordsCount
+		page.Bookmark = metadata.Bookmark
+	}
+	return &page, nil
+}
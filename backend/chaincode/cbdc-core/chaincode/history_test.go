@@ -0,0 +1,69 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestConformanceWalletHistory(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "kit", "Tier1", 10000)
+	l.fund(p, "lee", "Tier1", 0)
+	for _, amount := range []int64{100, 200, 300, 400} {
+		l.submit(p.bank, l.pay("kit", "lee", amount), false, "Transfer", addr("kit"), addr("lee"), strconv.FormatInt(amount, 10))
+	}
+
+	history := func(reader identity, walletID string, pageSize int, bookmark string) (HistoryPage, bool) {
+		got := l.endorse(reader, "query", nil, "GetWalletHistory", []string{walletID, strconv.Itoa(pageSize), bookmark})
+		var page HistoryPage
+		json.Unmarshal(got.payload, &page)
+		return page, got.status == 200
+	}
+
+	// kit's issuance and four payments come back oldest first, two to a page
+	var amounts []int64
+	var timestamps []int64
+	bookmark := ""
+	for pages := 1; ; pages++ {
+		page, ok := history(p.bank, addr("kit"), 2, bookmark)
+		if !ok || pages > 3 {
+			t.Fatalf("page %d: ok %t", pages, ok)
+		}
+		if page.FetchedCount != int32(len(page.Records)) || len(page.Records) > 2 {
+			t.Errorf("page %d: fetched %d of %d records", pages, page.FetchedCount, len(page.Records))
+		}
+		for _, tx := range page.Records {
+			amounts = append(amounts, tx.Amount)
+			timestamps = append(timestamps, tx.Timestamp)
+		}
+		if bookmark = page.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if want := []int64{10000, 100, 200, 300, 400}; !slices.Equal(amounts, want) {
+		t.Errorf("kit history amounts = %v, want %v", amounts, want)
+	}
+	if !slices.IsSorted(timestamps) {
+		t.Errorf("kit history is out of order: %v", timestamps)
+	}
+
+	// The payee sees the same payments, and a page larger than the history holds all of them
+	if page, _ := history(p.bank, addr("lee"), 10, ""); len(page.Records) != 4 || page.Bookmark != "" || page.Records[0].To != addr("lee") {
+		t.Errorf("lee history = %d records, bookmark %q", len(page.Records), page.Bookmark)
+	}
+
+	if _, ok := history(p.regulator, addr("kit"), 2, ""); !ok {
+		t.Error("the regulator could not audit kit")
+	}
+	if _, ok := history(p.otherBank, addr("kit"), 2, ""); ok {
+		t.Error("another bank read kit's history")
+	}
+	if _, ok := history(p.bank, addr("kit"), 0, ""); ok {
+		t.Error("a zero page size was accepted")
+	}
+}
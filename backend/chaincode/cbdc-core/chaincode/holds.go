@@ -0,0 +1,396 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DocTypeHold keys holds in world state
+const DocTypeHold = "HOLD"
+
+// Hold purposes
+const (
+	HoldPurposeOfflineFunding = "OfflineFunding" // Funds backing a device's offline purse
+	HoldPurposeAuthorization  = "Authorization"  // Card-style pre-authorization
+	HoldPurposeLien           = "Lien"           // Court-ordered or regulatory lien
+)
+
+// Hold statuses
+const (
+	HoldActive   = "Active"
+	HoldReleased = "Released"
+	HoldCaptured = "Captured"
+)
+
+// Hold reserves part of a wallet's balance. Held funds stay in Balance but are
+// excluded from Available until the hold is released or captured.
+type Hold struct {
+	ID         string `json:"id"`
+	WalletID   string `json:"wallet_id"`
+	Amount     int64  `json:"amount"` // Amount still reserved
+	Purpose    string `json:"purpose"`
+	Status     string `json:"status"` // Active, Released, Captured
+	CapturedTo string `json:"captured_to"`
+	Captured   int64  `json:"captured"`                                // Total amount captured
+	CaseRef    string `json:"case_ref,omitempty" metadata:",optional"` // Court or regulatory case behind a lien
+	CreatedBy  string `json:"created_by"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+// offlineHoldID is the hold backing a device's offline purse
+func offlineHoldID(deviceID string) string {
+	return "offline-" + deviceID
+}
+
+func holdKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeHold, []string{id})
+}
+
+// readHold returns nil when no hold exists with the ID
+func readHold(ctx contractapi.TransactionContextInterface, id string) (*Hold, error) {
+	key, err := holdKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	holdBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hold: %v", err)
+	}
+	if holdBytes == nil {
+		return nil, nil
+	}
+
+	var hold Hold
+	if err := json.Unmarshal(holdBytes, &hold); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+func readActiveHold(ctx contractapi.TransactionContextInterface, id string) (*Hold, error) {
+	hold, err := readHold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, fmt.Errorf("hold %s does not exist", id)
+	}
+	if hold.Status != HoldActive {
+		return nil, fmt.Errorf("hold %s is already %s", id, hold.Status)
+	}
+	return hold, nil
+}
+
+func putHold(ctx contractapi.TransactionContextInterface, hold *Hold) error {
+	key, err := holdKey(ctx, hold.ID)
+	if err != nil {
+		return err
+	}
+	holdBytes, _ := json.Marshal(hold)
+	return ctx.GetStub().PutState(key, holdBytes)
+}
+
+// checkHoldAuthority restricts liens to the Central Bank and Regulator and
+// every other hold to the intermediary that manages the wallet
+func checkHoldAuthority(ctx contractapi.TransactionContextInterface, purpose string, wallet *Wallet) error {
+	if purpose != HoldPurposeLien {
+		return requireWalletIntermediary(ctx, wallet)
+	}
+	if _, err := requireMSP(ctx, "managing liens", MSPCentralBank, MSPRegulator); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PlaceHold reserves amount of the wallet's available balance under holdID.
+// Placing an OfflineFunding hold that is already active on the same wallet tops it up,
+// so a device purse can be funded repeatedly under one hold.
+func (s *SmartContract) PlaceHold(ctx contractapi.TransactionContextInterface, walletID string, amount int64, purpose string, holdID string) (*Hold, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if holdID == "" {
+		return nil, fmt.Errorf("hold ID is required")
+	}
+	switch purpose {
+	case HoldPurposeOfflineFunding, HoldPurposeAuthorization, HoldPurposeLien:
+	default:
+		return nil, fmt.Errorf("unknown hold purpose %q", purpose)
+	}
+	return placeHold(ctx, walletID, amount, purpose, holdID, "")
+}
+
+// placeHold reserves amount on the wallet after checking the caller may place a hold of this purpose
+func placeHold(ctx contractapi.TransactionContextInterface, walletID string, amount int64, purpose string, holdID string, caseRef string) (*Hold, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHoldAuthority(ctx, purpose, wallet); err != nil {
+		return nil, err
+	}
+	// Liens can be placed on frozen or suspended wallets; other holds need a wallet that can
+	// send and the owner's authorization, as they reserve funds the intermediary can capture
+	if purpose != HoldPurposeLien {
+		if err := wallet.canSend(); err != nil {
+			return nil, err
+		}
+		if err := authorizeDebit(ctx, wallet, intents.Transfer{Hold: holdID, Asset: assets.Default, Amount: amount}); err != nil {
+			return nil, err
+		}
+	}
+	if wallet.available() < amount {
+		return nil, fmt.Errorf("insufficient available funds: available %d, requested %d (%d already held)", wallet.available(), amount, wallet.Held)
+	}
+
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold != nil && (hold.Status != HoldActive || hold.WalletID != walletID || hold.Purpose != HoldPurposeOfflineFunding || purpose != HoldPurposeOfflineFunding) {
+		return nil, fmt.Errorf("hold %s already exists", holdID)
+	}
+	// An OfflineFunding hold is the device purse's balance, capped by governance
+	if purpose == HoldPurposeOfflineFunding {
+		doc, err := readParams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reserved := amount
+		if hold != nil {
+			reserved += hold.Amount
+		}
+		if err := checkOfflineBalance(doc, reserved); err != nil {
+			return nil, err
+		}
+	}
+	if hold != nil {
+		hold.Amount += amount
+	} else {
+		creator, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MSP ID: %v", err)
+		}
+		hold = &Hold{
+			ID:        holdID,
+			WalletID:  walletID,
+			Amount:    amount,
+			Purpose:   purpose,
+			Status:    HoldActive,
+			CaseRef:   caseRef,
+			CreatedBy: creator,
+			CreatedAt: now.Unix(),
+		}
+	}
+	hold.UpdatedAt = now.Unix()
+
+	wallet.Held += amount
+	if err := putWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+	if err := putHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, events.NameHold, holdEvent(hold)); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// ReleaseHold returns the reserved funds to the wallet's available balance. An
+// OfflineFunding hold is released only once its device can no longer spend it. Holds other
+// than liens are released by the wallet's intermediary, which voids an Authorization hold
+// for the merchant's side only, never at the payer's request.
+func (s *SmartContract) ReleaseHold(ctx contractapi.TransactionContextInterface, holdID string) (*Hold, error) {
+	hold, err := readActiveHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := readWallet(ctx, hold.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHoldAuthority(ctx, hold.Purpose, wallet); err != nil {
+		return nil, err
+	}
+	if hold.Purpose == HoldPurposeOfflineFunding {
+		if err := checkOfflineHoldRelease(ctx, hold); err != nil {
+			return nil, err
+		}
+	}
+	wallet.Held -= hold.Amount
+	if err := putWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	hold.Amount = 0
+	hold.Status = HoldReleased
+	hold.UpdatedAt = now.Unix()
+	if err := putHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, events.NameHold, holdEvent(hold)); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// CaptureHold pays amount of the held funds to toWalletID and releases any remainder.
+// Other than a lien, a capture is the wallet's payment to toWalletID: it counts towards the
+// owner's tier spend limits and is charged the merchant (P2B) fee, which is paid from the
+// available balance.
+func (s *SmartContract) CaptureHold(ctx contractapi.TransactionContextInterface, holdID string, toWalletID string, amount int64) (*Hold, error) {
+	hold, err := readActiveHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 || amount > hold.Amount {
+		return nil, fmt.Errorf("capture amount must be between 1 and the held amount %d", hold.Amount)
+	}
+	if toWalletID == hold.WalletID {
+		return nil, fmt.Errorf("cannot capture a hold to its own wallet")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := readWallet(ctx, hold.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkHoldAuthority(ctx, hold.Purpose, wallet); err != nil {
+		return nil, err
+	}
+	// The owner authorizes the payee of a capture from a wallet that can send; liens are
+	// captured by order whatever the wallet's status
+	if hold.Purpose != HoldPurposeLien {
+		if err := wallet.canSend(); err != nil {
+			return nil, err
+		}
+		if err := authorizeDebit(ctx, wallet, intents.Transfer{To: toWalletID, Hold: holdID, Asset: assets.Default, Amount: amount}); err != nil {
+			return nil, err
+		}
+	}
+	receiver, err := readWallet(ctx, toWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := receiver.canReceive(); err != nil {
+		return nil, err
+	}
+	if err := checkNotPaused(ctx, OperationTransfer, wallet.IntermediaryID, receiver.IntermediaryID); err != nil {
+		return nil, err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBalanceCeiling(doc, receiver, amount); err != nil {
+		return nil, err
+	}
+	// Liens are captured by order, outside the owner's limits and free of charge
+	quote := &FeeQuote{Amount: amount}
+	var usage *WalletUsage
+	if hold.Purpose != HoldPurposeLien {
+		if quote, err = quoteFee(doc, fees.P2B, amount); err != nil {
+			return nil, err
+		}
+		if quote.CollectorWalletID == wallet.ID {
+			quote.Fee = 0
+		}
+		// The capture releases the rest of the hold, so it can go towards the fee
+		if wallet.available()+hold.Amount-amount < quote.Fee {
+			return nil, fmt.Errorf("insufficient available funds for the capture fee %d", quote.Fee)
+		}
+		if usage, err = checkSpendLimits(ctx, doc, wallet, amount+quote.Fee); err != nil {
+			return nil, err
+		}
+	}
+
+	wallet.Held -= hold.Amount
+	wallet.Balance -= amount + quote.Fee
+	receiver.Balance += amount
+	if err := putWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+	if err := putWallet(ctx, receiver); err != nil {
+		return nil, err
+	}
+	if usage != nil {
+		if err := putWalletUsage(ctx, usage); err != nil {
+			return nil, err
+		}
+	}
+
+	hold.Amount = 0
+	hold.Captured += amount
+	hold.CapturedTo = toWalletID
+	hold.Status = HoldCaptured
+	hold.UpdatedAt = now.Unix()
+	if err := putHold(ctx, hold); err != nil {
+		return nil, err
+	}
+
+	tx := Transaction{
+		ID:          ctx.GetStub().GetTxID(),
+		Type:        "HoldCapture",
+		From:        hold.WalletID,
+		To:          toWalletID,
+		Amount:      amount,
+		Timestamp:   now.Unix(),
+		Asset:       assets.Default,
+		PaymentType: quote.PaymentType,
+		Fee:         quote.Fee,
+	}
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, err
+	}
+	if quote.Fee > 0 {
+		if err := collectFee(ctx, &tx, quote.CollectorWalletID, receiver); err != nil {
+			return nil, err
+		}
+	}
+	if err := emitEvent(ctx, events.NameHold, holdEvent(hold)); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// GetHold returns a hold by ID
+func (s *SmartContract) GetHold(ctx contractapi.TransactionContextInterface, holdID string) (*Hold, error) {
+	hold, err := readHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		return nil, fmt.Errorf("hold %s does not exist", holdID)
+	}
+	wallet, err := readWallet(ctx, hold.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWalletReader(ctx, wallet); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
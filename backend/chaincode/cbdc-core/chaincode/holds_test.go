@@ -0,0 +1,93 @@
+package chaincode
+
+import (
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
+)
+
+func TestConformanceHoldsAndLiens(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "frank", "Tier1", 10000)
+	l.fund(p, "grace", "Tier1", 0)
+
+	l.submit(p.bank, nil, true, "PlaceHold", addr("frank"), "2000", HoldPurposeAuthorization, "auth-1") // Not authorized by frank
+	l.submit(p.bank, l.hold("frank", "auth-1", 2000), false, "PlaceHold", addr("frank"), "2000", HoldPurposeAuthorization, "auth-1")
+	l.submit(p.bank, nil, true, "CaptureHold", "auth-1", addr("grace"), "1500")
+	l.submit(p.bank, l.capture("frank", "auth-1", "mallory", 1500), true, "CaptureHold", "auth-1", addr("grace"), "1500") // Signed for another payee
+	l.submit(p.bank, l.capture("frank", "auth-1", "grace", 1500), false, "CaptureHold", "auth-1", addr("grace"), "1500")
+	l.submit(p.bank, l.hold("frank", "auth-2", 1000), false, "PlaceHold", addr("frank"), "1000", HoldPurposeAuthorization, "auth-2")
+	l.submit(p.bank, nil, false, "ReleaseHold", "auth-2")
+	l.submit(p.bank, nil, true, "PlaceLien", addr("frank"), "3000", "case-1")
+	l.submit(p.regulator, nil, false, "PlaceLien", addr("frank"), "3000", "case-1")
+	l.submit(p.bank, l.pay("frank", "grace", 6000), true, "Transfer", addr("frank"), addr("grace"), "6000")
+	l.submit(p.regulator, nil, false, "LiftLien", addr("frank"), "case-1")
+
+	frank := l.wallet(addr("frank"))
+	if frank.Balance != 8500 || frank.Held != 0 {
+		t.Errorf("frank balance = %d, held = %d", frank.Balance, frank.Held)
+	}
+}
+
+func TestConformanceHoldCaptureLimits(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Tiers[tiers.Tier1] = tiers.Limits{MaxTransaction: 2000, DailyLimit: 3000, MaxDailyCount: 10, MaxBalance: 1000000}
+	l.governance.doc.Fees = fees.Schedule{CollectorWalletID: addr("fees"), Default: fees.Rule{Kind: fees.KindFlat, Flat: 10}}
+
+	l.fund(p, "ivan", "Tier1", 10000)
+	l.fund(p, "jade", "Tier1", 0)
+	l.fund(p, "fees", "Tier2", 0)
+
+	l.submit(p.bank, l.pay("ivan", "jade", 1500), false, "TransferWithType", addr("ivan"), addr("jade"), "1500", "P2P")
+	l.submit(p.bank, l.hold("ivan", "auth-1", 2000), false, "PlaceHold", addr("ivan"), "2000", HoldPurposeAuthorization, "auth-1")
+	l.submit(p.bank, l.capture("ivan", "auth-1", "jade", 1600), true, "CaptureHold", "auth-1", addr("jade"), "1600") // 1510 + 1610 is over the daily 3000
+
+	ivan := l.wallet(addr("ivan"))
+	if ivan.Balance != 8490 || ivan.Held != 2000 {
+		t.Errorf("after the rejected capture ivan balance = %d, held = %d", ivan.Balance, ivan.Held)
+	}
+	if got := l.usage(addr("ivan")); got.DailySpent != 1510 || got.DailyCount != 1 {
+		t.Errorf("after the rejected capture ivan usage = %+v", got)
+	}
+
+	l.submit(p.bank, l.capture("ivan", "auth-1", "jade", 1400), false, "CaptureHold", "auth-1", addr("jade"), "1400")
+
+	ivan = l.wallet(addr("ivan"))
+	if ivan.Balance != 8490-1410 || ivan.Held != 0 {
+		t.Errorf("ivan balance = %d, held = %d", ivan.Balance, ivan.Held)
+	}
+	if got := l.usage(addr("ivan")); got.DailySpent != 2920 || got.DailyCount != 2 {
+		t.Errorf("ivan usage = %+v", got)
+	}
+	if got := l.wallet(addr("jade")).Balance; got != 2900 {
+		t.Errorf("jade balance = %d", got)
+	}
+	if got := l.wallet(addr("fees")).Balance; got != 20 {
+		t.Errorf("fees balance = %d", got)
+	}
+}
+
+func TestConformanceHoldCaptureFrozen(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "kim", "Tier1", 5000)
+	l.fund(p, "lou", "Tier1", 0)
+
+	l.submit(p.bank, l.hold("kim", "auth-1", 2000), false, "PlaceHold", addr("kim"), "2000", HoldPurposeAuthorization, "auth-1")
+	l.submit(p.regulator, nil, false, "FreezeWallet", addr("kim"), StatusReasonFraudSuspected)
+	l.submit(p.bank, l.capture("kim", "auth-1", "lou", 1500), true, "CaptureHold", "auth-1", addr("lou"), "1500")
+	l.submit(p.centralBank, nil, false, "UnfreezeWallet", addr("kim"), StatusReasonResolved)
+	l.submit(p.bank, l.capture("kim", "auth-1", "lou", 1500), false, "CaptureHold", "auth-1", addr("lou"), "1500")
+
+	if got := l.wallet(addr("lou")).Balance; got != 1500 {
+		t.Errorf("lou balance = %d", got)
+	}
+}
@@ -0,0 +1,109 @@
+package chaincode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DocTypeIdempotency keys the request a client idempotency key was first used for
+// (msp~intermediary~key), so each intermediary has its own key space
+const DocTypeIdempotency = "IDEMPOTENCY"
+
+// TransientIdempotencyKey is the transient map entry carrying the optional client
+// idempotency key. It is passed as transient data so function signatures stay unchanged.
+const TransientIdempotencyKey = "idempotency_key"
+
+// IdempotencyRecord remembers the transaction that first used an idempotency key
+type IdempotencyRecord struct {
+	Key            string `json:"key"`
+	MSPID          string `json:"msp_id"`
+	IntermediaryID string `json:"intermediary_id,omitempty"` // Empty for Central Bank callers
+	Function       string `json:"function"`
+	RequestHash    string `json:"request_hash"` // SHA-256 of the function arguments
+	TxID           string `json:"tx_id"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+// idempotentCall tracks one call made with an idempotency key. A zero value (no key
+// supplied) never replays and records nothing.
+type idempotentCall struct {
+	ledgerKey string
+	record    IdempotencyRecord
+	replay    *Transaction // Original result when the key has been used before
+}
+
+// beginIdempotent looks up the caller's idempotency key. When the key was already used
+// for the same request the original transaction is returned in replay; reusing it for a
+// different request is an error. Concurrent first uses of a key read the same missing
+// record, so all but one fail MVCC validation at commit.
+func beginIdempotent(ctx contractapi.TransactionContextInterface, function string, args ...any) (*idempotentCall, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient data: %v", err)
+	}
+	key := string(transient[TransientIdempotencyKey])
+	if key == "" {
+		return &idempotentCall{}, nil
+	}
+
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	argBytes, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(argBytes)
+
+	ledgerKey, err := ctx.GetStub().CreateCompositeKey(DocTypeIdempotency, []string{c.MSPID, c.IntermediaryID, key})
+	if err != nil {
+		return nil, err
+	}
+	call := &idempotentCall{
+		ledgerKey: ledgerKey,
+		record: IdempotencyRecord{
+			Key:            key,
+			MSPID:          c.MSPID,
+			IntermediaryID: c.IntermediaryID,
+			Function:       function,
+			RequestHash:    hex.EncodeToString(sum[:]),
+		},
+	}
+
+	recordBytes, err := ctx.GetStub().GetState(ledgerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read idempotency key: %v", err)
+	}
+	if recordBytes == nil {
+		return call, nil
+	}
+
+	var existing IdempotencyRecord
+	if err := json.Unmarshal(recordBytes, &existing); err != nil {
+		return nil, err
+	}
+	if existing.Function != function || existing.RequestHash != call.record.RequestHash {
+		return nil, fmt.Errorf("idempotency key %q was already used for a different %s request in %s", key, existing.Function, existing.TxID)
+	}
+	call.replay, err = readTransaction(ctx, existing.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+// complete records that the call's idempotency key produced tx
+func (c *idempotentCall) complete(ctx contractapi.TransactionContextInterface, tx *Transaction) error {
+	if c.ledgerKey == "" {
+		return nil
+	}
+	c.record.TxID = tx.ID
+	c.record.Timestamp = tx.Timestamp
+	recordBytes, _ := json.Marshal(c.record)
+	return ctx.GetStub().PutState(c.ledgerKey, recordBytes)
+}
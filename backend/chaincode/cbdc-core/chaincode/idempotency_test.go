@@ -0,0 +1,58 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformanceIdempotency(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	key := func(k string) map[string][]byte {
+		return map[string][]byte{TransientIdempotencyKey: []byte(k)}
+	}
+
+	l.fund(p, "quinn", "Tier1", 0)
+	l.fund(p, "rita", "Tier1", 0)
+
+	issued := l.submit(p.centralBank, key("issue-1"), false, "Issue", "10000", addr("quinn"))
+	if got := l.replay(p.centralBank, key("issue-1"), "Issue", "10000", addr("quinn")); string(got) != string(issued) {
+		t.Errorf("Issue replay = %s, want %s", got, issued)
+	}
+
+	sent := l.submit(p.bank, l.pay("quinn", "rita", 2500, key("pay-1")), false, "Transfer", addr("quinn"), addr("rita"), "2500")
+	if got := l.replay(p.bank, key("pay-1"), "Transfer", addr("quinn"), addr("rita"), "2500"); string(got) != string(sent) {
+		t.Errorf("Transfer replay = %s, want %s", got, sent)
+	}
+	l.submit(p.bank, l.pay("quinn", "rita", 2600, key("pay-1")), true, "Transfer", addr("quinn"), addr("rita"), "2600")
+	l.submit(p.bank, l.pay("quinn", "rita", 2500, key("pay-1")), true, "TransferWithType", addr("quinn"), addr("rita"), "2500", "P2B")
+	l.submit(p.otherBank, l.pay("quinn", "rita", 2500, key("pay-1")), true, "Transfer", addr("quinn"), addr("rita"), "2500")
+	l.submit(p.bank, l.pay("quinn", "rita", 2500), false, "Transfer", addr("quinn"), addr("rita"), "2500")
+	l.submit(p.centralBank, key("pay-1"), false, "Redeem", "1000", addr("rita"))
+
+	legs := `[{"to_wallet_id":"` + addr("rita") + `","amount":300},{"to_wallet_id":"` + addr("rita") + `","amount":200}]`
+	batch := func(legs string, amount int64) map[string][]byte {
+		return l.signed("quinn", intents.Transfer{Legs: intents.LegsHash(legs), Amount: amount}, key("batch-1"))
+	}
+	paid := l.submit(p.bank, batch(legs, 500), false, "BatchTransfer", addr("quinn"), legs)
+	if got := l.replay(p.bank, key("batch-1"), "BatchTransfer", addr("quinn"), legs); string(got) != string(paid) {
+		t.Errorf("BatchTransfer replay = %s, want %s", got, paid)
+	}
+	var result BatchTransferResult
+	json.Unmarshal(paid, &result)
+	if len(result.TxIDs) != 2 || result.TxIDs[1] != result.BatchID+"-leg-1" {
+		t.Errorf("batch tx IDs = %v", result.TxIDs)
+	}
+	other := `[{"to_wallet_id":"` + addr("rita") + `","amount":500}]`
+	l.submit(p.bank, batch(other, 500), true, "BatchTransfer", addr("quinn"), other)
+
+	if got := l.wallet(addr("quinn")).Balance; got != 10000-2500-2500-500 {
+		t.Errorf("quinn balance = %d", got)
+	}
+	if got := l.wallet(addr("rita")).Balance; got != 2500+2500-1000+500 {
+		t.Errorf("rita balance = %d", got)
+	}
+}
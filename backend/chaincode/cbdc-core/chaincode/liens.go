@@ -0,0 +1,41 @@
+package chaincode
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// lienHoldID is the hold backing the lien for a case on a wallet
+func lienHoldID(walletID string, caseRef string) string {
+	return "lien-" + walletID + "-" + caseRef
+}
+
+// PlaceLien freezes amount of a wallet's funds for a court or regulatory case while the
+// customer keeps using the remainder. Liened funds are excluded from the available balance,
+// so Transfer, Redeem and every other debit respect them. Only the Central Bank or Regulator
+// can place liens, and they may be placed on frozen or suspended wallets.
+//
+// A lien can only cover funds that are available when it is placed. Funds already reserved
+// by other holds (an offline purse or a card authorization) are not liened, and later
+// credits do not top the lien up; to cover more, release or wait out the other holds and
+// place a further lien under another case reference.
+func (s *SmartContract) PlaceLien(ctx contractapi.TransactionContextInterface, walletID string, amount int64, caseRef string) (*Hold, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if caseRef == "" {
+		return nil, fmt.Errorf("case reference is required")
+	}
+	return placeHold(ctx, walletID, amount, HoldPurposeLien, lienHoldID(walletID, caseRef), caseRef)
+}
+
+// LiftLien releases the funds held under a case back to the wallet's available balance
+func (s *SmartContract) LiftLien(ctx contractapi.TransactionContextInterface, walletID string, caseRef string) (*Hold, error) {
+	return s.ReleaseHold(ctx, lienHoldID(walletID, caseRef))
+}
+
+// GetLien returns the lien placed on a wallet for a case
+func (s *SmartContract) GetLien(ctx contractapi.TransactionContextInterface, walletID string, caseRef string) (*Hold, error) {
+	return s.GetHold(ctx, lienHoldID(walletID, caseRef))
+}
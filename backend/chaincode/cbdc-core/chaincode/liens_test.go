@@ -0,0 +1,42 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConformanceLiens(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "lena", "Tier1", 5000)
+	l.fund(p, "mo", "Tier1", 0)
+
+	l.submit(p.bank, l.hold("lena", "auth-1", 4000), false, "PlaceHold", addr("lena"), "4000", HoldPurposeAuthorization, "auth-1")
+	l.submit(p.regulator, nil, true, "PlaceLien", addr("lena"), "0", "case-1")
+	l.submit(p.regulator, nil, true, "PlaceLien", addr("lena"), "1000", "")
+	l.submit(p.regulator, nil, true, "PlaceLien", addr("lena"), "2000", "case-1") // Only 1000 is not already held
+	l.submit(p.regulator, nil, false, "FreezeWallet", addr("lena"), StatusReasonAMLInvestigation)
+	l.submit(p.regulator, nil, false, "PlaceLien", addr("lena"), "1000", "case-1") // Frozen wallets can be liened
+	l.submit(p.centralBank, nil, false, "UnfreezeWallet", addr("lena"), StatusReasonResolved)
+	l.submit(p.centralBank, nil, true, "PlaceLien", addr("lena"), "1000", "case-1")
+
+	// Releasing the authorization frees funds for the customer, not for the lien
+	l.submit(p.bank, nil, false, "ReleaseHold", "auth-1")
+	l.submit(p.bank, l.pay("lena", "mo", 4001), true, "Transfer", addr("lena"), addr("mo"), "4001")
+	l.submit(p.bank, l.pay("lena", "mo", 4000), false, "Transfer", addr("lena"), addr("mo"), "4000")
+	l.submit(p.bank, nil, true, "LiftLien", addr("lena"), "case-1")
+
+	var lien Hold
+	json.Unmarshal(l.endorse(p.bank, "query", nil, "GetLien", []string{addr("lena"), "case-1"}).payload, &lien)
+	if lien.Status != HoldActive || lien.Amount != 1000 || lien.CaseRef != "case-1" || lien.Purpose != HoldPurposeLien {
+		t.Errorf("lien = %+v", lien)
+	}
+
+	l.submit(p.centralBank, nil, false, "LiftLien", addr("lena"), "case-1")
+	l.submit(p.regulator, nil, true, "LiftLien", addr("lena"), "case-1")
+	if lena := l.wallet(addr("lena")); lena.Balance != 1000 || lena.Held != 0 {
+		t.Errorf("lena balance = %d, held = %d", lena.Balance, lena.Held)
+	}
+}
@@ -0,0 +1,295 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DocTypeWalletStatus and DocTypeWalletTier key the status and tier change history of each wallet
+const (
+	DocTypeWalletStatus = "WALLET_STATUS"
+	DocTypeWalletTier   = "WALLET_TIER"
+)
+
+// Wallet lifecycle states
+const (
+	WalletActive    = "Active"    // Can send and receive
+	WalletFrozen    = "Frozen"    // Blocked by the Central Bank or Regulator
+	WalletSuspended = "Suspended" // Receive-only, e.g. pending KYC review
+	WalletDormant   = "Dormant"   // Receive-only after prolonged inactivity
+	WalletClosed    = "Closed"    // Terminal; requires a zero balance
+)
+
+// Reason codes recorded on wallet status and tier changes
+const (
+	StatusReasonCustomerRequest  = "CUSTOMER_REQUEST"
+	StatusReasonKYCReview        = "KYC_REVIEW"
+	StatusReasonKYCVerified      = "KYC_VERIFIED"
+	StatusReasonAMLInvestigation = "AML_INVESTIGATION"
+	StatusReasonFraudSuspected   = "FRAUD_SUSPECTED"
+	StatusReasonCourtOrder       = "COURT_ORDER"
+	StatusReasonRegulatory       = "REGULATORY_DIRECTIVE"
+	StatusReasonInactivity       = "INACTIVITY"
+	StatusReasonDeceased         = "DECEASED_CUSTOMER"
+	StatusReasonResolved         = "RESOLVED"
+	StatusReasonOther            = "OTHER"
+)
+
+var statusReasonCodes = []string{
+	StatusReasonCustomerRequest,
+	StatusReasonKYCReview,
+	StatusReasonKYCVerified,
+	StatusReasonAMLInvestigation,
+	StatusReasonFraudSuspected,
+	StatusReasonCourtOrder,
+	StatusReasonRegulatory,
+	StatusReasonInactivity,
+	StatusReasonDeceased,
+	StatusReasonResolved,
+	StatusReasonOther,
+}
+
+// WalletStatusChange records who moved a wallet between lifecycle states, when and why
+type WalletStatusChange struct {
+	WalletID   string `json:"wallet_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	ReasonCode string `json:"reason_code"`
+	Actor      string `json:"actor"` // Client identity ID of the caller
+	ActorMSP   string `json:"actor_msp"`
+	TxID       string `json:"tx_id"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// WalletTierChange records who moved a wallet between KYC tiers, when and why
+type WalletTierChange struct {
+	WalletID   string `json:"wallet_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	ReasonCode string `json:"reason_code"`
+	Actor      string `json:"actor"` // Client identity ID of the caller
+	ActorMSP   string `json:"actor_msp"`
+	TxID       string `json:"tx_id"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// canSend reports whether the wallet may be debited
+func (w *Wallet) canSend() error {
+	if w.Status != WalletActive {
+		return fmt.Errorf("wallet %s is %s and cannot send funds", w.ID, strings.ToLower(w.Status))
+	}
+	return nil
+}
+
+// canReceive reports whether the wallet may be credited
+func (w *Wallet) canReceive() error {
+	switch w.Status {
+	case WalletActive, WalletSuspended, WalletDormant:
+		return nil
+	}
+	return fmt.Errorf("wallet %s is %s and cannot receive funds", w.ID, strings.ToLower(w.Status))
+}
+
+// setWalletStatus moves the wallet to status if its current state is one of from,
+// and appends the change to the wallet's status history
+func setWalletStatus(ctx contractapi.TransactionContextInterface, wallet *Wallet, from []string, status string, reasonCode string) (*WalletStatusChange, error) {
+	if !slices.Contains(statusReasonCodes, reasonCode) {
+		return nil, fmt.Errorf("unknown reason code %q", reasonCode)
+	}
+	if !slices.Contains(from, wallet.Status) {
+		return nil, fmt.Errorf("wallet %s cannot move from %s to %s", wallet.ID, wallet.Status, status)
+	}
+
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	change := WalletStatusChange{
+		WalletID:   wallet.ID,
+		From:       wallet.Status,
+		To:         status,
+		ReasonCode: reasonCode,
+		Actor:      c.ID,
+		ActorMSP:   c.MSPID,
+		TxID:       ctx.GetStub().GetTxID(),
+		Timestamp:  now.Unix(),
+	}
+
+	wallet.Status = status
+	wallet.StatusReason = reasonCode
+	wallet.StatusUpdatedAt = change.Timestamp
+	wallet.StatusSetBy = change.ActorMSP
+	if err := putWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	// Zero-pad the timestamp so the history sorts chronologically
+	key, err := ctx.GetStub().CreateCompositeKey(DocTypeWalletStatus, []string{wallet.ID, fmt.Sprintf("%020d", change.Timestamp), change.TxID})
+	if err != nil {
+		return nil, err
+	}
+	changeBytes, _ := json.Marshal(change)
+	if err := ctx.GetStub().PutState(key, changeBytes); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, events.NameWalletStatus, events.WalletStatus{
+		WalletID:   change.WalletID,
+		From:       change.From,
+		To:         change.To,
+		ReasonCode: change.ReasonCode,
+		Actor:      change.Actor,
+		ActorMSP:   change.ActorMSP,
+	}); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// readManagedWallet loads a wallet that the caller may change the status of:
+// its own intermediary, or the Central Bank and Regulator. A wallet the Central Bank or
+// Regulator took out of Active stays under their control until they restore it.
+func readManagedWallet(ctx contractapi.TransactionContextInterface, walletID string) (*Wallet, error) {
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !c.isSupervisor() && !c.manages(wallet) {
+		return nil, fmt.Errorf("unauthorized: wallet %s is not managed by %s", walletID, c.IntermediaryID)
+	}
+	if !c.isSupervisor() && wallet.Status != WalletActive && slices.Contains([]string{MSPCentralBank, MSPRegulator}, wallet.StatusSetBy) {
+		return nil, fmt.Errorf("unauthorized: wallet %s was made %s by %s and only a supervisor can change it", walletID, strings.ToLower(wallet.Status), wallet.StatusSetBy)
+	}
+	return wallet, nil
+}
+
+// SuspendWallet makes a wallet receive-only, e.g. while KYC is reviewed
+func (s *SmartContract) SuspendWallet(ctx contractapi.TransactionContextInterface, walletID string, reasonCode string) (*WalletStatusChange, error) {
+	wallet, err := readManagedWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return setWalletStatus(ctx, wallet, []string{WalletActive, WalletDormant}, WalletSuspended, reasonCode)
+}
+
+// MarkWalletDormant flags an inactive wallet; it keeps receiving but cannot send until reactivated
+func (s *SmartContract) MarkWalletDormant(ctx contractapi.TransactionContextInterface, walletID string, reasonCode string) (*WalletStatusChange, error) {
+	wallet, err := readManagedWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return setWalletStatus(ctx, wallet, []string{WalletActive}, WalletDormant, reasonCode)
+}
+
+// ReactivateWallet returns a suspended or dormant wallet to Active.
+// Frozen wallets are released with UnfreezeWallet instead.
+func (s *SmartContract) ReactivateWallet(ctx contractapi.TransactionContextInterface, walletID string, reasonCode string) (*WalletStatusChange, error) {
+	wallet, err := readManagedWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	return setWalletStatus(ctx, wallet, []string{WalletSuspended, WalletDormant}, WalletActive, reasonCode)
+}
+
+// CloseWallet permanently closes a wallet. Every asset balance must be zero with no active holds.
+func (s *SmartContract) CloseWallet(ctx contractapi.TransactionContextInterface, walletID string, reasonCode string) (*WalletStatusChange, error) {
+	wallet, err := readManagedWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Balance != 0 || wallet.Held != 0 {
+		return nil, fmt.Errorf("wallet %s must have a zero balance and no holds to close: balance %d, held %d", walletID, wallet.Balance, wallet.Held)
+	}
+	if balances := wallet.assetBalances(); len(balances) > 0 {
+		return nil, fmt.Errorf("wallet %s must have zero balances in every asset to close: %v", walletID, balances)
+	}
+	return setWalletStatus(ctx, wallet, []string{WalletActive, WalletSuspended, WalletDormant}, WalletClosed, reasonCode)
+}
+
+// GetWalletStatusHistory returns every status change of a wallet, oldest first
+func (s *SmartContract) GetWalletStatusHistory(ctx contractapi.TransactionContextInterface, walletID string) ([]*WalletStatusChange, error) {
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWalletReader(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypeWalletStatus, []string{walletID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	changes := []*WalletStatusChange{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var change WalletStatusChange
+		if err := json.Unmarshal(result.Value, &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
+
+// putWalletTierChange appends a tier change to the wallet's tier history
+func putWalletTierChange(ctx contractapi.TransactionContextInterface, change *WalletTierChange) error {
+	// Zero-pad the timestamp so the history sorts chronologically
+	key, err := ctx.GetStub().CreateCompositeKey(DocTypeWalletTier, []string{change.WalletID, fmt.Sprintf("%020d", change.Timestamp), change.TxID})
+	if err != nil {
+		return err
+	}
+	changeBytes, _ := json.Marshal(change)
+	return ctx.GetStub().PutState(key, changeBytes)
+}
+
+// GetWalletTierHistory returns every tier change of a wallet, oldest first
+func (s *SmartContract) GetWalletTierHistory(ctx contractapi.TransactionContextInterface, walletID string) ([]*WalletTierChange, error) {
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWalletReader(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypeWalletTier, []string{walletID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	changes := []*WalletTierChange{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var change WalletTierChange
+		if err := json.Unmarshal(result.Value, &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,91 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConformanceWalletLifecycle(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "dave", "Tier0", 1000)
+	l.fund(p, "erin", "Tier0", 0)
+
+	l.submit(p.bank, nil, false, "UpdateWalletTier", addr("dave"), "Tier1", StatusReasonKYCVerified)
+	l.submit(p.regulator, nil, false, "FreezeWallet", addr("dave"), StatusReasonAMLInvestigation)
+	l.submit(p.bank, l.pay("dave", "erin", 100), true, "Transfer", addr("dave"), addr("erin"), "100")
+	l.submit(p.centralBank, nil, false, "UnfreezeWallet", addr("dave"), StatusReasonResolved)
+	l.submit(p.bank, nil, false, "SuspendWallet", addr("erin"), StatusReasonKYCReview)
+	l.submit(p.bank, l.pay("dave", "erin", 100), false, "Transfer", addr("dave"), addr("erin"), "100")
+	l.submit(p.bank, nil, false, "ReactivateWallet", addr("erin"), StatusReasonResolved)
+	l.submit(p.bank, l.pay("erin", "dave", 100), false, "Transfer", addr("erin"), addr("dave"), "100")
+	l.submit(p.bank, nil, false, "MarkWalletDormant", addr("erin"), StatusReasonInactivity)
+	l.submit(p.bank, nil, false, "CloseWallet", addr("erin"), StatusReasonCustomerRequest)
+
+	if got := l.wallet(addr("erin")).Status; got != WalletClosed {
+		t.Errorf("erin status = %s", got)
+	}
+}
+
+func TestConformanceSupervisorSuspension(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "fay", "Tier1", 1000)
+
+	l.submit(p.regulator, nil, false, "SuspendWallet", addr("fay"), StatusReasonAMLInvestigation)
+	l.submit(p.bank, nil, true, "ReactivateWallet", addr("fay"), StatusReasonResolved)
+	l.submit(p.bank, nil, true, "CloseWallet", addr("fay"), StatusReasonCustomerRequest)
+	l.submit(p.regulator, nil, false, "ReactivateWallet", addr("fay"), StatusReasonResolved)
+
+	// Once restored, the intermediary manages the wallet's lifecycle again
+	l.submit(p.bank, nil, false, "SuspendWallet", addr("fay"), StatusReasonKYCReview)
+	l.submit(p.bank, nil, false, "ReactivateWallet", addr("fay"), StatusReasonResolved)
+
+	var history []*WalletStatusChange
+	json.Unmarshal(l.endorse(p.bank, "query", nil, "GetWalletStatusHistory", []string{addr("fay")}).payload, &history)
+	if len(history) != 4 || history[0].ActorMSP != MSPRegulator || history[2].ActorMSP != MSPBankConsortium {
+		t.Errorf("history = %+v", history)
+	}
+	if got := l.wallet(addr("fay")); got.Status != WalletActive || got.StatusSetBy != MSPBankConsortium {
+		t.Errorf("fay status = %s set by %s", got.Status, got.StatusSetBy)
+	}
+}
+
+func TestConformanceWalletTier(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "gus", "Tier0", 20000)
+	l.fund(p, "hal", "Tier1", 0)
+
+	l.submit(p.bank, nil, true, "UpdateWalletTier", addr("gus"), "Tier1", "PROMOTION") // Unknown reason code
+	l.submit(p.otherBank, nil, true, "UpdateWalletTier", addr("gus"), "Tier1", StatusReasonKYCVerified)
+	l.submit(p.bank, nil, false, "UpdateWalletTier", addr("gus"), "Tier1", StatusReasonKYCVerified)
+	if got := l.wallet(addr("gus")).Tier; got != "Tier1" {
+		t.Fatalf("tier after upgrade = %s", got)
+	}
+
+	// 60000 fits Tier1 but not the Tier0 ceiling of 50000, so the downgrade waits for funds to move out
+	l.submit(p.centralBank, nil, false, "Issue", "40000", addr("gus"))
+	l.submit(p.bank, nil, true, "UpdateWalletTier", addr("gus"), "Tier0", StatusReasonKYCReview)
+	l.submit(p.bank, l.pay("gus", "hal", 20000), false, "Transfer", addr("gus"), addr("hal"), "20000")
+	l.submit(p.bank, nil, false, "UpdateWalletTier", addr("gus"), "Tier0", StatusReasonKYCReview)
+
+	var history []WalletTierChange
+	json.Unmarshal(l.endorse(p.regulator, "query", nil, "GetWalletTierHistory", []string{addr("gus")}).payload, &history)
+	if len(history) != 2 {
+		t.Fatalf("tier history has %d changes", len(history))
+	}
+	upgrade, downgrade := history[0], history[1]
+	if upgrade.From != "Tier0" || upgrade.To != "Tier1" || upgrade.ReasonCode != StatusReasonKYCVerified || upgrade.Actor != p.bank.id || upgrade.ActorMSP != MSPBankConsortium {
+		t.Errorf("upgrade = %+v", upgrade)
+	}
+	if downgrade.From != "Tier1" || downgrade.To != "Tier0" || downgrade.ReasonCode != StatusReasonKYCReview {
+		t.Errorf("downgrade = %+v", downgrade)
+	}
+}
@@ -0,0 +1,185 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// getTierLimits returns the governed limits for a tier, rejecting unknown tiers
+func getTierLimits(ctx contractapi.TransactionContextInterface, tier string) (tiers.Limits, error) {
+	doc, err := readParams(ctx)
+	if err != nil {
+		return tiers.Limits{}, err
+	}
+	return tierLimits(doc, tier)
+}
+
+func tierLimits(doc *params.Document, tier string) (tiers.Limits, error) {
+	limits, ok := doc.TierLimits(tier)
+	if !ok {
+		return tiers.Limits{}, fmt.Errorf("unknown wallet tier %q", tier)
+	}
+	return limits, nil
+}
+
+// txTime returns the timestamp of the current transaction proposal.
+// The proposal timestamp is used instead of the peer clock so that every
+// endorser sees the same time.
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+// txDay returns the UTC day of the current transaction proposal
+func txDay(ctx contractapi.TransactionContextInterface) (string, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return "", err
+	}
+	return now.Format("2006-01-02"), nil
+}
+
+func usageKey(ctx contractapi.TransactionContextInterface, walletID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeUsage, []string{walletID})
+}
+
+// readWalletUsage loads the wallet's usage record, resetting it when the day has rolled over
+func readWalletUsage(ctx contractapi.TransactionContextInterface, walletID string) (*WalletUsage, error) {
+	day, err := txDay(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, err := usageKey(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	usageBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %v", err)
+	}
+
+	usage := WalletUsage{WalletID: walletID, Day: day}
+	if usageBytes != nil {
+		if err := json.Unmarshal(usageBytes, &usage); err != nil {
+			return nil, err
+		}
+		if usage.Day != day {
+			usage = WalletUsage{WalletID: walletID, Day: day}
+		}
+	}
+	return &usage, nil
+}
+
+// checkSpendLimits verifies a debit against the scheme-wide and sender's tier limits in doc
+// and returns the usage record to persist once the debit is applied
+func checkSpendLimits(ctx contractapi.TransactionContextInterface, doc *params.Document, wallet *Wallet, amount int64) (*WalletUsage, error) {
+	if err := checkTransactionLimits(doc, wallet, amount); err != nil {
+		return nil, err
+	}
+	return checkDailyLimits(ctx, doc, wallet, amount)
+}
+
+// checkTransactionLimits verifies a single payment against the scheme-wide amount range and
+// the sender's tier maximum in doc
+func checkTransactionLimits(doc *params.Document, wallet *Wallet, amount int64) error {
+	limits, err := tierLimits(doc, wallet.Tier)
+	if err != nil {
+		return err
+	}
+	if amount < doc.MinTransactionLimit || amount > doc.MaxTransactionLimit {
+		return fmt.Errorf("transaction amount %d is outside the scheme limits %d-%d", amount, doc.MinTransactionLimit, doc.MaxTransactionLimit)
+	}
+	if amount > limits.MaxTransaction {
+		return fmt.Errorf("transaction amount %d exceeds limit %d for %s", amount, limits.MaxTransaction, wallet.Tier)
+	}
+	return nil
+}
+
+// checkDailyLimits counts one debit of amount against the sender's daily amount and count
+// limits in doc and returns the usage record to persist once the debit is applied
+func checkDailyLimits(ctx contractapi.TransactionContextInterface, doc *params.Document, wallet *Wallet, amount int64) (*WalletUsage, error) {
+	limits, err := tierLimits(doc, wallet.Tier)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := readWalletUsage(ctx, wallet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if usage.DailySpent+amount > limits.DailyLimit {
+		return nil, fmt.Errorf("daily limit %d exceeded for %s: spent %d, requested %d", limits.DailyLimit, wallet.Tier, usage.DailySpent, amount)
+	}
+	if usage.DailyCount+1 > limits.MaxDailyCount {
+		return nil, fmt.Errorf("daily transaction count %d reached for %s", limits.MaxDailyCount, wallet.Tier)
+	}
+
+	usage.DailySpent += amount
+	usage.DailyCount++
+	return usage, nil
+}
+
+// checkBalanceCeiling verifies that crediting amount keeps the wallet within its tier ceiling in doc
+func checkBalanceCeiling(doc *params.Document, wallet *Wallet, amount int64) error {
+	limits, err := tierLimits(doc, wallet.Tier)
+	if err != nil {
+		return err
+	}
+	if limits.MaxBalance > 0 && wallet.Balance+amount > limits.MaxBalance {
+		return fmt.Errorf("wallet %s would exceed balance ceiling %d for %s", wallet.ID, limits.MaxBalance, wallet.Tier)
+	}
+	return nil
+}
+
+func putWalletUsage(ctx contractapi.TransactionContextInterface, usage *WalletUsage) error {
+	key, err := usageKey(ctx, usage.WalletID)
+	if err != nil {
+		return err
+	}
+	usageBytes, _ := json.Marshal(usage)
+	return ctx.GetStub().PutState(key, usageBytes)
+}
+
+// GetWalletUsage returns today's spend for a wallet and the headroom left under its tier
+func (s *SmartContract) GetWalletUsage(ctx contractapi.TransactionContextInterface, walletID string) (*WalletHeadroom, error) {
+	wallet, err := s.GetWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := getTierLimits(ctx, wallet.Tier)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := readWalletUsage(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	headroom := WalletHeadroom{
+		WalletUsage:      *usage,
+		Tier:             wallet.Tier,
+		MaxTransaction:   limits.MaxTransaction,
+		DailyLimit:       limits.DailyLimit,
+		DailyRemaining:   max(limits.DailyLimit-usage.DailySpent, 0),
+		MaxDailyCount:    limits.MaxDailyCount,
+		CountRemaining:   max(limits.MaxDailyCount-usage.DailyCount, 0),
+		MaxBalance:       limits.MaxBalance,
+		BalanceRemaining: -1,
+	}
+	if limits.MaxBalance > 0 {
+		headroom.BalanceRemaining = max(limits.MaxBalance-wallet.Balance, 0)
+	}
+	return &headroom, nil
+}
@@ -0,0 +1,68 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
+)
+
+func TestConformanceGovernedLimits(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.MinTransactionLimit = 100
+	l.governance.doc.Tiers[tiers.Tier1] = tiers.Limits{MaxTransaction: 2000, DailyLimit: 3000, MaxDailyCount: 10, MaxBalance: 1000000}
+	l.governance.doc.Tiers[tiers.Tier0] = tiers.Limits{MaxTransaction: 1000, DailyLimit: 1000, MaxDailyCount: 5, MaxBalance: 5000}
+
+	l.fund(p, "dan", "Tier1", 10000)
+	l.fund(p, "erin", "Tier1", 0)
+
+	l.submit(p.bank, l.pay("dan", "erin", 2500), true, "Transfer", addr("dan"), addr("erin"), "2500") // Above the governed Tier1 maximum
+	l.submit(p.bank, l.pay("dan", "erin", 50), true, "Transfer", addr("dan"), addr("erin"), "50")     // Below the scheme minimum
+	l.submit(p.bank, l.pay("dan", "erin", 2000), false, "Transfer", addr("dan"), addr("erin"), "2000")
+	l.submit(p.bank, l.pay("dan", "erin", 1500), true, "Transfer", addr("dan"), addr("erin"), "1500")  // Daily limit of 3000
+	l.submit(p.bank, nil, true, "UpdateWalletTier", addr("dan"), "Tier0", StatusReasonCustomerRequest) // 8000 is above the governed Tier0 ceiling
+
+	// A tier withdrawn by governance can neither be opened nor moved into
+	delete(l.governance.doc.Tiers, tiers.Tier2)
+	owner, _ := json.Marshal(WalletOwner{Attributes: []WalletAttribute{{Name: AttributeOwnerID, Value: "owner-fay", Salt: ownerSalt}}})
+	l.submit(p.bank, map[string][]byte{TransientWalletOwner: owner}, true, "CreateWallet", addr("fay"), "bank-a", "Tier2", ownerKeyHex("fay"))
+	l.createWallet(p.bank, "fay", "bank-a", "Tier1")
+	l.submit(p.bank, nil, true, "UpdateWalletTier", addr("erin"), "Tier2", StatusReasonKYCVerified)
+
+	l.txCount++
+	usage := l.endorse(p.bank, fmt.Sprintf("tx%04d", l.txCount), nil, "GetWalletUsage", []string{addr("dan")})
+	var headroom WalletHeadroom
+	json.Unmarshal(usage.payload, &headroom)
+	if headroom.MaxTransaction != 2000 || headroom.DailyRemaining != 1000 {
+		t.Errorf("headroom = %+v", headroom)
+	}
+}
+
+func TestConformanceLimitsIncludeFees(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Tiers[tiers.Tier1] = tiers.Limits{MaxTransaction: 2000, DailyLimit: 3000, MaxDailyCount: 10, MaxBalance: 1000000}
+	l.governance.doc.Fees = fees.Schedule{CollectorWalletID: addr("fees"), Default: fees.Rule{Kind: fees.KindFlat, Flat: 10}}
+
+	l.fund(p, "gina", "Tier1", 10000)
+	l.fund(p, "hank", "Tier1", 0)
+	l.fund(p, "fees", "Tier2", 0)
+
+	l.submit(p.bank, l.pay("gina", "hank", 2000), true, "TransferWithType", addr("gina"), addr("hank"), "2000", "P2B") // 2010 with the fee
+	l.governance.reads = 0
+	l.submit(p.bank, l.pay("gina", "hank", 1990), false, "TransferWithType", addr("gina"), addr("hank"), "1990", "P2B")
+	if l.governance.reads != 2 { // Once in each of the two endorsements
+		t.Errorf("transfer read params %d times", l.governance.reads)
+	}
+	l.submit(p.bank, l.pay("gina", "hank", 995), true, "TransferWithType", addr("gina"), addr("hank"), "995", "P2B") // 1005 of the 1000 left today
+	l.submit(p.bank, l.pay("gina", "hank", 990), false, "TransferWithType", addr("gina"), addr("hank"), "990", "P2B")
+
+	if got := l.wallet(addr("gina")).Balance; got != 10000-2000-1000 {
+		t.Errorf("gina balance = %d", got)
+	}
+}
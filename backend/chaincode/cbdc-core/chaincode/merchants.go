@@ -0,0 +1,398 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Ledger keys for merchants and their payment requests
+const (
+	DocTypeMerchant       = "MERCHANT"        // merchantID
+	DocTypePaymentRequest = "PAYMENT_REQUEST" // merchantID~invoiceID
+)
+
+// Payment request statuses. Expired is reported for open requests past their expiry
+// and is never stored.
+const (
+	PaymentRequestOpen      = "Open"
+	PaymentRequestPaid      = "Paid"
+	PaymentRequestCancelled = "Cancelled"
+	PaymentRequestExpired   = "Expired"
+)
+
+// mccPattern matches an ISO 18245 merchant category code
+var mccPattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+// Merchant is a business that settles payments into one of its intermediary's wallets
+type Merchant struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	MCC                string `json:"mcc"` // Merchant category code
+	SettlementWalletID string `json:"settlement_wallet_id"`
+	FeePlan            string `json:"fee_plan"` // Payment type whose governance fee PayRequest charges, e.g. P2B
+	IntermediaryID     string `json:"intermediary_id"`
+	RegisteredAt       int64  `json:"registered_at"`
+}
+
+// PaymentRequest is an invoice a payer can settle exactly once with PayRequest
+type PaymentRequest struct {
+	MerchantID    string `json:"merchant_id"`
+	InvoiceID     string `json:"invoice_id"`
+	Amount        int64  `json:"amount"`
+	ExpiresAt     int64  `json:"expires_at"` // Unix seconds
+	Status        string `json:"status"`     // Open, Paid, Cancelled (Expired on read)
+	CreatedAt     int64  `json:"created_at"`
+	PayerWalletID string `json:"payer_wallet_id,omitempty" metadata:",optional"`
+	TxID          string `json:"tx_id,omitempty" metadata:",optional"` // Settling transfer
+	Fee           int64  `json:"fee,omitempty" metadata:",optional"`
+	SettledAt     int64  `json:"settled_at,omitempty" metadata:",optional"` // Paid or cancelled
+}
+
+func merchantKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeMerchant, []string{id})
+}
+
+func readMerchant(ctx contractapi.TransactionContextInterface, id string) (*Merchant, error) {
+	key, err := merchantKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	merchantBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read merchant: %v", err)
+	}
+	if merchantBytes == nil {
+		return nil, fmt.Errorf("merchant %s does not exist", id)
+	}
+
+	var merchant Merchant
+	if err := json.Unmarshal(merchantBytes, &merchant); err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// requireMerchantIntermediary allows only the intermediary that registered the merchant
+func requireMerchantIntermediary(ctx contractapi.TransactionContextInterface, merchant *Merchant) (*caller, error) {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.IntermediaryID != merchant.IntermediaryID {
+		return nil, fmt.Errorf("unauthorized: merchant %s is not managed by %s", merchant.ID, c.IntermediaryID)
+	}
+	return c, nil
+}
+
+func paymentRequestKey(ctx contractapi.TransactionContextInterface, merchantID string, invoiceID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypePaymentRequest, []string{merchantID, invoiceID})
+}
+
+func readPaymentRequest(ctx contractapi.TransactionContextInterface, merchantID string, invoiceID string) (*PaymentRequest, error) {
+	key, err := paymentRequestKey(ctx, merchantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	requestBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payment request: %v", err)
+	}
+	if requestBytes == nil {
+		return nil, fmt.Errorf("payment request %s/%s does not exist", merchantID, invoiceID)
+	}
+
+	var request PaymentRequest
+	if err := json.Unmarshal(requestBytes, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// putPaymentRequest saves the request and emits it as a PaymentRequestEvent
+func putPaymentRequest(ctx contractapi.TransactionContextInterface, request *PaymentRequest) error {
+	key, err := paymentRequestKey(ctx, request.MerchantID, request.InvoiceID)
+	if err != nil {
+		return err
+	}
+	requestBytes, _ := json.Marshal(request)
+	if err := ctx.GetStub().PutState(key, requestBytes); err != nil {
+		return err
+	}
+	return emitEvent(ctx, events.NamePaymentRequest, events.PaymentRequest{
+		MerchantID:    request.MerchantID,
+		InvoiceID:     request.InvoiceID,
+		Amount:        request.Amount,
+		Status:        request.Status,
+		PayerWalletID: request.PayerWalletID,
+		TxID:          request.TxID,
+		Fee:           request.Fee,
+	})
+}
+
+// withExpiry reports an open request past its expiry as Expired
+func (r *PaymentRequest) withExpiry(now int64) *PaymentRequest {
+	if r.Status == PaymentRequestOpen && now >= r.ExpiresAt {
+		r.Status = PaymentRequestExpired
+	}
+	return r
+}
+
+// RegisterMerchant registers a merchant that settles into settlementWalletID. Only the
+// intermediary managing the settlement wallet can register it. feePlan is the payment
+// type (P2B, B2B, P2G, ...) whose governance fee is charged on payments to the merchant.
+func (s *SmartContract) RegisterMerchant(ctx contractapi.TransactionContextInterface, id string, name string, mcc string, settlementWalletID string, feePlan string) (*Merchant, error) {
+	if id == "" || name == "" {
+		return nil, fmt.Errorf("merchant ID and name are required")
+	}
+	if !mccPattern.MatchString(mcc) {
+		return nil, fmt.Errorf("invalid merchant category code %q", mcc)
+	}
+	if !slices.Contains(fees.Types, feePlan) {
+		return nil, fmt.Errorf("unknown fee plan %q", feePlan)
+	}
+
+	wallet, err := readWallet(ctx, settlementWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWalletIntermediary(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	key, err := merchantKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("merchant %s already exists", id)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	merchant := Merchant{
+		ID:                 id,
+		Name:               name,
+		MCC:                mcc,
+		SettlementWalletID: settlementWalletID,
+		FeePlan:            feePlan,
+		IntermediaryID:     wallet.IntermediaryID,
+		RegisteredAt:       now.Unix(),
+	}
+	merchantBytes, _ := json.Marshal(merchant)
+	if err := ctx.GetStub().PutState(key, merchantBytes); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, events.NameMerchant, events.Merchant{
+		MerchantID:         id,
+		Name:               name,
+		MCC:                mcc,
+		SettlementWalletID: settlementWalletID,
+		FeePlan:            feePlan,
+		IntermediaryID:     wallet.IntermediaryID,
+	}); err != nil {
+		return nil, err
+	}
+	return &merchant, nil
+}
+
+// GetMerchant returns a registered merchant
+func (s *SmartContract) GetMerchant(ctx contractapi.TransactionContextInterface, id string) (*Merchant, error) {
+	return readMerchant(ctx, id)
+}
+
+// CreatePaymentRequest issues an invoice for amount that can be paid until expiresAt
+// (Unix seconds). Only the merchant's intermediary can create requests.
+func (s *SmartContract) CreatePaymentRequest(ctx contractapi.TransactionContextInterface, merchantID string, invoiceID string, amount int64, expiresAt int64) (*PaymentRequest, error) {
+	if invoiceID == "" {
+		return nil, fmt.Errorf("invoice ID is required")
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	merchant, err := readMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := requireMerchantIntermediary(ctx, merchant); err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt <= now.Unix() {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+
+	key, err := paymentRequestKey(ctx, merchantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("payment request %s/%s already exists", merchantID, invoiceID)
+	}
+
+	request := PaymentRequest{
+		MerchantID: merchantID,
+		InvoiceID:  invoiceID,
+		Amount:     amount,
+		ExpiresAt:  expiresAt,
+		Status:     PaymentRequestOpen,
+		CreatedAt:  now.Unix(),
+	}
+	if err := putPaymentRequest(ctx, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// PayRequest settles an open payment request from the payer's wallet into the merchant's
+// settlement wallet, charging the merchant's fee plan to the payer. Only the payer's
+// intermediary can pay, and a request can be paid once.
+func (s *SmartContract) PayRequest(ctx contractapi.TransactionContextInterface, merchantID string, invoiceID string, payerWalletID string) (*PaymentRequest, error) {
+	request, err := readPaymentRequest(ctx, merchantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if request.withExpiry(now.Unix()).Status != PaymentRequestOpen {
+		return nil, fmt.Errorf("payment request %s/%s is %s", merchantID, invoiceID, request.Status)
+	}
+
+	merchant, err := readMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	payer, err := readWallet(ctx, payerWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireWalletIntermediary(ctx, payer); err != nil {
+		return nil, err
+	}
+	if payer.ID == merchant.SettlementWalletID {
+		return nil, fmt.Errorf("a merchant cannot pay its own request")
+	}
+
+	tx, quote, err := settleTransfer(ctx, assets.Default, payer, merchant.SettlementWalletID, request.Amount, merchant.FeePlan, merchantID+"/"+invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = PaymentRequestPaid
+	request.PayerWalletID = payerWalletID
+	request.TxID = tx.ID
+	request.Fee = quote.Fee
+	request.SettledAt = now.Unix()
+	if err := putPaymentRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// CancelPaymentRequest withdraws an open payment request. Only the merchant's
+// intermediary can cancel.
+func (s *SmartContract) CancelPaymentRequest(ctx contractapi.TransactionContextInterface, merchantID string, invoiceID string) (*PaymentRequest, error) {
+	request, err := readPaymentRequest(ctx, merchantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	merchant, err := readMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := requireMerchantIntermediary(ctx, merchant); err != nil {
+		return nil, err
+	}
+	if request.Status != PaymentRequestOpen {
+		return nil, fmt.Errorf("payment request %s/%s is already %s", merchantID, invoiceID, request.Status)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request.Status = PaymentRequestCancelled
+	request.SettledAt = now.Unix()
+	if err := putPaymentRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// GetPaymentRequest returns a payment request with its paid/unpaid status
+func (s *SmartContract) GetPaymentRequest(ctx contractapi.TransactionContextInterface, merchantID string, invoiceID string) (*PaymentRequest, error) {
+	request, err := readPaymentRequest(ctx, merchantID, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return request.withExpiry(now.Unix()), nil
+}
+
+// ListPaymentRequests returns every payment request of a merchant. Visible to the
+// merchant's intermediary, the Central Bank and the Regulator.
+func (s *SmartContract) ListPaymentRequests(ctx contractapi.TransactionContextInterface, merchantID string) ([]*PaymentRequest, error) {
+	merchant, err := readMerchant(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !c.isSupervisor() && c.IntermediaryID != merchant.IntermediaryID {
+		return nil, fmt.Errorf("unauthorized: merchant %s is not visible to %s", merchantID, c.IntermediaryID)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypePaymentRequest, []string{merchantID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	requests := []*PaymentRequest{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var request PaymentRequest
+		if err := json.Unmarshal(result.Value, &request); err != nil {
+			return nil, err
+		}
+		requests = append(requests, request.withExpiry(now.Unix()))
+	}
+	return requests, nil
+}
@@ -0,0 +1,58 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+)
+
+func TestConformanceMerchants(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Fees = fees.Schedule{
+		CollectorWalletID: addr("fees"),
+		Rules:             map[string]fees.Rule{fees.P2B: {Kind: fees.KindFlat, Flat: 15}},
+	}
+
+	l.fund(p, "sam", "Tier1", 10000)
+	l.fund(p, "fees", "Tier2", 0)
+	l.createWallet(p.otherBank, "store", "bank-b", "Tier2")
+
+	l.submit(p.bank, nil, true, "RegisterMerchant", "m-1", "Corner Store", "5411", addr("store"), fees.P2B)
+	l.submit(p.otherBank, nil, true, "RegisterMerchant", "m-1", "Corner Store", "54", addr("store"), fees.P2B)
+	l.submit(p.otherBank, nil, false, "RegisterMerchant", "m-1", "Corner Store", "5411", addr("store"), fees.P2B)
+
+	expiry := func(d time.Duration) string { return strconv.FormatInt(l.clock.Add(d).Unix(), 10) }
+	l.submit(p.bank, nil, true, "CreatePaymentRequest", "m-1", "inv-1", "2500", expiry(time.Hour))
+	l.submit(p.otherBank, nil, false, "CreatePaymentRequest", "m-1", "inv-1", "2500", expiry(time.Hour))
+	l.submit(p.otherBank, nil, false, "CreatePaymentRequest", "m-1", "inv-2", "700", expiry(90*time.Second))
+	l.submit(p.otherBank, nil, false, "CreatePaymentRequest", "m-1", "inv-3", "300", expiry(time.Hour))
+
+	l.submit(p.otherBank, l.pay("sam", "store", 2500), true, "PayRequest", "m-1", "inv-1", addr("sam"))
+	l.submit(p.bank, nil, true, "PayRequest", "m-1", "inv-1", addr("sam"))
+	l.submit(p.bank, l.pay("sam", "store", 2500), false, "PayRequest", "m-1", "inv-1", addr("sam"))
+	l.submit(p.bank, l.pay("sam", "store", 2500), true, "PayRequest", "m-1", "inv-1", addr("sam"))
+	l.submit(p.bank, l.pay("sam", "store", 700), true, "PayRequest", "m-1", "inv-2", addr("sam")) // Expired
+	l.submit(p.otherBank, nil, false, "CancelPaymentRequest", "m-1", "inv-3")
+	l.submit(p.bank, l.pay("sam", "store", 300), true, "PayRequest", "m-1", "inv-3", addr("sam"))
+
+	var request PaymentRequest
+	json.Unmarshal(l.endorse(p.bank, "query", nil, "GetPaymentRequest", []string{"m-1", "inv-1"}).payload, &request)
+	if request.Status != PaymentRequestPaid || request.PayerWalletID != addr("sam") || request.Fee != 15 {
+		t.Errorf("inv-1 = %+v", request)
+	}
+	json.Unmarshal(l.endorse(p.bank, "query", nil, "GetPaymentRequest", []string{"m-1", "inv-2"}).payload, &request)
+	if request.Status != PaymentRequestExpired {
+		t.Errorf("inv-2 status = %s", request.Status)
+	}
+	if got := l.wallet(addr("store")).Balance; got != 2500 {
+		t.Errorf("store balance = %d", got)
+	}
+	if got := l.wallet(addr("sam")).Balance; got != 10000-2500-15 {
+		t.Errorf("sam balance = %d", got)
+	}
+}
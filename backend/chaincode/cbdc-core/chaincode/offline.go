@@ -0,0 +1,648 @@
+package chaincode
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Private data collections defined in infra/fabric/chaincode/collections_config.json
+const (
+	CollectionRetailWallets         = "pdc-retail-wallets"
+	CollectionIntermediaryPositions = "pdc-intermediary-positions"
+)
+
+// DocTypeOfflinePurse keys device purses in the retail wallets collection
+const DocTypeOfflinePurse = "OFFLINE_PURSE"
+
+// OfflinePurse represents a secure element on a device (Private Data)
+type OfflinePurse struct {
+	DeviceID  string `json:"device_id"`
+	WalletID  string `json:"wallet_id"`  // Wallet the device spends from
+	PublicKey string `json:"public_key"` // Hex-encoded Ed25519 public key
+	Counter   int64  `json:"counter"`    // Highest nonce settled on-chain
+	Limit     int64  `json:"limit"`
+
+	// A deregistered device settles no nonce above FinalCounter
+	Deregistered bool  `json:"deregistered,omitempty" metadata:",optional"`
+	FinalCounter int64 `json:"final_counter,omitempty" metadata:",optional"`
+}
+
+// OfflineProof represents the cryptographic proof of an offline transaction
+type OfflineProof struct {
+	DeviceID     string `json:"device_id"`
+	FromWalletID string `json:"from"`
+	ToWalletID   string `json:"to"`
+	Amount       int64  `json:"amount"`
+	Nonce        int64  `json:"nonce"`
+	Signature    string `json:"signature"` // Hex-encoded Ed25519 signature over Intent
+	Intent       string `json:"intent"`    // Exact bytes signed by the device
+}
+
+// Reason codes reported for rejected offline proofs
+const (
+	ReasonInsufficientFunds = "INSUFFICIENT_FUNDS"
+	ReasonWalletFrozen      = "WALLET_FROZEN"
+	ReasonWalletNotFound    = "WALLET_NOT_FOUND"
+	ReasonBadSignature      = "BAD_SIGNATURE"
+	ReasonDuplicateNonce    = "DUPLICATE_NONCE"
+	ReasonUnknownDevice     = "UNKNOWN_DEVICE"
+	ReasonInvalidProof      = "INVALID_PROOF"
+	ReasonWalletInactive    = "WALLET_INACTIVE"
+	ReasonUnauthorized      = "UNAUTHORIZED"
+	ReasonPaused            = "PAUSED"
+	ReasonLimitExceeded     = "LIMIT_EXCEEDED"
+)
+
+// Proof settlement statuses
+const (
+	ProofSettled  = "SETTLED"
+	ProofRejected = "REJECTED"
+)
+
+// ProofResult reports the outcome of one proof within a BatchReconcile
+type ProofResult struct {
+	Index    int    `json:"index"`
+	DeviceID string `json:"device_id"`
+	Nonce    int64  `json:"nonce"`
+	Status   string `json:"status"` // SETTLED, REJECTED
+	TxID     string `json:"tx_id,omitempty" metadata:",optional"`
+	Reason   string `json:"reason,omitempty" metadata:",optional"`
+	Message  string `json:"message,omitempty" metadata:",optional"`
+}
+
+// BatchReconcileResult is returned by BatchReconcile and emitted as the BatchReconcileEvent payload
+type BatchReconcileResult struct {
+	BatchID       string        `json:"batch_id"`
+	BatchSize     int           `json:"batch_size"`
+	SuccessCount  int           `json:"success_count"`
+	RejectedCount int           `json:"rejected_count"`
+	Results       []ProofResult `json:"results"`
+	Timestamp     int64         `json:"timestamp"`
+}
+
+// proofError is a business rejection of a single proof, carrying its reason code.
+// Any other error from settle is a ledger failure and aborts the transaction.
+type proofError struct {
+	Reason string
+	Msg    string
+}
+
+func (e *proofError) Error() string {
+	return e.Msg
+}
+
+func rejectProof(reason string, format string, args ...interface{}) error {
+	return &proofError{Reason: reason, Msg: fmt.Sprintf(format, args...)}
+}
+
+// walletStatusReason maps a wallet that cannot transact to its rejection reason
+func walletStatusReason(wallet *Wallet) string {
+	if wallet.Status == WalletFrozen {
+		return ReasonWalletFrozen
+	}
+	return ReasonWalletInactive
+}
+
+// offlineIntent is the payment intent signed by the payer device
+type offlineIntent struct {
+	Amount  int64  `json:"amount"`
+	PayeeID string `json:"payee_id"`
+	Counter int64  `json:"counter"`
+}
+
+// RegisterOfflineDevice records a device's public key and counter in the retail wallets PDC.
+// The purse is passed as transient data under "device" so key material never lands in the public ledger.
+// The wallet's owner must authorize binding the device, since its signature settles offline spends.
+func (s *SmartContract) RegisterOfflineDevice(ctx contractapi.TransactionContextInterface) error {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return fmt.Errorf("failed to get transient data: %v", err)
+	}
+	deviceJSON, ok := transient["device"]
+	if !ok {
+		return fmt.Errorf("device must be passed in the transient map")
+	}
+
+	var purse OfflinePurse
+	if err := json.Unmarshal(deviceJSON, &purse); err != nil {
+		return fmt.Errorf("failed to parse device: %v", err)
+	}
+	if purse.DeviceID == "" || purse.WalletID == "" {
+		return fmt.Errorf("device_id and wallet_id are required")
+	}
+	if _, err := parsePublicKey(purse.PublicKey); err != nil {
+		return err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return err
+	}
+	if purse.Limit <= 0 {
+		return fmt.Errorf("purse limit must be positive")
+	}
+	if err := checkOfflineBalance(doc, purse.Limit); err != nil {
+		return err
+	}
+
+	wallet, err := readWallet(ctx, purse.WalletID)
+	if err != nil {
+		return err
+	}
+	if err := requireWalletIntermediary(ctx, wallet); err != nil {
+		return err
+	}
+
+	existing, err := readOfflinePurse(ctx, purse.DeviceID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("device %s is already registered", purse.DeviceID)
+	}
+
+	if err := authorizeDebit(ctx, wallet, intents.Transfer{Device: purse.DeviceID, Key: purse.PublicKey, Asset: assets.Default, Amount: purse.Limit}); err != nil {
+		return err
+	}
+	walletBytes, _ := json.Marshal(wallet)
+	if err := ctx.GetStub().PutState(wallet.ID, walletBytes); err != nil {
+		return err
+	}
+
+	purse.Counter = 0
+	if err := putOfflinePurse(ctx, &purse); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, events.NameOfflineDevice, events.OfflineDevice{DeviceID: purse.DeviceID, WalletID: purse.WalletID})
+}
+
+// DeregisterOfflineDevice retires a device whose last used nonce is finalCounter. Proofs up
+// to finalCounter still settle; later ones are rejected. Once they have all settled the
+// device's OfflineFunding hold can be released. The wallet's owner must authorize it.
+func (s *SmartContract) DeregisterOfflineDevice(ctx contractapi.TransactionContextInterface, deviceID string, finalCounter int64) error {
+	purse, err := readOfflinePurse(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if purse == nil {
+		return fmt.Errorf("device %s is not registered", deviceID)
+	}
+	wallet, err := readWallet(ctx, purse.WalletID)
+	if err != nil {
+		return err
+	}
+	if err := requireWalletIntermediary(ctx, wallet); err != nil {
+		return err
+	}
+	if purse.Deregistered {
+		return fmt.Errorf("device %s is already deregistered", deviceID)
+	}
+	if finalCounter < purse.Counter {
+		return fmt.Errorf("final counter %d is below the last settled nonce %d of device %s", finalCounter, purse.Counter, deviceID)
+	}
+	if err := authorizeDebit(ctx, wallet, intents.Transfer{Device: deviceID, Final: finalCounter, Asset: assets.Default}); err != nil {
+		return err
+	}
+	if err := putWallet(ctx, wallet); err != nil {
+		return err
+	}
+
+	purse.Deregistered = true
+	purse.FinalCounter = finalCounter
+	if err := putOfflinePurse(ctx, purse); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, events.NameOfflineDevice, events.OfflineDevice{
+		DeviceID:     purse.DeviceID,
+		WalletID:     purse.WalletID,
+		Deregistered: true,
+		FinalCounter: finalCounter,
+	})
+}
+
+// checkOfflineHoldRelease refuses to release an OfflineFunding hold that still backs a device:
+// proofs the device signed offline would fail for lack of reserved funds. The hold can be
+// released once nothing is left on it, or the device is deregistered and every nonce up to
+// its final counter has settled.
+func checkOfflineHoldRelease(ctx contractapi.TransactionContextInterface, hold *Hold) error {
+	deviceID, ok := strings.CutPrefix(hold.ID, offlineHoldID(""))
+	if !ok || hold.Amount == 0 {
+		return nil
+	}
+	purse, err := readOfflinePurse(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if purse == nil || purse.WalletID != hold.WalletID {
+		return nil
+	}
+	if !purse.Deregistered {
+		return fmt.Errorf("hold %s backs offline device %s, which must be deregistered first", hold.ID, deviceID)
+	}
+	if purse.Counter < purse.FinalCounter {
+		return fmt.Errorf("device %s has settled nonce %d of %d; its hold cannot be released yet", deviceID, purse.Counter, purse.FinalCounter)
+	}
+	return nil
+}
+
+// checkOfflineBalance rejects a device purse balance or limit above the governed offline cap
+func checkOfflineBalance(doc *params.Document, balance int64) error {
+	if balance > doc.Offline.MaxBalance {
+		return fmt.Errorf("offline balance %d exceeds the maximum %d", balance, doc.Offline.MaxBalance)
+	}
+	return nil
+}
+
+func parsePublicKey(publicKeyHex string) (ed25519.PublicKey, error) {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d hex-encoded bytes", ed25519.PublicKeySize)
+	}
+	return publicKey, nil
+}
+
+func offlinePurseKey(ctx contractapi.TransactionContextInterface, deviceID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeOfflinePurse, []string{deviceID})
+}
+
+// readOfflinePurse returns nil when the device has not been registered
+func readOfflinePurse(ctx contractapi.TransactionContextInterface, deviceID string) (*OfflinePurse, error) {
+	key, err := offlinePurseKey(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	purseBytes, err := ctx.GetStub().GetPrivateData(CollectionRetailWallets, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offline purse: %v", err)
+	}
+	if purseBytes == nil {
+		return nil, nil
+	}
+
+	var purse OfflinePurse
+	if err := json.Unmarshal(purseBytes, &purse); err != nil {
+		return nil, err
+	}
+	return &purse, nil
+}
+
+func putOfflinePurse(ctx contractapi.TransactionContextInterface, purse *OfflinePurse) error {
+	key, err := offlinePurseKey(ctx, purse.DeviceID)
+	if err != nil {
+		return err
+	}
+	purseBytes, _ := json.Marshal(purse)
+	return ctx.GetStub().PutPrivateData(CollectionRetailWallets, key, purseBytes)
+}
+
+// offlineSettlement caches the wallets, purses and holds touched while settling proofs.
+// Fabric does not return a transaction's own pending writes from GetState, so a
+// batch that debits the same wallet or device twice must work from this cache
+// and write each record once at the end.
+type offlineSettlement struct {
+	caller      *caller
+	now         int64            // Transaction timestamp, shared by every proof in the batch
+	doc         *params.Document // Offline caps, read once per transaction
+	wallets     map[string]*Wallet
+	purses      map[string]*OfflinePurse
+	holds       map[string]*Hold
+	walletOrder []string
+	purseOrder  []string
+	holdOrder   []string
+}
+
+func newOfflineSettlement(c *caller, now int64, doc *params.Document) *offlineSettlement {
+	return &offlineSettlement{
+		caller:  c,
+		now:     now,
+		doc:     doc,
+		wallets: map[string]*Wallet{},
+		purses:  map[string]*OfflinePurse{},
+		holds:   map[string]*Hold{},
+	}
+}
+
+func (o *offlineSettlement) wallet(ctx contractapi.TransactionContextInterface, id string) (*Wallet, error) {
+	if wallet, ok := o.wallets[id]; ok {
+		return wallet, nil
+	}
+	walletBytes, err := ctx.GetStub().GetState(id)
+	if err != nil {
+		return nil, err
+	}
+	if walletBytes == nil {
+		return nil, rejectProof(ReasonWalletNotFound, "wallet not found: %s", id)
+	}
+	var wallet Wallet
+	if err := json.Unmarshal(walletBytes, &wallet); err != nil {
+		return nil, err
+	}
+	o.wallets[id] = &wallet
+	o.walletOrder = append(o.walletOrder, id)
+	return &wallet, nil
+}
+
+func (o *offlineSettlement) purse(ctx contractapi.TransactionContextInterface, deviceID string) (*OfflinePurse, error) {
+	if purse, ok := o.purses[deviceID]; ok {
+		return purse, nil
+	}
+	purse, err := readOfflinePurse(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if purse == nil {
+		return nil, rejectProof(ReasonUnknownDevice, "device not registered: %s", deviceID)
+	}
+	o.purses[deviceID] = purse
+	o.purseOrder = append(o.purseOrder, deviceID)
+	return purse, nil
+}
+
+// offlineHold returns the active OfflineFunding hold backing a device on walletID, or nil
+func (o *offlineSettlement) offlineHold(ctx contractapi.TransactionContextInterface, deviceID string, walletID string) (*Hold, error) {
+	id := offlineHoldID(deviceID)
+	hold, ok := o.holds[id]
+	if !ok {
+		var err error
+		hold, err = readHold(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		o.holds[id] = hold
+		if hold != nil {
+			o.holdOrder = append(o.holdOrder, id)
+		}
+	}
+	if hold == nil || hold.Status != HoldActive || hold.WalletID != walletID {
+		return nil, nil
+	}
+	return hold, nil
+}
+
+// settle verifies a proof and applies it to the cached state. Nothing is mutated
+// unless every check passes, so a rejected proof leaves the batch untouched.
+func (o *offlineSettlement) settle(ctx contractapi.TransactionContextInterface, proof OfflineProof, txID string) error {
+	if proof.Amount <= 0 {
+		return rejectProof(ReasonInvalidProof, "amount must be positive")
+	}
+	if proof.ToWalletID == proof.FromWalletID {
+		return rejectProof(ReasonInvalidProof, "cannot transfer to the sending wallet")
+	}
+	if proof.Amount > o.doc.Offline.MaxTransaction {
+		return rejectProof(ReasonLimitExceeded, "amount %d exceeds the offline maximum %d", proof.Amount, o.doc.Offline.MaxTransaction)
+	}
+
+	// 1. Verify the device signature over the intent
+	purse, err := o.purse(ctx, proof.DeviceID)
+	if err != nil {
+		return err
+	}
+	if purse.WalletID != proof.FromWalletID {
+		return rejectProof(ReasonInvalidProof, "device %s is not bound to wallet %s", proof.DeviceID, proof.FromWalletID)
+	}
+	publicKey, err := parsePublicKey(purse.PublicKey)
+	if err != nil {
+		return rejectProof(ReasonBadSignature, "device %s: %v", proof.DeviceID, err)
+	}
+	signature, err := hex.DecodeString(proof.Signature)
+	if err != nil || !ed25519.Verify(publicKey, []byte(proof.Intent), signature) {
+		return rejectProof(ReasonBadSignature, "invalid signature from device %s", proof.DeviceID)
+	}
+
+	var intent offlineIntent
+	if err := json.Unmarshal([]byte(proof.Intent), &intent); err != nil {
+		return rejectProof(ReasonInvalidProof, "failed to parse intent: %v", err)
+	}
+	if intent.Amount != proof.Amount || intent.Counter != proof.Nonce || intent.PayeeID != proof.ToWalletID {
+		return rejectProof(ReasonInvalidProof, "proof does not match signed intent")
+	}
+
+	// 2. Replay protection: nonces must strictly increase per device
+	if proof.Nonce <= purse.Counter {
+		return rejectProof(ReasonDuplicateNonce, "duplicate nonce %d for device %s (last settled %d)", proof.Nonce, proof.DeviceID, purse.Counter)
+	}
+	if purse.Deregistered && proof.Nonce > purse.FinalCounter {
+		return rejectProof(ReasonUnknownDevice, "device %s was deregistered after nonce %d", proof.DeviceID, purse.FinalCounter)
+	}
+
+	// 3. Get Sender and Receiver
+	sender, err := o.wallet(ctx, proof.FromWalletID)
+	if err != nil {
+		return err
+	}
+	receiver, err := o.wallet(ctx, proof.ToWalletID)
+	if err != nil {
+		return err
+	}
+	// An intermediary can only settle offline spends from wallets it manages
+	if !o.caller.manages(sender) {
+		return rejectProof(ReasonUnauthorized, "wallet %s is not managed by %s", sender.ID, o.caller.IntermediaryID)
+	}
+	if err := sender.canSend(); err != nil {
+		return rejectProof(walletStatusReason(sender), "%v", err)
+	}
+	if err := receiver.canReceive(); err != nil {
+		return rejectProof(walletStatusReason(receiver), "%v", err)
+	}
+	// The submitting intermediary was checked on entry; the payer's and payee's may be paused too
+	pause, err := activePause(ctx, OperationOfflineReconcile, sender.IntermediaryID, receiver.IntermediaryID)
+	if err != nil {
+		return err
+	}
+	if pause != nil {
+		return rejectProof(ReasonPaused, "%v", pausedError(OperationOfflineReconcile, pause))
+	}
+
+	// Offline spends are paid only from the funds reserved for the device when its purse
+	// was funded; the rest of the wallet was never in the device's reach
+	hold, err := o.offlineHold(ctx, proof.DeviceID, sender.ID)
+	if err != nil {
+		return err
+	}
+	if hold == nil || hold.Amount < proof.Amount {
+		return rejectProof(ReasonInsufficientFunds, "insufficient offline funds reserved for device %s", proof.DeviceID)
+	}
+
+	// 4. Update
+	sender.Balance -= proof.Amount
+	sender.Held -= proof.Amount
+	receiver.Balance += proof.Amount
+	purse.Counter = proof.Nonce
+	hold.Amount -= proof.Amount
+	hold.Captured += proof.Amount
+	hold.UpdatedAt = o.now
+
+	// 5. Record Transaction
+	tx := Transaction{
+		ID:        txID,
+		Type:      "OfflineReconcile",
+		From:      proof.FromWalletID,
+		To:        proof.ToWalletID,
+		Amount:    proof.Amount,
+		Timestamp: o.now,
+		Signature: []byte(proof.Signature),
+		Asset:     assets.Default,
+	}
+	_, err = putTransaction(ctx, &tx)
+	return err
+}
+
+// flush writes every cached wallet, purse and hold once
+func (o *offlineSettlement) flush(ctx contractapi.TransactionContextInterface) error {
+	for _, id := range o.walletOrder {
+		walletBytes, _ := json.Marshal(o.wallets[id])
+		if err := ctx.GetStub().PutState(id, walletBytes); err != nil {
+			return err
+		}
+	}
+	for _, id := range o.purseOrder {
+		if err := putOfflinePurse(ctx, o.purses[id]); err != nil {
+			return err
+		}
+	}
+	for _, id := range o.holdOrder {
+		if err := putHold(ctx, o.holds[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReconcileOffline processes an offline transaction proof
+func (s *SmartContract) ReconcileOffline(ctx contractapi.TransactionContextInterface, proofJSON string) error {
+	var proof OfflineProof
+	if err := json.Unmarshal([]byte(proofJSON), &proof); err != nil {
+		return err
+	}
+
+	c, err := getCaller(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkNotPaused(ctx, OperationOfflineReconcile, c.IntermediaryID); err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	settlement := newOfflineSettlement(c, now.Unix(), doc)
+	if err := settlement.settle(ctx, proof, ctx.GetStub().GetTxID()); err != nil {
+		return err
+	}
+	if err := settlement.flush(ctx); err != nil {
+		return err
+	}
+
+	return emitEvent(ctx, events.NameOfflineSettled, events.OfflineSettled{
+		DeviceID: proof.DeviceID,
+		From:     proof.FromWalletID,
+		To:       proof.ToWalletID,
+		Amount:   proof.Amount,
+		Nonce:    proof.Nonce,
+	})
+}
+
+// BatchReconcile processes a batch of offline transaction proofs
+// This is called by the offline-service to settle multiple offline transactions at once.
+// Rejected proofs do not fail the batch; each is reported with a reason code so the
+// caller can mark it settled or disputed.
+func (s *SmartContract) BatchReconcile(ctx contractapi.TransactionContextInterface, proofsJSON string) (*BatchReconcileResult, error) {
+	var proofs []OfflineProof
+	if err := json.Unmarshal([]byte(proofsJSON), &proofs); err != nil {
+		return nil, fmt.Errorf("failed to parse proofs: %v", err)
+	}
+
+	if len(proofs) == 0 {
+		return nil, fmt.Errorf("empty batch")
+	}
+
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotPaused(ctx, OperationOfflineReconcile, c.IntermediaryID); err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := readParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process each proof in the batch
+	settlement := newOfflineSettlement(c, now.Unix(), doc)
+	result := BatchReconcileResult{
+		BatchID:   ctx.GetStub().GetTxID(),
+		BatchSize: len(proofs),
+		Results:   make([]ProofResult, 0, len(proofs)),
+		Timestamp: now.Unix(),
+	}
+	for i, proof := range proofs {
+		proofResult := ProofResult{Index: i, DeviceID: proof.DeviceID, Nonce: proof.Nonce}
+		txID := fmt.Sprintf("%s-batch-%d", result.BatchID, i)
+
+		err := settlement.settle(ctx, proof, txID)
+		if err != nil {
+			var rejected *proofError
+			if !errors.As(err, &rejected) {
+				return nil, err
+			}
+			proofResult.Status = ProofRejected
+			proofResult.Reason = rejected.Reason
+			proofResult.Message = rejected.Msg
+			result.RejectedCount++
+		} else {
+			proofResult.Status = ProofSettled
+			proofResult.TxID = txID
+			result.SuccessCount++
+		}
+		result.Results = append(result.Results, proofResult)
+	}
+
+	if err := settlement.flush(ctx); err != nil {
+		return nil, err
+	}
+
+	// Emit batch event
+	event := events.BatchReconcile{
+		BatchSize:     result.BatchSize,
+		SuccessCount:  result.SuccessCount,
+		RejectedCount: result.RejectedCount,
+		Results:       make([]events.ProofOutcome, 0, len(result.Results)),
+	}
+	for _, r := range result.Results {
+		event.Results = append(event.Results, events.ProofOutcome{
+			Index:    r.Index,
+			DeviceID: r.DeviceID,
+			Nonce:    r.Nonce,
+			Status:   r.Status,
+			TxID:     r.TxID,
+			Reason:   r.Reason,
+		})
+	}
+	if err := emitEvent(ctx, events.NameBatchReconcile, event); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
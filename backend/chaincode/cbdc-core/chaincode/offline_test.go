@@ -0,0 +1,201 @@
+package chaincode
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformanceOffline(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Offline.MaxBalance = 5000
+	l.governance.doc.Offline.MaxTransaction = 1000
+
+	l.fund(p, "judy", "Tier1", 10000)
+	l.fund(p, "mallory", "Tier1", 0)
+	l.fund(p, "trent", "Tier1", 0)
+
+	seed := deviceSeed("device-1")
+	key := ed25519.NewKeyFromSeed(seed)
+	device, _ := json.Marshal(OfflinePurse{
+		DeviceID:  "device-1",
+		WalletID:  addr("judy"),
+		PublicKey: hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Limit:     5000,
+	})
+	// Binding a device needs the owner's signature over its ID, key and limit
+	registration := intents.Transfer{Device: "device-1", Key: hex.EncodeToString(key.Public().(ed25519.PublicKey)), Amount: 5000}
+	l.submit(p.bank, map[string][]byte{"device": device}, true, "RegisterOfflineDevice")
+	swapped := registration
+	swapped.Key = hex.EncodeToString(make([]byte, ed25519.PublicKeySize))
+	l.submit(p.bank, l.signed("judy", swapped, map[string][]byte{"device": device}), true, "RegisterOfflineDevice")
+	// The purse limit cannot exceed the governed offline balance cap
+	oversized, _ := json.Marshal(OfflinePurse{DeviceID: "device-1", WalletID: addr("judy"), PublicKey: registration.Key, Limit: 6000})
+	l.submit(p.bank, l.signed("judy", intents.Transfer{Device: "device-1", Key: registration.Key, Amount: 6000}, map[string][]byte{"device": oversized}), true, "RegisterOfflineDevice")
+	l.submit(p.bank, l.signed("judy", registration, map[string][]byte{"device": device}), false, "RegisterOfflineDevice")
+	l.submit(p.bank, l.hold("judy", offlineHoldID("device-1"), 2500), false, "PlaceHold", addr("judy"), "2500", HoldPurposeOfflineFunding, offlineHoldID("device-1"))
+	// Neither can a top-up take the device's hold past it
+	l.submit(p.bank, l.hold("judy", offlineHoldID("device-1"), 3000), true, "PlaceHold", addr("judy"), "3000", HoldPurposeOfflineFunding, offlineHoldID("device-1"))
+
+	proof := func(nonce int64, amount int64) OfflineProof {
+		intent, _ := json.Marshal(offlineIntent{Amount: amount, PayeeID: addr("mallory"), Counter: nonce})
+		return OfflineProof{
+			DeviceID:     "device-1",
+			FromWalletID: addr("judy"),
+			ToWalletID:   addr("mallory"),
+			Amount:       amount,
+			Nonce:        nonce,
+			Signature:    hex.EncodeToString(ed25519.Sign(key, intent)),
+			Intent:       string(intent),
+		}
+	}
+
+	redirected := proof(1, 500)
+	redirected.ToWalletID = addr("trent")
+	single, _ := json.Marshal(redirected)
+	l.submit(p.bank, nil, true, "ReconcileOffline", string(single))
+
+	// A device paying its own wallet would only release the hold
+	intent, _ := json.Marshal(offlineIntent{Amount: 500, PayeeID: addr("judy"), Counter: 1})
+	single, _ = json.Marshal(OfflineProof{
+		DeviceID:     "device-1",
+		FromWalletID: addr("judy"),
+		ToWalletID:   addr("judy"),
+		Amount:       500,
+		Nonce:        1,
+		Signature:    hex.EncodeToString(ed25519.Sign(key, intent)),
+		Intent:       string(intent),
+	})
+	l.submit(p.bank, nil, true, "ReconcileOffline", string(single))
+
+	single, _ = json.Marshal(proof(1, 500))
+	l.submit(p.bank, nil, false, "ReconcileOffline", string(single))
+
+	batch, _ := json.Marshal([]OfflineProof{proof(2, 700), proof(2, 700), proof(3, 1100), proof(3, 900)})
+	var result BatchReconcileResult
+	json.Unmarshal(l.submit(p.bank, nil, false, "BatchReconcile", string(batch)), &result)
+	if result.SuccessCount != 2 || result.RejectedCount != 2 {
+		t.Errorf("batch settled %d, rejected %d", result.SuccessCount, result.RejectedCount)
+	}
+	if len(result.Results) != 4 || result.Results[2].Reason != ReasonLimitExceeded {
+		t.Errorf("over-cap proof result = %+v", result.Results)
+	}
+
+	if got := l.wallet(addr("mallory")).Balance; got != 2100 {
+		t.Errorf("mallory balance = %d", got)
+	}
+
+	// Only the 400 left on the device's hold can be spent, not judy's other funds
+	single, _ = json.Marshal(proof(4, 500))
+	l.submit(p.bank, nil, true, "ReconcileOffline", string(single))
+	if got := l.wallet(addr("judy")); got.Balance != 7900 || got.Held != 400 {
+		t.Errorf("judy balance = %d, held = %d", got.Balance, got.Held)
+	}
+	if got := l.wallet(addr("trent")).Balance; got != 0 {
+		t.Errorf("trent balance = %d", got)
+	}
+}
+
+func TestConformanceOfflineHoldRelease(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Offline.MaxBalance = 5000
+	l.governance.doc.Offline.MaxTransaction = 1000
+
+	l.fund(p, "nina", "Tier1", 10000)
+	l.fund(p, "omar", "Tier1", 0)
+
+	key := ed25519.NewKeyFromSeed(deviceSeed("device-2"))
+	publicKey := hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	device, _ := json.Marshal(OfflinePurse{DeviceID: "device-2", WalletID: addr("nina"), PublicKey: publicKey, Limit: 5000})
+	l.submit(p.bank, l.signed("nina", intents.Transfer{Device: "device-2", Key: publicKey, Amount: 5000}, map[string][]byte{"device": device}), false, "RegisterOfflineDevice")
+	holdID := offlineHoldID("device-2")
+	l.submit(p.bank, l.hold("nina", holdID, 3000), false, "PlaceHold", addr("nina"), "3000", HoldPurposeOfflineFunding, holdID)
+
+	proof := func(nonce int64, amount int64) string {
+		intent, _ := json.Marshal(offlineIntent{Amount: amount, PayeeID: addr("omar"), Counter: nonce})
+		proofJSON, _ := json.Marshal(OfflineProof{
+			DeviceID:     "device-2",
+			FromWalletID: addr("nina"),
+			ToWalletID:   addr("omar"),
+			Amount:       amount,
+			Nonce:        nonce,
+			Signature:    hex.EncodeToString(ed25519.Sign(key, intent)),
+			Intent:       string(intent),
+		})
+		return string(proofJSON)
+	}
+
+	// The device spent nonces 1 and 2 offline; neither has reached the ledger yet
+	l.submit(p.bank, nil, true, "ReleaseHold", holdID)
+	l.submit(p.bank, nil, true, "DeregisterOfflineDevice", "device-2", "2") // Not authorized by nina
+	l.submit(p.bank, l.signed("nina", intents.Transfer{Device: "device-2", Final: 2}), false, "DeregisterOfflineDevice", "device-2", "2")
+	l.submit(p.bank, nil, false, "ReconcileOffline", proof(1, 500))
+	l.submit(p.bank, nil, true, "ReleaseHold", holdID) // Nonce 2 is still outstanding
+	l.submit(p.bank, nil, false, "ReconcileOffline", proof(2, 700))
+	l.submit(p.bank, nil, true, "ReconcileOffline", proof(3, 100)) // Signed after the final counter
+	l.submit(p.bank, nil, false, "ReleaseHold", holdID)
+
+	if got := l.wallet(addr("nina")); got.Balance != 8800 || got.Held != 0 {
+		t.Errorf("nina balance = %d, held = %d", got.Balance, got.Held)
+	}
+	if got := l.wallet(addr("omar")).Balance; got != 1200 {
+		t.Errorf("omar balance = %d", got)
+	}
+}
+
+// TestConformanceOfflineServiceProof settles proofs shaped the way offline-service forwards
+// them: the device signs a payment intent naming the payee's wallet address and the service
+// passes that address on as "to" with the signed intent unchanged
+func TestConformanceOfflineServiceProof(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.governance.doc.Offline.MaxBalance = 5000
+	l.governance.doc.Offline.MaxTransaction = 1000
+
+	l.fund(p, "pia", "Tier1", 10000)
+	l.fund(p, "quinn", "Tier1", 0)
+
+	key := ed25519.NewKeyFromSeed(deviceSeed("device-3"))
+	publicKey := hex.EncodeToString(key.Public().(ed25519.PublicKey))
+	device, _ := json.Marshal(OfflinePurse{DeviceID: "device-3", WalletID: addr("pia"), PublicKey: publicKey, Limit: 5000})
+	l.submit(p.bank, l.signed("pia", intents.Transfer{Device: "device-3", Key: publicKey, Amount: 5000}, map[string][]byte{"device": device}), false, "RegisterOfflineDevice")
+	l.submit(p.bank, l.hold("pia", offlineHoldID("device-3"), 3000), false, "PlaceHold", addr("pia"), "3000", HoldPurposeOfflineFunding, offlineHoldID("device-3"))
+
+	// forward builds the proof from a device payment to payee, as processTransaction does
+	forward := func(payee string, counter, amount int64) map[string]interface{} {
+		intent, _ := json.Marshal(struct {
+			Amount  int64  `json:"amount"`
+			PayeeID string `json:"payee_id"`
+			Counter int64  `json:"counter"`
+			Nonce   string `json:"nonce"`
+		}{amount, payee, counter, "c2f1"})
+		return map[string]interface{}{
+			"device_id": "device-3",
+			"from":      addr("pia"),
+			"to":        addr("quinn"),
+			"amount":    amount,
+			"nonce":     counter,
+			"signature": hex.EncodeToString(ed25519.Sign(key, intent)),
+			"intent":    string(intent),
+		}
+	}
+
+	// An intent naming the payee's device instead of its address does not settle
+	batch, _ := json.Marshal([]map[string]interface{}{forward(addr("quinn"), 1, 400), forward("device-quinn", 2, 300)})
+	var result BatchReconcileResult
+	json.Unmarshal(l.submit(p.bank, nil, false, "BatchReconcile", string(batch)), &result)
+	if result.SuccessCount != 1 || len(result.Results) != 2 || result.Results[1].Reason != ReasonInvalidProof {
+		t.Errorf("batch = %+v", result)
+	}
+	if got := l.wallet(addr("quinn")).Balance; got != 400 {
+		t.Errorf("quinn balance = %d", got)
+	}
+}
@@ -0,0 +1,317 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Ledger keys for the circuit breaker
+const (
+	DocTypePause    = "PAUSE"     // scope~target of each active pause
+	DocTypePauseLog = "PAUSE_LOG" // timestamp~txID of every pause and unpause
+)
+
+// Pause scopes. An ALL pause has no target; INTERMEDIARY and OPERATION pauses
+// name the intermediary ID or operation they halt.
+const (
+	PauseScopeAll          = "ALL"
+	PauseScopeIntermediary = "INTERMEDIARY"
+	PauseScopeOperation    = "OPERATION"
+)
+
+// Operations that can be paused
+const (
+	OperationTransfer         = "Transfer"         // Transfer, TransferWithType, TransferAsset, BatchTransfer, PayRequest, Refund, CaptureHold, escrows
+	OperationOfflineReconcile = "OfflineReconcile" // ReconcileOffline, BatchReconcile
+	OperationRedeem           = "Redeem"           // Redeem, RedeemAsset
+)
+
+// Pause actions recorded in the pause log
+const (
+	PauseActionPaused   = "PAUSED"
+	PauseActionUnpaused = "UNPAUSED"
+)
+
+// Pause is an active emergency stop
+type Pause struct {
+	Scope    string `json:"scope"`
+	Target   string `json:"target,omitempty" metadata:",optional"`
+	Reason   string `json:"reason"`
+	Actor    string `json:"actor"` // Client identity ID of the caller
+	ActorMSP string `json:"actor_msp"`
+	TxID     string `json:"tx_id"`
+	PausedAt int64  `json:"paused_at"`
+}
+
+// PauseChange records a pause or unpause for audit
+type PauseChange struct {
+	Action    string `json:"action"` // PAUSED, UNPAUSED
+	Scope     string `json:"scope"`
+	Target    string `json:"target,omitempty" metadata:",optional"`
+	Reason    string `json:"reason"`
+	Actor     string `json:"actor"`
+	ActorMSP  string `json:"actor_msp"`
+	TxID      string `json:"tx_id"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func validatePauseScope(scope string, target string) error {
+	switch scope {
+	case PauseScopeAll:
+		if target != "" {
+			return fmt.Errorf("an %s pause takes no target", PauseScopeAll)
+		}
+	case PauseScopeIntermediary:
+		if target == "" {
+			return fmt.Errorf("an %s pause needs an intermediary ID", PauseScopeIntermediary)
+		}
+	case PauseScopeOperation:
+		if target != OperationTransfer && target != OperationOfflineReconcile && target != OperationRedeem {
+			return fmt.Errorf("unknown operation %q", target)
+		}
+	default:
+		return fmt.Errorf("unknown pause scope %q", scope)
+	}
+	return nil
+}
+
+func pauseKey(ctx contractapi.TransactionContextInterface, scope string, target string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypePause, []string{scope, target})
+}
+
+func readPause(ctx contractapi.TransactionContextInterface, scope string, target string) (*Pause, error) {
+	key, err := pauseKey(ctx, scope, target)
+	if err != nil {
+		return nil, err
+	}
+	pauseBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pause: %v", err)
+	}
+	if pauseBytes == nil {
+		return nil, nil
+	}
+
+	var pause Pause
+	if err := json.Unmarshal(pauseBytes, &pause); err != nil {
+		return nil, err
+	}
+	return &pause, nil
+}
+
+// activePause returns the pause that halts operation for any of the intermediaries, or nil
+func activePause(ctx contractapi.TransactionContextInterface, operation string, intermediaryIDs ...string) (*Pause, error) {
+	scopes := [][2]string{{PauseScopeAll, ""}, {PauseScopeOperation, operation}}
+	for _, id := range intermediaryIDs {
+		scopes = append(scopes, [2]string{PauseScopeIntermediary, id})
+	}
+	for _, scope := range scopes {
+		pause, err := readPause(ctx, scope[0], scope[1])
+		if err != nil {
+			return nil, err
+		}
+		if pause != nil {
+			return pause, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkNotPaused fails when operation is halted for any of the intermediaries
+func checkNotPaused(ctx contractapi.TransactionContextInterface, operation string, intermediaryIDs ...string) error {
+	pause, err := activePause(ctx, operation, intermediaryIDs...)
+	if err != nil {
+		return err
+	}
+	if pause != nil {
+		return pausedError(operation, pause)
+	}
+	return nil
+}
+
+func pausedError(operation string, pause *Pause) error {
+	scope := pause.Scope
+	if pause.Target != "" {
+		scope += " " + pause.Target
+	}
+	return fmt.Errorf("%s is paused (%s): %s", operation, scope, pause.Reason)
+}
+
+// recordPauseChange appends to the pause log and emits the PauseEvent
+func recordPauseChange(ctx contractapi.TransactionContextInterface, change PauseChange) error {
+	key, err := ctx.GetStub().CreateCompositeKey(DocTypePauseLog, []string{fmt.Sprintf("%020d", change.Timestamp), change.TxID})
+	if err != nil {
+		return err
+	}
+	changeBytes, _ := json.Marshal(change)
+	if err := ctx.GetStub().PutState(key, changeBytes); err != nil {
+		return err
+	}
+	return emitEvent(ctx, events.NamePause, events.Pause{
+		Action:   change.Action,
+		Scope:    change.Scope,
+		Target:   change.Target,
+		Reason:   change.Reason,
+		Actor:    change.Actor,
+		ActorMSP: change.ActorMSP,
+	})
+}
+
+// Pause halts transfers and offline reconciliation, either everywhere (ALL), for one
+// intermediary's wallets (INTERMEDIARY) or for one operation (OPERATION). Queries keep
+// working. Only Central Bank admins can pause.
+func (s *SmartContract) Pause(ctx contractapi.TransactionContextInterface, scope string, target string, reason string) (*Pause, error) {
+	c, err := requireCentralBankAdmin(ctx, "pausing")
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePauseScope(scope, target); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+
+	existing, err := readPause(ctx, scope, target)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("already paused by %s at %d: %s", existing.ActorMSP, existing.PausedAt, existing.Reason)
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pause := Pause{
+		Scope:    scope,
+		Target:   target,
+		Reason:   reason,
+		Actor:    c.ID,
+		ActorMSP: c.MSPID,
+		TxID:     ctx.GetStub().GetTxID(),
+		PausedAt: now.Unix(),
+	}
+	key, err := pauseKey(ctx, scope, target)
+	if err != nil {
+		return nil, err
+	}
+	pauseBytes, _ := json.Marshal(pause)
+	if err := ctx.GetStub().PutState(key, pauseBytes); err != nil {
+		return nil, err
+	}
+
+	if err := recordPauseChange(ctx, PauseChange{
+		Action:    PauseActionPaused,
+		Scope:     scope,
+		Target:    target,
+		Reason:    reason,
+		Actor:     c.ID,
+		ActorMSP:  c.MSPID,
+		TxID:      pause.TxID,
+		Timestamp: pause.PausedAt,
+	}); err != nil {
+		return nil, err
+	}
+	return &pause, nil
+}
+
+// Unpause lifts a pause. Only Central Bank admins can unpause.
+func (s *SmartContract) Unpause(ctx contractapi.TransactionContextInterface, scope string, target string, reason string) error {
+	c, err := requireCentralBankAdmin(ctx, "unpausing")
+	if err != nil {
+		return err
+	}
+	if reason == "" {
+		return fmt.Errorf("a reason is required")
+	}
+
+	existing, err := readPause(ctx, scope, target)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("no %s pause for %q", scope, target)
+	}
+
+	key, err := pauseKey(ctx, scope, target)
+	if err != nil {
+		return err
+	}
+	if err := ctx.GetStub().DelState(key); err != nil {
+		return err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	return recordPauseChange(ctx, PauseChange{
+		Action:    PauseActionUnpaused,
+		Scope:     scope,
+		Target:    target,
+		Reason:    reason,
+		Actor:     c.ID,
+		ActorMSP:  c.MSPID,
+		TxID:      ctx.GetStub().GetTxID(),
+		Timestamp: now.Unix(),
+	})
+}
+
+// GetPauses returns every active pause. Intermediaries read it to learn what is paused for them.
+func (s *SmartContract) GetPauses(ctx contractapi.TransactionContextInterface) ([]*Pause, error) {
+	if _, err := requireMSP(ctx, "reading pauses", MSPCentralBank, MSPRegulator, MSPBankConsortium); err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypePause, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	pauses := []*Pause{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var pause Pause
+		if err := json.Unmarshal(result.Value, &pause); err != nil {
+			return nil, err
+		}
+		pauses = append(pauses, &pause)
+	}
+	return pauses, nil
+}
+
+// GetPauseHistory returns every pause and unpause, oldest first
+func (s *SmartContract) GetPauseHistory(ctx contractapi.TransactionContextInterface) ([]*PauseChange, error) {
+	if _, err := requireMSP(ctx, "reading pauses", MSPCentralBank, MSPRegulator, MSPBankConsortium); err != nil {
+		return nil, err
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypePauseLog, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	changes := []*PauseChange{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var change PauseChange
+		if err := json.Unmarshal(result.Value, &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,81 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformancePause(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "nina", "Tier1", 10000)
+	l.fund(p, "oscar", "Tier1", 0)
+	l.createWallet(p.otherBank, "paul", "bank-b", "Tier1")
+
+	l.submit(p.bank, nil, true, "Pause", PauseScopeAll, "", "drill")
+	l.submit(p.centralBank, nil, false, "Pause", PauseScopeOperation, OperationTransfer, "ledger incident")
+	l.submit(p.bank, l.pay("nina", "oscar", 100), true, "Transfer", addr("nina"), addr("oscar"), "100")
+	legs := fmt.Sprintf(`[{"to_wallet_id":%q,"amount":100}]`, addr("oscar"))
+	l.submit(p.bank, l.signed("nina", intents.Transfer{Legs: intents.LegsHash(legs), Amount: 100}), true, "BatchTransfer", addr("nina"), legs)
+	if e := l.endorse(p.bank, "query", nil, "GetWallet", []string{addr("nina")}); e.status != 200 {
+		t.Errorf("GetWallet while paused: %s", e.message)
+	}
+	l.submit(p.centralBank, nil, false, "Unpause", PauseScopeOperation, OperationTransfer, "resolved")
+
+	l.submit(p.centralBank, nil, false, "Pause", PauseScopeIntermediary, "bank-b", "settlement default")
+	l.submit(p.bank, l.pay("nina", "paul", 100), true, "Transfer", addr("nina"), addr("paul"), "100")
+	l.submit(p.bank, l.pay("nina", "oscar", 100), false, "Transfer", addr("nina"), addr("oscar"), "100")
+	l.submit(p.centralBank, nil, true, "Pause", PauseScopeIntermediary, "bank-b", "again")
+	l.submit(p.centralBank, nil, false, "Unpause", PauseScopeIntermediary, "bank-b", "resolved")
+	l.submit(p.bank, l.pay("nina", "paul", 100), false, "Transfer", addr("nina"), addr("paul"), "100")
+	l.submit(p.centralBank, nil, true, "Unpause", PauseScopeAll, "", "not paused")
+}
+
+func TestConformancePauseCoverage(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "rhea", "Tier1", 10000)
+	l.createWallet(p.otherBank, "saul", "bank-b", "Tier1")
+
+	expiry := strconv.FormatInt(l.clock.Add(24*time.Hour).Unix(), 10)
+	condition, _ := json.Marshal(ReleaseCondition{Type: ConditionArbiter, Arbiter: p.regulator.id})
+	var escrow Escrow
+	json.Unmarshal(l.submit(p.bank, l.escrow("rhea", "saul", 500), false, "CreateEscrow", addr("rhea"), addr("saul"), "500", string(condition), expiry), &escrow)
+	l.submit(p.regulator, nil, false, "ApproveEscrow", escrow.ID)
+	l.submit(p.bank, l.hold("rhea", "auth-1", 1000), false, "PlaceHold", addr("rhea"), "1000", HoldPurposeAuthorization, "auth-1")
+	l.submit(p.regulator, nil, false, "PlaceLien", addr("rhea"), "1000", "case-1")
+
+	// Every movement touching bank-b stops, whichever side it is on
+	l.submit(p.centralBank, nil, false, "Pause", PauseScopeIntermediary, "bank-b", "settlement default")
+	l.submit(p.bank, l.escrow("rhea", "saul", 500), true, "CreateEscrow", addr("rhea"), addr("saul"), "500", string(condition), expiry)
+	l.submit(p.bank, nil, true, "ReleaseEscrow", escrow.ID)
+	l.submit(p.regulator, nil, true, "RefundEscrow", escrow.ID)
+	l.submit(p.bank, l.capture("rhea", "auth-1", "saul", 1000), true, "CaptureHold", "auth-1", addr("saul"), "1000")
+	l.submit(p.regulator, nil, true, "CaptureHold", lienHoldID(addr("rhea"), "case-1"), addr("saul"), "1000")
+	l.submit(p.centralBank, nil, false, "Unpause", PauseScopeIntermediary, "bank-b", "resolved")
+
+	l.submit(p.centralBank, nil, false, "Pause", PauseScopeOperation, OperationRedeem, "reserve reconciliation")
+	l.submit(p.centralBank, nil, true, "Redeem", "100", addr("rhea"))
+	l.submit(p.centralBank, nil, false, "Unpause", PauseScopeOperation, OperationRedeem, "resolved")
+
+	l.submit(p.centralBank, nil, false, "Pause", PauseScopeAll, "", "drill")
+	l.submit(p.centralBank, nil, true, "Redeem", "100", addr("rhea"))
+	l.submit(p.bank, l.capture("rhea", "auth-1", "saul", 1000), true, "CaptureHold", "auth-1", addr("saul"), "1000")
+	l.submit(p.centralBank, nil, false, "Unpause", PauseScopeAll, "", "drill over")
+
+	l.submit(p.centralBank, nil, false, "Redeem", "100", addr("rhea"))
+	l.submit(p.bank, nil, false, "ReleaseEscrow", escrow.ID)
+	l.submit(p.bank, l.capture("rhea", "auth-1", "saul", 1000), false, "CaptureHold", "auth-1", addr("saul"), "1000")
+	if got := l.wallet(addr("saul")).Balance; got != 1500 {
+		t.Errorf("saul balance = %d", got)
+	}
+}
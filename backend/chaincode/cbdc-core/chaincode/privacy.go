@@ -0,0 +1,337 @@
+package chaincode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Ledger keys for owner data and intermediary positions. Only the intermediary index is
+// in public world state; peers outside a collection see just the hash of its values.
+const (
+	DocTypeWalletOwner        = "WALLET_OWNER"        // walletID, in pdc-retail-wallets
+	DocTypeWalletAttribute    = "WALLET_ATTR"         // walletID~name, in pdc-retail-wallets
+	DocTypeIntermediaryWallet = "INTERMEDIARY_WALLET" // intermediaryID~walletID index, public
+	DocTypePosition           = "POSITION"            // intermediaryID, in pdc-intermediary-positions
+)
+
+// Transient map entries carrying private inputs
+const (
+	TransientWalletOwner = "wallet_owner" // WalletOwner attributes for CreateWallet
+	TransientAttribute   = "attribute"    // Disclosed value and salt for VerifyWalletAttribute
+	TransientPosition    = "position"     // Disclosed position record for VerifyIntermediaryPosition
+)
+
+// AttributeOwnerID is the attribute every wallet owner record must carry
+const AttributeOwnerID = "owner_id"
+
+// minSaltLength keeps low-entropy values such as birth dates from being recovered by
+// hashing guesses against the public private-data hash
+const minSaltLength = 16
+
+// WalletAttribute is one owner or KYC attribute. Each is stored under its own key so
+// that its hash, which every peer holds, proves a single disclosed value.
+type WalletAttribute struct {
+	WalletID string `json:"wallet_id"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Salt     string `json:"salt"`
+}
+
+// WalletOwner is the private owner record of a wallet
+type WalletOwner struct {
+	WalletID   string            `json:"wallet_id"`
+	Attributes []WalletAttribute `json:"attributes"`
+}
+
+// IntermediaryPosition is a snapshot of the funds held in an intermediary's wallets
+type IntermediaryPosition struct {
+	IntermediaryID string           `json:"intermediary_id"`
+	WalletCount    int              `json:"wallet_count"`
+	Balance        int64            `json:"balance"` // NGN, including held funds
+	Held           int64            `json:"held"`
+	Balances       map[string]int64 `json:"balances,omitempty" metadata:",optional"` // Other assets
+	AsOf           int64            `json:"as_of"`
+	TxID           string           `json:"tx_id"`
+}
+
+func walletOwnerKey(ctx contractapi.TransactionContextInterface, walletID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeWalletOwner, []string{walletID})
+}
+
+func walletAttributeKey(ctx contractapi.TransactionContextInterface, walletID string, name string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeWalletAttribute, []string{walletID, name})
+}
+
+func positionKey(ctx contractapi.TransactionContextInterface, intermediaryID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypePosition, []string{intermediaryID})
+}
+
+// readTransient returns a required transient map entry
+func readTransient(ctx contractapi.TransactionContextInterface, name string) ([]byte, error) {
+	transient, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transient data: %v", err)
+	}
+	value, ok := transient[name]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("%s must be passed in the transient map", name)
+	}
+	return value, nil
+}
+
+// putWalletOwner validates the transient owner record of a new wallet and stores it in
+// the retail wallets collection, with one hashed key per attribute
+func putWalletOwner(ctx contractapi.TransactionContextInterface, walletID string) error {
+	ownerJSON, err := readTransient(ctx, TransientWalletOwner)
+	if err != nil {
+		return err
+	}
+	var owner WalletOwner
+	if err := json.Unmarshal(ownerJSON, &owner); err != nil {
+		return fmt.Errorf("failed to parse wallet owner: %v", err)
+	}
+
+	owner.WalletID = walletID
+	seen := map[string]bool{}
+	for i := range owner.Attributes {
+		attribute := &owner.Attributes[i]
+		attribute.WalletID = walletID
+		if attribute.Name == "" || attribute.Value == "" {
+			return fmt.Errorf("attribute %d needs a name and a value", i)
+		}
+		if seen[attribute.Name] {
+			return fmt.Errorf("duplicate attribute %q", attribute.Name)
+		}
+		seen[attribute.Name] = true
+		if len(attribute.Salt) < minSaltLength {
+			return fmt.Errorf("attribute %q needs a salt of at least %d characters", attribute.Name, minSaltLength)
+		}
+
+		key, err := walletAttributeKey(ctx, walletID, attribute.Name)
+		if err != nil {
+			return err
+		}
+		attributeBytes, _ := json.Marshal(attribute)
+		if err := ctx.GetStub().PutPrivateData(CollectionRetailWallets, key, attributeBytes); err != nil {
+			return err
+		}
+	}
+	if !seen[AttributeOwnerID] {
+		return fmt.Errorf("wallet owner must include the %s attribute", AttributeOwnerID)
+	}
+
+	key, err := walletOwnerKey(ctx, walletID)
+	if err != nil {
+		return err
+	}
+	ownerBytes, _ := json.Marshal(owner)
+	return ctx.GetStub().PutPrivateData(CollectionRetailWallets, key, ownerBytes)
+}
+
+// indexIntermediaryWallet lists the wallet under its intermediary for position snapshots
+func indexIntermediaryWallet(ctx contractapi.TransactionContextInterface, wallet *Wallet) error {
+	key, err := ctx.GetStub().CreateCompositeKey(DocTypeIntermediaryWallet, []string{wallet.IntermediaryID, wallet.ID})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, []byte{0x00})
+}
+
+// matchesPrivateDataHash reports whether value hashes to the private data every peer holds a hash of
+func matchesPrivateDataHash(ctx contractapi.TransactionContextInterface, collection string, key string, value []byte) (bool, error) {
+	onChain, err := ctx.GetStub().GetPrivateDataHash(collection, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read private data hash: %v", err)
+	}
+	if onChain == nil {
+		return false, nil
+	}
+	sum := sha256.Sum256(value)
+	return bytes.Equal(onChain, sum[:]), nil
+}
+
+// GetWalletOwner returns the private owner record of a wallet. Only the managing
+// intermediary and the Central Bank, both members of the collection, can read it.
+func (s *SmartContract) GetWalletOwner(ctx contractapi.TransactionContextInterface, walletID string) (*WalletOwner, error) {
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.MSPID != MSPCentralBank && !c.manages(wallet) {
+		return nil, fmt.Errorf("unauthorized: the owner of wallet %s is not visible to %s", walletID, c.IntermediaryID)
+	}
+
+	key, err := walletOwnerKey(ctx, walletID)
+	if err != nil {
+		return nil, err
+	}
+	ownerBytes, err := ctx.GetStub().GetPrivateData(CollectionRetailWallets, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet owner: %v", err)
+	}
+	if ownerBytes == nil {
+		return nil, fmt.Errorf("wallet %s has no owner record", walletID)
+	}
+
+	var owner WalletOwner
+	if err := json.Unmarshal(ownerBytes, &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// VerifyWalletAttribute checks a disclosed attribute value against the hash of the private
+// record, so the Regulator can confirm it without being a member of the collection. The
+// value and salt are passed in the transient map under "attribute" as {"value", "salt"}.
+func (s *SmartContract) VerifyWalletAttribute(ctx contractapi.TransactionContextInterface, walletID string, name string) (bool, error) {
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return false, err
+	}
+	if err := requireWalletReader(ctx, wallet); err != nil {
+		return false, err
+	}
+
+	disclosedJSON, err := readTransient(ctx, TransientAttribute)
+	if err != nil {
+		return false, err
+	}
+	var disclosed WalletAttribute
+	if err := json.Unmarshal(disclosedJSON, &disclosed); err != nil {
+		return false, fmt.Errorf("failed to parse attribute: %v", err)
+	}
+
+	key, err := walletAttributeKey(ctx, walletID, name)
+	if err != nil {
+		return false, err
+	}
+	attributeBytes, _ := json.Marshal(WalletAttribute{WalletID: walletID, Name: name, Value: disclosed.Value, Salt: disclosed.Salt})
+	return matchesPrivateDataHash(ctx, CollectionRetailWallets, key, attributeBytes)
+}
+
+// RecordIntermediaryPosition snapshots the funds in an intermediary's wallets into the
+// positions collection. The intermediary itself or a Central Bank admin can record it.
+func (s *SmartContract) RecordIntermediaryPosition(ctx contractapi.TransactionContextInterface, intermediaryID string) (*IntermediaryPosition, error) {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin := c.MSPID == MSPCentralBank && c.Role == RoleAdmin
+	if !isAdmin && (c.MSPID != MSPBankConsortium || c.IntermediaryID != intermediaryID) {
+		return nil, fmt.Errorf("unauthorized: %s cannot record the position of %s", c.IntermediaryID, intermediaryID)
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypeIntermediaryWallet, []string{intermediaryID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	position := IntermediaryPosition{
+		IntermediaryID: intermediaryID,
+		Balances:       map[string]int64{},
+		AsOf:           now.Unix(),
+		TxID:           ctx.GetStub().GetTxID(),
+	}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, parts, err := ctx.GetStub().SplitCompositeKey(result.Key)
+		if err != nil {
+			return nil, err
+		}
+		wallet, err := readWallet(ctx, parts[1])
+		if err != nil {
+			return nil, err
+		}
+
+		position.WalletCount++
+		position.Held += wallet.Held
+		for asset, balance := range wallet.assetBalances() {
+			if asset == assets.Default {
+				position.Balance += balance
+			} else {
+				position.Balances[asset] += balance
+			}
+		}
+	}
+
+	key, err := positionKey(ctx, intermediaryID)
+	if err != nil {
+		return nil, err
+	}
+	positionBytes, _ := json.Marshal(position)
+	if err := ctx.GetStub().PutPrivateData(CollectionIntermediaryPositions, key, positionBytes); err != nil {
+		return nil, err
+	}
+
+	// The event only announces the snapshot; amounts stay in the collection
+	if err := emitEvent(ctx, events.NamePosition, events.Position{IntermediaryID: intermediaryID}); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// GetIntermediaryPosition returns the last position snapshot of an intermediary, visible
+// to the intermediary itself and the Central Bank
+func (s *SmartContract) GetIntermediaryPosition(ctx contractapi.TransactionContextInterface, intermediaryID string) (*IntermediaryPosition, error) {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.MSPID != MSPCentralBank && (c.MSPID != MSPBankConsortium || c.IntermediaryID != intermediaryID) {
+		return nil, fmt.Errorf("unauthorized: the position of %s is not visible to %s", intermediaryID, c.IntermediaryID)
+	}
+
+	key, err := positionKey(ctx, intermediaryID)
+	if err != nil {
+		return nil, err
+	}
+	positionBytes, err := ctx.GetStub().GetPrivateData(CollectionIntermediaryPositions, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read position: %v", err)
+	}
+	if positionBytes == nil {
+		return nil, fmt.Errorf("no position recorded for %s", intermediaryID)
+	}
+
+	var position IntermediaryPosition
+	if err := json.Unmarshal(positionBytes, &position); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// VerifyIntermediaryPosition checks a position record disclosed by an intermediary, passed
+// in the transient map under "position" exactly as GetIntermediaryPosition returned it,
+// against the hash of the last snapshot. Restricted to the Central Bank and Regulator.
+func (s *SmartContract) VerifyIntermediaryPosition(ctx contractapi.TransactionContextInterface, intermediaryID string) (bool, error) {
+	if _, err := requireMSP(ctx, "position verification", MSPCentralBank, MSPRegulator); err != nil {
+		return false, err
+	}
+	disclosed, err := readTransient(ctx, TransientPosition)
+	if err != nil {
+		return false, err
+	}
+	key, err := positionKey(ctx, intermediaryID)
+	if err != nil {
+		return false, err
+	}
+	return matchesPrivateDataHash(ctx, CollectionIntermediaryPositions, key, disclosed)
+}
@@ -0,0 +1,88 @@
+package chaincode
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestConformancePrivateData(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	owner := func(attributes ...WalletAttribute) map[string][]byte {
+		ownerBytes, _ := json.Marshal(WalletOwner{Attributes: attributes})
+		return map[string][]byte{TransientWalletOwner: ownerBytes}
+	}
+	ownerID := WalletAttribute{Name: AttributeOwnerID, Value: "bvn-22212345678", Salt: ownerSalt}
+	dob := WalletAttribute{Name: "date_of_birth", Value: "1990-04-01", Salt: "fedcba9876543210"}
+	l.submit(p.bank, nil, true, "CreateWallet", addr("wen"), "bank-a", "Tier1", ownerKeyHex("wen"))
+	l.submit(p.bank, owner(dob), true, "CreateWallet", addr("wen"), "bank-a", "Tier1", ownerKeyHex("wen"))
+	l.submit(p.bank, owner(ownerID, WalletAttribute{Name: "date_of_birth", Value: "1990-04-01", Salt: "short"}), true, "CreateWallet", addr("wen"), "bank-a", "Tier1", ownerKeyHex("wen"))
+	l.submit(p.bank, owner(ownerID, dob), true, "CreateWallet", "wallet-wen", "bank-a", "Tier1", ownerKeyHex("wen"))
+	mistyped := []byte(addr("wen"))
+	mistyped[10] ^= 1
+	l.submit(p.bank, owner(ownerID, dob), true, "CreateWallet", string(mistyped), "bank-a", "Tier1", ownerKeyHex("wen"))
+	l.submit(p.bank, owner(ownerID, dob), false, "CreateWallet", addr("wen"), "bank-a", "Tier1", ownerKeyHex("wen"))
+	l.submit(p.centralBank, nil, false, "Issue", "4000", addr("wen"))
+	l.fund(p, "xia", "Tier1", 1500)
+	l.createWallet(p.otherBank, "yul", "bank-b", "Tier1")
+
+	if bytes.Contains(l.stub.State[addr("wen")], []byte(ownerID.Value)) {
+		t.Errorf("public wallet state leaks the owner: %s", l.stub.State[addr("wen")])
+	}
+	var record WalletOwner
+	json.Unmarshal(l.endorse(p.bank, "query", nil, "GetWalletOwner", []string{addr("wen")}).payload, &record)
+	if len(record.Attributes) != 2 || record.Attributes[0].Value != ownerID.Value {
+		t.Errorf("wen owner = %+v", record)
+	}
+	if got := l.endorse(p.otherBank, "query", nil, "GetWalletOwner", []string{addr("wen")}); got.status == 200 {
+		t.Errorf("bank-b read the owner of wen")
+	}
+	if got := l.endorse(p.regulator, "query", nil, "GetWalletOwner", []string{addr("wen")}); got.status == 200 {
+		t.Errorf("the regulator read the owner of wen")
+	}
+
+	attribute := func(value string, salt string) []byte {
+		b, _ := json.Marshal(map[string]string{"value": value, "salt": salt})
+		return b
+	}
+	verifyAttribute := func(caller identity, disclosed []byte) string {
+		return string(l.endorse(caller, "query", map[string][]byte{TransientAttribute: disclosed}, "VerifyWalletAttribute", []string{addr("wen"), "date_of_birth"}).payload)
+	}
+	if got := verifyAttribute(p.regulator, attribute(dob.Value, dob.Salt)); got != "true" {
+		t.Errorf("disclosed date of birth verified = %s", got)
+	}
+	if got := verifyAttribute(p.regulator, attribute("1990-04-02", dob.Salt)); got != "false" {
+		t.Errorf("wrong date of birth verified = %s", got)
+	}
+	if got := l.endorse(p.otherBank, "query", map[string][]byte{TransientAttribute: attribute(dob.Value, dob.Salt)}, "VerifyWalletAttribute", []string{addr("wen"), "date_of_birth"}); got.status == 200 {
+		t.Errorf("bank-b verified an attribute of wen")
+	}
+
+	l.submit(p.bank, nil, true, "RecordIntermediaryPosition", "bank-b")
+	l.submit(p.regulator, nil, true, "RecordIntermediaryPosition", "bank-a")
+	l.submit(p.bank, nil, false, "RecordIntermediaryPosition", "bank-a")
+
+	disclosed := l.endorse(p.bank, "query", nil, "GetIntermediaryPosition", []string{"bank-a"}).payload
+	var position IntermediaryPosition
+	json.Unmarshal(disclosed, &position)
+	if position.WalletCount != 2 || position.Balance != 4000+1500 {
+		t.Errorf("bank-a position = %+v", position)
+	}
+	if got := l.endorse(p.otherBank, "query", nil, "GetIntermediaryPosition", []string{"bank-a"}); got.status == 200 {
+		t.Errorf("bank-b read the position of bank-a")
+	}
+	verifyPosition := func(disclosed []byte) string {
+		return string(l.endorse(p.regulator, "query", map[string][]byte{TransientPosition: disclosed}, "VerifyIntermediaryPosition", []string{"bank-a"}).payload)
+	}
+	if got := verifyPosition(disclosed); got != "true" {
+		t.Errorf("disclosed position verified = %s", got)
+	}
+	position.Balance--
+	understated, _ := json.Marshal(position)
+	if got := verifyPosition(understated); got != "false" {
+		t.Errorf("understated position verified = %s", got)
+	}
+}
@@ -0,0 +1,222 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DocTypeRefund keys the refunds made against an original transaction (originalTxID)
+const DocTypeRefund = "REFUND"
+
+// Refund kinds, recorded as the Type of the reversing Transaction
+const (
+	RefundKindRefund     = "Refund"     // Initiated by the payee's intermediary
+	RefundKindChargeback = "Chargeback" // Forced by the Central Bank after a dispute
+)
+
+// RefundEntry is one refund or chargeback of an original transaction
+type RefundEntry struct {
+	TxID      string `json:"tx_id"`
+	Kind      string `json:"kind"` // Refund, Chargeback
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+	Actor     string `json:"actor"`
+	ActorMSP  string `json:"actor_msp"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// RefundSummary links an original transaction to every refund made against it
+type RefundSummary struct {
+	OriginalTxID   string        `json:"original_tx_id"`
+	OriginalAmount int64         `json:"original_amount"`
+	Refunded       int64         `json:"refunded"` // Never exceeds OriginalAmount
+	Refunds        []RefundEntry `json:"refunds"`
+}
+
+func refundKey(ctx contractapi.TransactionContextInterface, originalTxID string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeRefund, []string{originalTxID})
+}
+
+// readRefundSummary loads the refunds of a transaction, or an empty summary if there are none
+func readRefundSummary(ctx contractapi.TransactionContextInterface, original *Transaction) (*RefundSummary, error) {
+	key, err := refundKey(ctx, original.ID)
+	if err != nil {
+		return nil, err
+	}
+	summaryBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refunds: %v", err)
+	}
+	if summaryBytes == nil {
+		return &RefundSummary{OriginalTxID: original.ID, OriginalAmount: original.Amount, Refunds: []RefundEntry{}}, nil
+	}
+
+	var summary RefundSummary
+	if err := json.Unmarshal(summaryBytes, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// Refund returns amount of an original transfer from its payee to its payer. The payee's
+// intermediary issues refunds with the payee owner's authorization; a Central Bank admin
+// can force a chargeback without it. Refunds of the
+// same transfer may be partial but never exceed its amount in total. No fee is charged and
+// the original fee is not returned.
+func (s *SmartContract) Refund(ctx contractapi.TransactionContextInterface, originalTxID string, amount int64, reason string) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+
+	original, err := readTransaction(ctx, originalTxID)
+	if err != nil {
+		return nil, err
+	}
+	if original.Type != "Transfer" {
+		return nil, fmt.Errorf("only transfers can be refunded, %s is a %s", originalTxID, original.Type)
+	}
+	if original.From == original.To {
+		return nil, fmt.Errorf("transfer %s has the same payer and payee", originalTxID)
+	}
+
+	// The refund moves funds back along the original transfer
+	payee, err := readWallet(ctx, original.To)
+	if err != nil {
+		return nil, err
+	}
+	payer, err := readWallet(ctx, original.From)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kind := RefundKindRefund
+	switch {
+	case c.MSPID == MSPCentralBank && c.Role == RoleAdmin:
+		kind = RefundKindChargeback
+	case !c.manages(payee):
+		return nil, fmt.Errorf("unauthorized: only the payee's intermediary can refund %s", originalTxID)
+	}
+
+	summary, err := readRefundSummary(ctx, original)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Refunded+amount > original.Amount {
+		return nil, fmt.Errorf("refund of %d exceeds the %d remaining on %s", amount, original.Amount-summary.Refunded, originalTxID)
+	}
+
+	asset := assets.OrDefault(original.Asset)
+	// A chargeback is the Central Bank reversing funds, typically from a frozen or
+	// suspended merchant, so only a voluntary refund needs a payee that can send
+	if kind == RefundKindRefund {
+		if err := payee.canSend(); err != nil {
+			return nil, err
+		}
+		if err := authorizeDebit(ctx, payee, intents.Transfer{To: payer.ID, Refund: originalTxID, Asset: asset, Amount: amount}); err != nil {
+			return nil, err
+		}
+	}
+	if payee.spendableOf(asset) < amount {
+		return nil, fmt.Errorf("insufficient funds")
+	}
+	if err := payer.canReceive(); err != nil {
+		return nil, err
+	}
+	if err := checkNotPaused(ctx, OperationTransfer, payee.IntermediaryID, payer.IntermediaryID); err != nil {
+		return nil, err
+	}
+	if asset == assets.Default {
+		doc, err := readParams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBalanceCeiling(doc, payer, amount); err != nil {
+			return nil, err
+		}
+	}
+
+	payee.adjustBalance(asset, -amount)
+	payer.adjustBalance(asset, amount)
+	if err := putWallet(ctx, payee); err != nil {
+		return nil, err
+	}
+	if err := putWallet(ctx, payer); err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx := Transaction{
+		ID:        ctx.GetStub().GetTxID(),
+		Type:      kind,
+		From:      payee.ID,
+		To:        payer.ID,
+		Amount:    amount,
+		Timestamp: now.Unix(),
+		Asset:     asset,
+		Reference: originalTxID,
+	}
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, err
+	}
+
+	summary.Refunded += amount
+	summary.Refunds = append(summary.Refunds, RefundEntry{
+		TxID:      tx.ID,
+		Kind:      kind,
+		Amount:    amount,
+		Reason:    reason,
+		Actor:     c.ID,
+		ActorMSP:  c.MSPID,
+		Timestamp: tx.Timestamp,
+	})
+	key, err := refundKey(ctx, originalTxID)
+	if err != nil {
+		return nil, err
+	}
+	summaryBytes, _ := json.Marshal(summary)
+	if err := ctx.GetStub().PutState(key, summaryBytes); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, events.NameRefund, events.Refund{
+		OriginalTxID: originalTxID,
+		Kind:         kind,
+		From:         payee.ID,
+		To:           payer.ID,
+		Asset:        asset,
+		Amount:       amount,
+		Refunded:     summary.Refunded,
+		Reason:       reason,
+	}); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// GetRefunds returns the refunds made against a transaction. Visible to whoever can
+// read the original transaction.
+func (s *SmartContract) GetRefunds(ctx contractapi.TransactionContextInterface, originalTxID string) (*RefundSummary, error) {
+	original, err := readTransaction(ctx, originalTxID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTransactionReader(ctx, original); err != nil {
+		return nil, err
+	}
+	return readRefundSummary(ctx, original)
+}
@@ -0,0 +1,55 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformanceRefunds(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "uma", "Tier1", 10000)
+	l.createWallet(p.otherBank, "vic", "bank-b", "Tier1")
+
+	var original Transaction
+	json.Unmarshal(l.submit(p.bank, l.pay("uma", "vic", 3000), false, "Transfer", addr("uma"), addr("vic"), "3000"), &original)
+
+	signedRefund := func(amount int64) map[string][]byte {
+		return l.signed("vic", intents.Transfer{To: addr("uma"), Refund: original.ID, Amount: amount})
+	}
+	l.submit(p.bank, signedRefund(1000), true, "Refund", original.ID, "1000", "wrong item")
+	l.submit(p.otherBank, nil, true, "Refund", original.ID, "1000", "wrong item")                       // Not authorized by vic
+	l.submit(p.otherBank, l.pay("vic", "uma", 1000), true, "Refund", original.ID, "1000", "wrong item") // A transfer signature is not a refund
+	l.submit(p.otherBank, signedRefund(1000), false, "Refund", original.ID, "1000", "wrong item")
+	l.submit(p.otherBank, signedRefund(2500), true, "Refund", original.ID, "2500", "remainder")
+	l.submit(p.centralBank, nil, false, "Refund", original.ID, "2000", "dispute upheld")
+	l.submit(p.otherBank, signedRefund(1), true, "Refund", original.ID, "1", "over refund")
+	l.submit(p.centralBank, nil, true, "Refund", "tx0002", "1", "mint") // Issue to uma
+
+	var summary RefundSummary
+	json.Unmarshal(l.endorse(p.bank, "query", nil, "GetRefunds", []string{original.ID}).payload, &summary)
+	if summary.Refunded != 3000 || len(summary.Refunds) != 2 || summary.Refunds[1].Kind != RefundKindChargeback {
+		t.Errorf("refunds = %+v", summary)
+	}
+	var refund Transaction
+	json.Unmarshal(l.stub.State[summary.Refunds[0].TxID], &refund)
+	if refund.Type != RefundKindRefund || refund.Reference != original.ID || refund.From != addr("vic") {
+		t.Errorf("refund transaction = %+v", refund)
+	}
+	if got := l.wallet(addr("uma")).Balance; got != 10000 {
+		t.Errorf("uma balance = %d", got)
+	}
+
+	// A frozen payee cannot refund, but the Central Bank can still charge back
+	json.Unmarshal(l.submit(p.bank, l.pay("uma", "vic", 1500), false, "Transfer", addr("uma"), addr("vic"), "1500"), &original)
+	l.submit(p.regulator, nil, false, "FreezeWallet", addr("vic"), StatusReasonFraudSuspected)
+	l.submit(p.otherBank, signedRefund(500), true, "Refund", original.ID, "500", "wrong item")
+	l.submit(p.centralBank, nil, false, "Refund", original.ID, "1500", "fraud confirmed")
+	if got := l.wallet(addr("uma")).Balance; got != 10000 {
+		t.Errorf("uma balance after chargeback = %d", got)
+	}
+}
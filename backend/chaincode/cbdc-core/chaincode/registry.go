@@ -0,0 +1,193 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Asset is a currency or denomination that wallets can hold. Only identities
+// carrying role=admin in the issuer's MSP may issue or redeem it.
+type Asset struct {
+	Code      string `json:"code"` // e.g. NGN, USD, WNGN
+	Name      string `json:"name"`
+	Decimals  int    `json:"decimals"` // Amounts are in units of 10^-Decimals
+	IssuerMSP string `json:"issuer_msp"`
+}
+
+// defaultAsset is built in so that single-asset calls work on a fresh ledger
+var defaultAsset = Asset{Code: assets.Default, Name: "eNaira", Decimals: 2, IssuerMSP: MSPCentralBank}
+
+// balanceOf returns the wallet's balance of an asset
+func (w *Wallet) balanceOf(asset string) int64 {
+	if asset == assets.Default {
+		return w.Balance
+	}
+	return w.Balances[asset]
+}
+
+// spendableOf returns the funds of an asset that can be debited. Holds only
+// reserve the default asset.
+func (w *Wallet) spendableOf(asset string) int64 {
+	if asset == assets.Default {
+		return w.available()
+	}
+	return w.Balances[asset]
+}
+
+// adjustBalance credits (positive delta) or debits (negative delta) an asset
+func (w *Wallet) adjustBalance(asset string, delta int64) {
+	if asset == assets.Default {
+		w.Balance += delta
+		return
+	}
+	if w.Balances == nil {
+		w.Balances = map[string]int64{}
+	}
+	w.Balances[asset] += delta
+	if w.Balances[asset] == 0 {
+		delete(w.Balances, asset)
+	}
+}
+
+// assetBalances returns every non-zero balance keyed by asset code, including the default asset
+func (w *Wallet) assetBalances() map[string]int64 {
+	balances := map[string]int64{}
+	for asset, balance := range w.Balances {
+		if asset != assets.Default && balance != 0 {
+			balances[asset] = balance
+		}
+	}
+	if w.Balance != 0 {
+		balances[assets.Default] = w.Balance
+	}
+	return balances
+}
+
+func assetKey(ctx contractapi.TransactionContextInterface, code string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(DocTypeAsset, []string{code})
+}
+
+// readAsset loads a registered asset, or the built-in default asset
+func readAsset(ctx contractapi.TransactionContextInterface, code string) (*Asset, error) {
+	if code == assets.Default {
+		asset := defaultAsset
+		return &asset, nil
+	}
+	key, err := assetKey(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	assetBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset: %v", err)
+	}
+	if assetBytes == nil {
+		return nil, fmt.Errorf("asset %s is not registered", code)
+	}
+
+	var asset Asset
+	if err := json.Unmarshal(assetBytes, &asset); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// requireAssetIssuer allows only identities carrying role=admin in the asset's issuer MSP
+func requireAssetIssuer(ctx contractapi.TransactionContextInterface, asset *Asset, action string) (*caller, error) {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if c.MSPID != asset.IssuerMSP || c.Role != RoleAdmin {
+		return nil, fmt.Errorf("unauthorized: %s of %s is restricted to %s identities with the %s role", action, asset.Code, asset.IssuerMSP, RoleAdmin)
+	}
+	return c, nil
+}
+
+// RegisterAsset adds an asset that wallets can hold and names the MSP allowed to issue it.
+// Only the Central Bank can register assets; the default asset is built in.
+func (s *SmartContract) RegisterAsset(ctx contractapi.TransactionContextInterface, code string, name string, decimals int, issuerMSP string) (*Asset, error) {
+	if _, err := requireCentralBankAdmin(ctx, "asset registration"); err != nil {
+		return nil, err
+	}
+	if !assets.ValidCode(code) {
+		return nil, fmt.Errorf("invalid asset code %q", code)
+	}
+	if code == assets.Default {
+		return nil, fmt.Errorf("asset %s is built in", code)
+	}
+	if decimals < 0 || decimals > 18 {
+		return nil, fmt.Errorf("decimals must be between 0 and 18")
+	}
+	if issuerMSP == "" {
+		return nil, fmt.Errorf("issuer MSP is required")
+	}
+
+	key, err := assetKey(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("asset %s already exists", code)
+	}
+
+	asset := Asset{Code: code, Name: name, Decimals: decimals, IssuerMSP: issuerMSP}
+	assetBytes, _ := json.Marshal(asset)
+	if err := ctx.GetStub().PutState(key, assetBytes); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, events.NameAsset, events.Asset{Code: code, Name: name, Decimals: decimals, IssuerMSP: issuerMSP}); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// GetAsset returns a registered asset or the default asset
+func (s *SmartContract) GetAsset(ctx contractapi.TransactionContextInterface, code string) (*Asset, error) {
+	return readAsset(ctx, code)
+}
+
+// ListAssets returns the default asset followed by every registered asset
+func (s *SmartContract) ListAssets(ctx contractapi.TransactionContextInterface) ([]*Asset, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(DocTypeAsset, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	asset := defaultAsset
+	list := []*Asset{&asset}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var asset Asset
+		if err := json.Unmarshal(result.Value, &asset); err != nil {
+			return nil, err
+		}
+		list = append(list, &asset)
+	}
+	return list, nil
+}
+
+// GetAssetSupply returns the running totals of an asset
+func (s *SmartContract) GetAssetSupply(ctx contractapi.TransactionContextInterface, code string) (*SupplyRecord, error) {
+	if _, err := requireMSP(ctx, "reading supply", MSPCentralBank, MSPRegulator); err != nil {
+		return nil, err
+	}
+	if _, err := readAsset(ctx, code); err != nil {
+		return nil, err
+	}
+	return readSupply(ctx, code)
+}
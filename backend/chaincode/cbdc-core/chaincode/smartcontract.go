@@ -3,32 +3,17 @@ package chaincode
 import (
 	"encoding/json"
 	"fmt"
-	"time"
-
+	"slices"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
-// Wallet represents a user's holding capability
-type Wallet struct {
-	ID             string `json:"id"`
-	OwnerID        string `json:"owner_id"`
-	IntermediaryID string `json:"intermediary_id"`
-	Tier           string `json:"tier"`
-	Status         string `json:"status"`
-	Balance        int64  `json:"balance"`
-}
-
-// Transaction represents a movement of funds
-type Transaction struct {
-	ID        string `json:"id"`
-	Type      string `json:"type"`
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Amount    int64  `json:"amount"`
-	Timestamp int64  `json:"timestamp"`
-	Signature []byte `json:"signature,omitempty"` // Added for Phase 4
-}
-
 // SmartContract provides functions for managing a CBDC
 type SmartContract struct {
 	contractapi.Contract
@@ -41,194 +26,449 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 }
 
 // Issue mints new CBDC to a bank's wallet. Only Central Bank can call this.
-func (s *SmartContract) Issue(ctx contractapi.TransactionContextInterface, amount int64, toWalletID string) error {
-	// Check if caller is from Central Bank MSP
-	mspID, err := ctx.GetClientIdentity().GetMSPID()
+// Issue, Redeem and the transfer functions accept an optional idempotency key in the
+// transient map; a retry carrying the same key returns the original transaction.
+func (s *SmartContract) Issue(ctx contractapi.TransactionContextInterface, amount int64, toWalletID string) (*Transaction, error) {
+	return s.IssueAsset(ctx, assets.Default, amount, toWalletID)
+}
+
+// IssueAsset mints an asset to a wallet. Only admins of the asset's issuer can call this.
+func (s *SmartContract) IssueAsset(ctx contractapi.TransactionContextInterface, assetCode string, amount int64, toWalletID string) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	asset, err := readAsset(ctx, assetCode)
 	if err != nil {
-		return fmt.Errorf("failed to get MSP ID: %v", err)
+		return nil, err
 	}
-	if mspID != "CentralBankMSP" {
-		return fmt.Errorf("unauthorized: only Central Bank can issue CBDC")
+	// Only an issuer identity carrying role=admin may mint
+	if _, err := requireAssetIssuer(ctx, asset, "issuance"); err != nil {
+		return nil, err
+	}
+	idem, err := beginIdempotent(ctx, "Issue", assetCode, amount, toWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if idem.replay != nil {
+		return idem.replay, nil
 	}
-
-	// In a real prod environment, we would also check for specific 'admin' attribute or OU
-	// val, found, err := ctx.GetClientIdentity().GetAttributeValue("role")
-	// if !found || val != "admin" { ... }
 
 	walletBytes, err := ctx.GetStub().GetState(toWalletID)
 	if err != nil {
-		return fmt.Errorf("failed to read wallet: %v", err)
+		return nil, fmt.Errorf("failed to read wallet: %v", err)
 	}
 	if walletBytes == nil {
-		return fmt.Errorf("wallet %s does not exist", toWalletID)
+		return nil, fmt.Errorf("wallet %s does not exist", toWalletID)
 	}
 
 	var wallet Wallet
 	err = json.Unmarshal(walletBytes, &wallet)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := wallet.canReceive(); err != nil {
+		return nil, err
 	}
 
-	wallet.Balance += amount
+	wallet.adjustBalance(assetCode, amount)
 
 	updatedWalletBytes, _ := json.Marshal(wallet)
 	err = ctx.GetStub().PutState(toWalletID, updatedWalletBytes)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := recordSupplyChange(ctx, assetCode, amount, 0); err != nil {
+		return nil, err
 	}
 
 	// Record Transaction
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
 	tx := Transaction{
 		ID:        ctx.GetStub().GetTxID(),
 		Type:      "Mint",
 		From:      "CentralBank", // Minting source
 		To:        toWalletID,
 		Amount:    amount,
-		Timestamp: time.Now().Unix(),
+		Timestamp: now.Unix(),
+		Asset:     assetCode,
 	}
-	txBytes, _ := json.Marshal(tx)
-	return ctx.GetStub().PutState(tx.ID, txBytes)
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, err
+	}
+
+	if err := idem.complete(ctx, &tx); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, events.NameMint, events.Mint{WalletID: toWalletID, Asset: assetCode, Amount: amount}); err != nil {
+		return nil, err
+	}
+	return &tx, nil
 }
 
 // Redeem burns CBDC from a bank's wallet. Only Central Bank can call this.
-func (s *SmartContract) Redeem(ctx contractapi.TransactionContextInterface, amount int64, fromWalletID string) error {
-	// Check if caller is from Central Bank MSP
-	mspID, err := ctx.GetClientIdentity().GetMSPID()
+func (s *SmartContract) Redeem(ctx contractapi.TransactionContextInterface, amount int64, fromWalletID string) (*Transaction, error) {
+	return s.RedeemAsset(ctx, assets.Default, amount, fromWalletID)
+}
+
+// RedeemAsset burns an asset from a wallet. Only admins of the asset's issuer can call this.
+func (s *SmartContract) RedeemAsset(ctx contractapi.TransactionContextInterface, assetCode string, amount int64, fromWalletID string) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	asset, err := readAsset(ctx, assetCode)
+	if err != nil {
+		return nil, err
+	}
+	// Only an issuer identity carrying role=admin may burn
+	if _, err := requireAssetIssuer(ctx, asset, "redemption"); err != nil {
+		return nil, err
+	}
+	idem, err := beginIdempotent(ctx, "Redeem", assetCode, amount, fromWalletID)
 	if err != nil {
-		return fmt.Errorf("failed to get MSP ID: %v", err)
+		return nil, err
 	}
-	if mspID != "CentralBankMSP" {
-		return fmt.Errorf("unauthorized: only Central Bank can redeem CBDC")
+	if idem.replay != nil {
+		return idem.replay, nil
 	}
 
 	walletBytes, err := ctx.GetStub().GetState(fromWalletID)
 	if err != nil {
-		return fmt.Errorf("failed to read wallet: %v", err)
+		return nil, fmt.Errorf("failed to read wallet: %v", err)
 	}
 	if walletBytes == nil {
-		return fmt.Errorf("wallet %s does not exist", fromWalletID)
+		return nil, fmt.Errorf("wallet %s does not exist", fromWalletID)
 	}
 
 	var wallet Wallet
 	err = json.Unmarshal(walletBytes, &wallet)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if wallet.Balance < amount {
-		return fmt.Errorf("insufficient funds to redeem")
+	if err := checkNotPaused(ctx, OperationRedeem, wallet.IntermediaryID); err != nil {
+		return nil, err
+	}
+	if wallet.spendableOf(assetCode) < amount {
+		return nil, fmt.Errorf("insufficient funds to redeem")
 	}
 
-	wallet.Balance -= amount
+	wallet.adjustBalance(assetCode, -amount)
 
 	updatedWalletBytes, _ := json.Marshal(wallet)
 	err = ctx.GetStub().PutState(fromWalletID, updatedWalletBytes)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if err := recordSupplyChange(ctx, assetCode, 0, amount); err != nil {
+		return nil, err
 	}
 
 	// Record Transaction
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
 	tx := Transaction{
 		ID:        ctx.GetStub().GetTxID(),
 		Type:      "Redeem",
 		From:      fromWalletID,
 		To:        "CentralBank", // Burning destination
 		Amount:    amount,
-		Timestamp: time.Now().Unix(),
+		Timestamp: now.Unix(),
+		Asset:     assetCode,
+	}
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, err
+	}
+
+	if err := idem.complete(ctx, &tx); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, events.NameRedeem, events.Redeem{WalletID: fromWalletID, Asset: assetCode, Amount: amount}); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Transfer moves funds between wallets as a P2P payment
+func (s *SmartContract) Transfer(ctx contractapi.TransactionContextInterface, fromWalletID string, toWalletID string, amount int64) (*Transaction, error) {
+	return s.transfer(ctx, assets.Default, fromWalletID, toWalletID, amount, fees.P2P)
+}
+
+// TransferWithType moves funds between wallets, charging the governance fee for the
+// payment type (P2P, P2B, B2P, B2B, G2P, P2G)
+func (s *SmartContract) TransferWithType(ctx contractapi.TransactionContextInterface, fromWalletID string, toWalletID string, amount int64, paymentType string) (*Transaction, error) {
+	return s.transfer(ctx, assets.Default, fromWalletID, toWalletID, amount, paymentType)
+}
+
+// TransferAsset moves an asset between wallets as a P2P payment. Tier limits, balance
+// ceilings and fees are denominated in NGN and only apply to NGN transfers.
+func (s *SmartContract) TransferAsset(ctx contractapi.TransactionContextInterface, assetCode string, fromWalletID string, toWalletID string, amount int64) (*Transaction, error) {
+	return s.transfer(ctx, assetCode, fromWalletID, toWalletID, amount, fees.P2P)
+}
+
+// SettleInterbank moves funds from one intermediary's settlement wallet to another's for an
+// RTGS settlement. The Central Bank operates RTGS, so a Central Bank admin submits it on
+// behalf of the debited bank; no other wallet can be debited this way.
+func (s *SmartContract) SettleInterbank(ctx contractapi.TransactionContextInterface, fromIntermediaryID string, toIntermediaryID string, amount int64) (*Transaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if _, err := requireCentralBankAdmin(ctx, "interbank settlement"); err != nil {
+		return nil, err
+	}
+
+	// Settlement wallets live at the well-known address of their intermediary
+	fromWalletID := addresses.ForIntermediary(fromIntermediaryID)
+	toWalletID := addresses.ForIntermediary(toIntermediaryID)
+	sender, err := readWallet(ctx, fromWalletID)
+	if err != nil {
+		return nil, err
+	}
+	idem, err := beginIdempotent(ctx, "SettleInterbank", fromWalletID, toWalletID, amount)
+	if err != nil {
+		return nil, err
+	}
+	if idem.replay != nil {
+		return idem.replay, nil
+	}
+
+	tx, quote, err := settleTransfer(ctx, assets.Default, sender, toWalletID, amount, fees.B2B, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := idem.complete(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	if err := emitEvent(ctx, events.NameTransfer, events.Transfer{
+		From:         fromWalletID,
+		To:           toWalletID,
+		Asset:        assets.Default,
+		Amount:       amount,
+		PaymentType:  fees.B2B,
+		Fee:          quote.Fee,
+		FeeCollector: quote.CollectorWalletID,
+	}); err != nil {
+		return nil, err
 	}
-	txBytes, _ := json.Marshal(tx)
-	return ctx.GetStub().PutState(tx.ID, txBytes)
+	return tx, nil
 }
 
-// Transfer moves funds between wallets
-func (s *SmartContract) Transfer(ctx contractapi.TransactionContextInterface, fromWalletID string, toWalletID string, amount int64) error {
+func (s *SmartContract) transfer(ctx contractapi.TransactionContextInterface, assetCode string, fromWalletID string, toWalletID string, amount int64, paymentType string) (*Transaction, error) {
 	if amount <= 0 {
-		return fmt.Errorf("amount must be positive")
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if _, err := readAsset(ctx, assetCode); err != nil {
+		return nil, err
 	}
 
 	// 1. Get Sender
 	senderBytes, err := ctx.GetStub().GetState(fromWalletID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if senderBytes == nil {
-		return fmt.Errorf("sender wallet %s not found", fromWalletID)
+		return nil, fmt.Errorf("sender wallet %s not found", fromWalletID)
 	}
 	var sender Wallet
 	json.Unmarshal(senderBytes, &sender)
 
-	if sender.Status == "Frozen" {
-		return fmt.Errorf("sender wallet is frozen")
+	// Only the sender's intermediary can move its funds
+	if err := requireWalletIntermediary(ctx, &sender); err != nil {
+		return nil, err
+	}
+	idem, err := beginIdempotent(ctx, "Transfer", assetCode, fromWalletID, toWalletID, amount, paymentType)
+	if err != nil {
+		return nil, err
 	}
-	if sender.Balance < amount {
-		return fmt.Errorf("insufficient funds")
+	if idem.replay != nil {
+		return idem.replay, nil
 	}
 
-	// Enforce Tier Limits (Phase 0/8 Requirement)
-	// Tier 0: $500 balance, $100 daily tx (simplified to 10,000 smallest units)
-	// Tier 1: $10,000 balance, $2,000 daily tx (100,000 smallest units)
-	// Tier 2: 1,000,000 limit
-	var limit int64
-	switch sender.Tier {
-	case "Tier0":
-		limit = 10000
-	case "Tier1":
-		limit = 100000
-	case "Tier2":
-		limit = 1000000
-	default:
-		limit = 0 // Unknown tier, block tx
+	// 2-5. Move the funds and record the transaction
+	tx, quote, err := settleTransfer(ctx, assetCode, &sender, toWalletID, amount, paymentType, "")
+	if err != nil {
+		return nil, err
 	}
+	if err := idem.complete(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// 6. Emit Event
+	if err := emitEvent(ctx, events.NameTransfer, events.Transfer{
+		From:         fromWalletID,
+		To:           toWalletID,
+		Asset:        assetCode,
+		Amount:       amount,
+		PaymentType:  paymentType,
+		Fee:          quote.Fee,
+		FeeCollector: quote.CollectorWalletID,
+	}); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
 
-	if amount > limit {
-		return fmt.Errorf("transaction amount %d exceeds limit %d for %s", amount, limit, sender.Tier)
+// settleTransfer moves amount from an authorized sender to toWalletID, charging the fee for
+// paymentType, and records the Transaction. The caller emits the event. reference links the
+// transfer to what it pays for, such as a payment request.
+func settleTransfer(ctx contractapi.TransactionContextInterface, assetCode string, sender *Wallet, toWalletID string, amount int64, paymentType string, reference string) (*Transaction, *FeeQuote, error) {
+	// The receiver is read separately from the sender, so a self-transfer would credit a
+	// stale copy of the wallet over its debit
+	if toWalletID == sender.ID {
+		return nil, nil, fmt.Errorf("cannot transfer to the sending wallet")
+	}
+	if err := sender.canSend(); err != nil {
+		return nil, nil, err
+	}
+	if err := authorizeDebit(ctx, sender, intents.Transfer{To: toWalletID, Asset: assetCode, Amount: amount}); err != nil {
+		return nil, nil, err
+	}
+
+	// The fee is charged to the sender on top of the amount. Fees and limits are
+	// denominated in NGN, so only NGN transfers read the params document.
+	var doc *params.Document
+	var err error
+	quote := &FeeQuote{PaymentType: paymentType, Amount: amount}
+	if assetCode == assets.Default {
+		if doc, err = readParams(ctx); err != nil {
+			return nil, nil, err
+		}
+		quote, err = quoteFee(doc, paymentType, amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		if quote.CollectorWalletID == sender.ID {
+			quote.Fee = 0
+		}
+	}
+	usage, err := checkDebit(ctx, doc, sender, assetCode, amount, quote.Fee)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// 2. Get Receiver
 	receiverBytes, err := ctx.GetStub().GetState(toWalletID)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	if receiverBytes == nil {
-		return fmt.Errorf("receiver wallet %s not found", toWalletID)
+		return nil, nil, fmt.Errorf("receiver wallet %s not found", toWalletID)
 	}
 	var receiver Wallet
 	json.Unmarshal(receiverBytes, &receiver)
 
-	if receiver.Status == "Frozen" {
-		return fmt.Errorf("receiver wallet is frozen")
+	if err := checkCredit(doc, &receiver, assetCode, amount); err != nil {
+		return nil, nil, err
+	}
+	if err := checkNotPaused(ctx, OperationTransfer, sender.IntermediaryID, receiver.IntermediaryID); err != nil {
+		return nil, nil, err
 	}
 
 	// 3. Update Balances
-	sender.Balance -= amount
-	receiver.Balance += amount
+	sender.adjustBalance(assetCode, -(amount + quote.Fee))
+	receiver.adjustBalance(assetCode, amount)
 
 	// 4. Save States
 	senderUpdated, _ := json.Marshal(sender)
 	receiverUpdated, _ := json.Marshal(receiver)
-	ctx.GetStub().PutState(fromWalletID, senderUpdated)
+	ctx.GetStub().PutState(sender.ID, senderUpdated)
 	ctx.GetStub().PutState(toWalletID, receiverUpdated)
+	if usage != nil {
+		if err := putWalletUsage(ctx, usage); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// 5. Save Transaction Record
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 	tx := Transaction{
-		ID:        ctx.GetStub().GetTxID(),
-		Type:      "Transfer",
-		From:      fromWalletID,
-		To:        toWalletID,
-		Amount:    amount,
-		Timestamp: time.Now().Unix(),
+		ID:          ctx.GetStub().GetTxID(),
+		Type:        "Transfer",
+		From:        sender.ID,
+		To:          toWalletID,
+		Amount:      amount,
+		Timestamp:   now.Unix(),
+		Asset:       assetCode,
+		PaymentType: paymentType,
+		Fee:         quote.Fee,
+		Reference:   reference,
+	}
+	if _, err := putTransaction(ctx, &tx); err != nil {
+		return nil, nil, err
+	}
+	if quote.Fee > 0 {
+		if err := collectFee(ctx, &tx, quote.CollectorWalletID, &receiver); err != nil {
+			return nil, nil, err
+		}
 	}
-	txBytes, _ := json.Marshal(tx)
-	ctx.GetStub().PutState(tx.ID, txBytes)
 
-	// 6. Emit Event
-	ctx.GetStub().SetEvent("TransferEvent", txBytes)
+	return &tx, quote, nil
+}
 
-	return nil
+// checkDebit verifies that sender can pay amount plus fee of an asset within its tier
+// limits in doc (Phase 0/8: single tx, daily amount and daily count) and returns the usage
+// record to persist once the debit is applied. The fee leaves the wallet too, so it counts
+// towards the limits. Limits are denominated in NGN, so other assets have no usage record
+// and need no doc.
+func checkDebit(ctx contractapi.TransactionContextInterface, doc *params.Document, sender *Wallet, assetCode string, amount int64, fee int64) (*WalletUsage, error) {
+	if sender.spendableOf(assetCode) < amount+fee {
+		return nil, fmt.Errorf("insufficient funds")
+	}
+	if assetCode != assets.Default {
+		return nil, nil
+	}
+	return checkSpendLimits(ctx, doc, sender, amount+fee)
+}
+
+// checkCredit verifies that receiver can take amount of an asset: its status must allow
+// credits and NGN credits must keep it within its tier's balance ceiling in doc
+func checkCredit(doc *params.Document, receiver *Wallet, assetCode string, amount int64) error {
+	if err := receiver.canReceive(); err != nil {
+		return err
+	}
+	if assetCode != assets.Default {
+		return nil
+	}
+	return checkBalanceCeiling(doc, receiver, amount)
 }
 
-// CreateWallet creates a new wallet (called by Intermediary)
-func (s *SmartContract) CreateWallet(ctx contractapi.TransactionContextInterface, id string, ownerID string, intermediaryID string, tier string) error {
+// CreateWallet creates a new wallet (called by Intermediary).
+// Intermediaries can only open wallets under their own intermediary ID, and the wallet ID
+// must be a pseudonymous address (see the addresses package) derived from ownerKey, which
+// signs the wallet's debits. The owner and KYC
+// attributes are passed in the transient map under "wallet_owner" and stored in
+// pdc-retail-wallets; world state keeps only balances and the intermediary, which access
+// checks need.
+func (s *SmartContract) CreateWallet(ctx contractapi.TransactionContextInterface, id string, intermediaryID string, tier string, ownerKey string) error {
+	c, err := getCaller(ctx)
+	if err != nil {
+		return err
+	}
+	if intermediaryID != c.IntermediaryID {
+		return fmt.Errorf("unauthorized: %s cannot create wallets for intermediary %s", c.IntermediaryID, intermediaryID)
+	}
+	if err := addresses.Validate(id); err != nil {
+		return err
+	}
+	if err := checkOwnerKey(id, intermediaryID, ownerKey); err != nil {
+		return err
+	}
+	if _, err := getTierLimits(ctx, tier); err != nil {
+		return err
+	}
+
 	exists, err := ctx.GetStub().GetState(id)
 	if err != nil {
 		return err
@@ -239,322 +479,218 @@ func (s *SmartContract) CreateWallet(ctx contractapi.TransactionContextInterface
 
 	wallet := Wallet{
 		ID:             id,
-		OwnerID:        ownerID,
 		IntermediaryID: intermediaryID,
+		OwnerKey:       ownerKey,
 		Tier:           tier,
-		Status:         "Active",
+		Status:         WalletActive,
 		Balance:        0,
 	}
 
 	walletBytes, _ := json.Marshal(wallet)
-	return ctx.GetStub().PutState(id, walletBytes)
-}
-
-// GetWallet returns the wallet state
-func (s *SmartContract) GetWallet(ctx contractapi.TransactionContextInterface, id string) (*Wallet, error) {
-	walletBytes, err := ctx.GetStub().GetState(id)
-	if err != nil {
-		return nil, err
+	if err := ctx.GetStub().PutState(id, walletBytes); err != nil {
+		return err
 	}
-	if walletBytes == nil {
-		return nil, fmt.Errorf("wallet %s does not exist", id)
+	if err := putWalletOwner(ctx, id); err != nil {
+		return err
+	}
+	if err := indexIntermediaryWallet(ctx, &wallet); err != nil {
+		return err
 	}
 
-	var wallet Wallet
-	err = json.Unmarshal(walletBytes, &wallet)
-	return &wallet, nil
+	return emitEvent(ctx, events.NameWalletCreated, events.WalletCreated{
+		WalletID:       id,
+		IntermediaryID: intermediaryID,
+		Tier:           tier,
+	})
 }
 
-// GetTransaction returns the transaction details
-func (s *SmartContract) GetTransaction(ctx contractapi.TransactionContextInterface, id string) (*Transaction, error) {
-	// Note: In Fabric, transactions are stored in blocks, but we can query the world state if we saved the Tx object there.
-	// In our Transfer function, we didn't explicitly save the Tx object to the world state, we only emitted an event.
-	// To support this query, we should modify Transfer to save the Tx object or rely on an off-chain indexer.
-	// For this build phase, let's implement saving the Tx to world state in Transfer.
-
-	txBytes, err := ctx.GetStub().GetState(id)
+// UpdateWalletTier moves a wallet to a new KYC tier (called by the owning Intermediary)
+// and appends the change, with its reason code, to the wallet's tier history.
+// The current balance must fit under the new tier's ceiling, so a downgrade may require
+// the customer to move funds out first.
+func (s *SmartContract) UpdateWalletTier(ctx contractapi.TransactionContextInterface, walletID string, tier string, reasonCode string) (*Wallet, error) {
+	if !slices.Contains(statusReasonCodes, reasonCode) {
+		return nil, fmt.Errorf("unknown reason code %q", reasonCode)
+	}
+	wallet, err := readWallet(ctx, walletID)
 	if err != nil {
 		return nil, err
 	}
-	if txBytes == nil {
-		return nil, fmt.Errorf("transaction %s does not exist", id)
+	if err := requireWalletIntermediary(ctx, wallet); err != nil {
+		return nil, err
 	}
-
-	var tx Transaction
-	err = json.Unmarshal(txBytes, &tx)
-	return &tx, nil
-}
-
-// OfflinePurse represents a secure element on a device (Private Data)
-type OfflinePurse struct {
-	DeviceID string `json:"device_id"`
-	Counter  int64  `json:"counter"`
-	Limit    int64  `json:"limit"`
-}
-
-// OfflineProof represents the cryptographic proof of an offline transaction
-type OfflineProof struct {
-	FromWalletID string `json:"from"`
-	ToWalletID   string `json:"to"`
-	Amount       int64  `json:"amount"`
-	Nonce        int64  `json:"nonce"`
-	Signature    string `json:"signature"`
-}
-
-// ReconcileOffline processes an offline transaction proof
-func (s *SmartContract) ReconcileOffline(ctx contractapi.TransactionContextInterface, proofJSON string) error {
-	// 1. Parse Proof (Simplified for prototype)
-	// In production, this would verify Ed25519 signatures and check against the OfflinePurse state
-	// stored in a Private Data Collection.
-
-	// For this build, we will simulate the reconciliation by just logging it and updating the wallet.
-	// We assume the 'proofJSON' contains { "from": "...", "to": "...", "amount": 10, "signature": "..." }
-
-	// We assume the 'proofJSON' contains the OfflineProof structure
-
-	var proof OfflineProof
-	if err := json.Unmarshal([]byte(proofJSON), &proof); err != nil {
-		return err
+	if wallet.Status == WalletClosed {
+		return nil, fmt.Errorf("wallet %s is closed", walletID)
+	}
+	if wallet.Tier == tier {
+		return nil, fmt.Errorf("wallet %s is already %s", walletID, tier)
 	}
 
-	// 2. Update Balances (Reuse Transfer logic or call it directly)
-	// Note: Offline transactions usually mean funds were ALREADY deducted from the 'OfflinePurse'
-	// and now need to be deducted from the on-chain 'Shadow Account' or just settled.
-	// Here we treat it as a deferred transfer.
-
-	// We call Transfer internally, which records a "Transfer" type transaction.
-	// To strictly follow the doc which says Type="OfflineReconcile", we should implement custom logic here
-	// or modify Transfer to accept a type. For simplicity and code reuse, we'll let Transfer handle it
-	// but we'll emit a separate event or just accept "Transfer" as the underlying mechanic.
-	// However, to be 100% compliant with the "OfflineReconcile" enum requirement, let's manually do it.
-
-	// ... Actually, reusing Transfer is safer for balance logic.
-	// Let's modify Transfer to be internal or just record a secondary "OfflineReconcile" record?
-	// No, that duplicates.
-	// Let's just call Transfer. The "Type" in the doc is likely the *intent*.
-	// If I must have "OfflineReconcile" as the Type in the DB, I should copy the Transfer logic here.
-
-	// COPYING TRANSFER LOGIC FOR ACCURACY (Simplified for brevity)
-	// 1. Get Sender
-	senderBytes, err := ctx.GetStub().GetState(proof.FromWalletID)
+	limits, err := getTierLimits(ctx, tier)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if senderBytes == nil {
-		return fmt.Errorf("sender wallet not found")
+	if limits.MaxBalance > 0 && wallet.Balance > limits.MaxBalance {
+		return nil, fmt.Errorf("wallet %s balance %d exceeds the %s ceiling %d", walletID, wallet.Balance, tier, limits.MaxBalance)
 	}
-	var sender Wallet
-	json.Unmarshal(senderBytes, &sender)
 
-	// 2. Get Receiver
-	receiverBytes, err := ctx.GetStub().GetState(proof.ToWalletID)
+	c, err := getCaller(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if receiverBytes == nil {
-		return fmt.Errorf("receiver wallet not found")
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
 	}
-	var receiver Wallet
-	json.Unmarshal(receiverBytes, &receiver)
-
-	// 3. Update
-	if sender.Balance < proof.Amount {
-		return fmt.Errorf("insufficient funds")
+	change := WalletTierChange{
+		WalletID:   walletID,
+		From:       wallet.Tier,
+		To:         tier,
+		ReasonCode: reasonCode,
+		Actor:      c.ID,
+		ActorMSP:   c.MSPID,
+		TxID:       ctx.GetStub().GetTxID(),
+		Timestamp:  now.Unix(),
 	}
-	sender.Balance -= proof.Amount
-	receiver.Balance += proof.Amount
 
-	senderUpdated, _ := json.Marshal(sender)
-	receiverUpdated, _ := json.Marshal(receiver)
-	ctx.GetStub().PutState(proof.FromWalletID, senderUpdated)
-	ctx.GetStub().PutState(proof.ToWalletID, receiverUpdated)
+	wallet.Tier = tier
+	if err := putWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+	if err := putWalletTierChange(ctx, &change); err != nil {
+		return nil, err
+	}
 
-	// 4. Record Transaction
-	tx := Transaction{
-		ID:        ctx.GetStub().GetTxID(),
-		Type:      "OfflineReconcile",
-		From:      proof.FromWalletID,
-		To:        proof.ToWalletID,
-		Amount:    proof.Amount,
-		Timestamp: time.Now().Unix(),
-		Signature: []byte(proof.Signature),
-	}
-	txBytes, _ := json.Marshal(tx)
-	return ctx.GetStub().PutState(tx.ID, txBytes)
+	if err := emitEvent(ctx, events.NameWalletTier, events.WalletTier{
+		WalletID:   walletID,
+		From:       change.From,
+		To:         change.To,
+		ReasonCode: change.ReasonCode,
+		Actor:      change.Actor,
+		ActorMSP:   change.ActorMSP,
+	}); err != nil {
+		return nil, err
+	}
+	return wallet, nil
 }
 
-// FreezeWallet blocks a wallet from transacting
-func (s *SmartContract) FreezeWallet(ctx contractapi.TransactionContextInterface, walletID string) error {
-	// Check permissions (Central Bank or Regulator)
-	mspID, err := ctx.GetClientIdentity().GetMSPID()
+// GetWallet returns the wallet state
+func (s *SmartContract) GetWallet(ctx contractapi.TransactionContextInterface, id string) (*Wallet, error) {
+	walletBytes, err := ctx.GetStub().GetState(id)
 	if err != nil {
-		return fmt.Errorf("failed to get MSP ID: %v", err)
+		return nil, err
 	}
-	// Simplified policy: CentralBankMSP OR RegulatorMSP (as per Phase 4 Design)
-	if mspID != "CentralBankMSP" && mspID != "RegulatorMSP" {
-		return fmt.Errorf("unauthorized: only Central Bank or Regulator can freeze wallets")
+	if walletBytes == nil {
+		return nil, fmt.Errorf("wallet %s does not exist", id)
+	}
+
+	var wallet Wallet
+	err = json.Unmarshal(walletBytes, &wallet)
+	if err := requireWalletReader(ctx, &wallet); err != nil {
+		return nil, err
 	}
+	return &wallet, nil
+}
 
-	walletBytes, err := ctx.GetStub().GetState(walletID)
+// readWallet loads a wallet from world state
+func readWallet(ctx contractapi.TransactionContextInterface, id string) (*Wallet, error) {
+	walletBytes, err := ctx.GetStub().GetState(id)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to read wallet: %v", err)
 	}
 	if walletBytes == nil {
-		return fmt.Errorf("wallet %s does not exist", walletID)
+		return nil, fmt.Errorf("wallet %s does not exist", id)
 	}
 
 	var wallet Wallet
-	err = json.Unmarshal(walletBytes, &wallet)
-	if err != nil {
-		return err
+	if err := json.Unmarshal(walletBytes, &wallet); err != nil {
+		return nil, err
 	}
+	return &wallet, nil
+}
 
-	wallet.Status = "Frozen"
-	updatedWalletBytes, _ := json.Marshal(wallet)
-	return ctx.GetStub().PutState(walletID, updatedWalletBytes)
+// putWallet saves a wallet to world state
+func putWallet(ctx contractapi.TransactionContextInterface, wallet *Wallet) error {
+	walletBytes, _ := json.Marshal(wallet)
+	return ctx.GetStub().PutState(wallet.ID, walletBytes)
 }
 
-// UnfreezeWallet unblocks a wallet
-func (s *SmartContract) UnfreezeWallet(ctx contractapi.TransactionContextInterface, walletID string) error {
-	// Check permissions
-	mspID, err := ctx.GetClientIdentity().GetMSPID()
-	if err != nil {
-		return fmt.Errorf("failed to get MSP ID: %v", err)
-	}
-	if mspID != "CentralBankMSP" {
-		return fmt.Errorf("unauthorized: only Central Bank can unfreeze wallets")
-	}
+// GetTransaction returns the transaction details
+func (s *SmartContract) GetTransaction(ctx contractapi.TransactionContextInterface, id string) (*Transaction, error) {
+	// Note: In Fabric, transactions are stored in blocks, but we can query the world state if we saved the Tx object there.
+	// In our Transfer function, we didn't explicitly save the Tx object to the world state, we only emitted an event.
+	// To support this query, we should modify Transfer to save the Tx object or rely on an off-chain indexer.
+	// For this build phase, let's implement saving the Tx to world state in Transfer.
 
-	walletBytes, err := ctx.GetStub().GetState(walletID)
+	tx, err := readTransaction(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if walletBytes == nil {
-		return fmt.Errorf("wallet %s does not exist", walletID)
+	if err := requireTransactionReader(ctx, tx); err != nil {
+		return nil, err
 	}
+	return tx, nil
+}
 
-	var wallet Wallet
-	err = json.Unmarshal(walletBytes, &wallet)
+// requireTransactionReader allows the Central Bank, the Regulator and the
+// intermediaries of either party to read a transaction
+func requireTransactionReader(ctx contractapi.TransactionContextInterface, tx *Transaction) error {
+	c, err := getCaller(ctx)
 	if err != nil {
 		return err
 	}
-
-	wallet.Status = "Active"
-	updatedWalletBytes, _ := json.Marshal(wallet)
-	return ctx.GetStub().PutState(walletID, updatedWalletBytes)
-}
-
-// BatchReconcile processes a batch of offline transaction proofs
-// This is called by the offline-service to settle multiple offline transactions at once
-func (s *SmartContract) BatchReconcile(ctx contractapi.TransactionContextInterface, proofsJSON string) error {
-	var proofs []OfflineProof
-	if err := json.Unmarshal([]byte(proofsJSON), &proofs); err != nil {
-		return fmt.Errorf("failed to parse proofs: %v", err)
+	if c.isSupervisor() {
+		return nil
 	}
-
-	if len(proofs) == 0 {
-		return fmt.Errorf("empty batch")
+	for _, party := range []string{tx.From, tx.To} {
+		// Parties such as CentralBank or an escrow ID are not wallets
+		if wallet, err := readWallet(ctx, party); err == nil && c.manages(wallet) {
+			return nil
+		}
 	}
+	return fmt.Errorf("unauthorized: transaction %s is not visible to %s", tx.ID, c.IntermediaryID)
+}
 
-	// Process each proof in the batch
-	successCount := 0
-	for i, proof := range proofs {
-		err := s.processOfflineProof(ctx, proof, i)
-		if err != nil {
-			// Log error but continue processing other proofs
-			// In production, might want to collect all errors
-			continue
-		}
-		successCount++
+// FreezeWallet blocks a wallet from sending or receiving funds
+func (s *SmartContract) FreezeWallet(ctx contractapi.TransactionContextInterface, walletID string, reasonCode string) error {
+	// Check permissions (Central Bank or Regulator, as per Phase 4 Design)
+	if _, err := requireMSP(ctx, "freezing wallets", MSPCentralBank, MSPRegulator); err != nil {
+		return err
 	}
 
-	// Emit batch event
-	batchResult := map[string]interface{}{
-		"batch_size":    len(proofs),
-		"success_count": successCount,
-		"timestamp":     time.Now().Unix(),
+	wallet, err := readWallet(ctx, walletID)
+	if err != nil {
+		return err
 	}
-	eventBytes, _ := json.Marshal(batchResult)
-	ctx.GetStub().SetEvent("BatchReconcileEvent", eventBytes)
 
-	return nil
+	_, err = setWalletStatus(ctx, wallet, []string{WalletActive, WalletSuspended, WalletDormant}, WalletFrozen, reasonCode)
+	return err
 }
 
-// processOfflineProof handles a single offline proof within a batch
-func (s *SmartContract) processOfflineProof(ctx contractapi.TransactionContextInterface, proof OfflineProof, index int) error {
-	// 1. Get Sender
-	senderBytes, err := ctx.GetStub().GetState(proof.FromWalletID)
-	if err != nil {
+// UnfreezeWallet unblocks a wallet
+func (s *SmartContract) UnfreezeWallet(ctx contractapi.TransactionContextInterface, walletID string, reasonCode string) error {
+	// Check permissions
+	if _, err := requireMSP(ctx, "unfreezing wallets", MSPCentralBank); err != nil {
 		return err
 	}
-	if senderBytes == nil {
-		return fmt.Errorf("sender wallet not found: %s", proof.FromWalletID)
-	}
-	var sender Wallet
-	json.Unmarshal(senderBytes, &sender)
 
-	// 2. Get Receiver
-	receiverBytes, err := ctx.GetStub().GetState(proof.ToWalletID)
+	wallet, err := readWallet(ctx, walletID)
 	if err != nil {
 		return err
 	}
-	if receiverBytes == nil {
-		return fmt.Errorf("receiver wallet not found: %s", proof.ToWalletID)
-	}
-	var receiver Wallet
-	json.Unmarshal(receiverBytes, &receiver)
-
-	// 3. Validate and Update
-	if sender.Balance < proof.Amount {
-		return fmt.Errorf("insufficient funds for proof %d", index)
-	}
 
-	sender.Balance -= proof.Amount
-	receiver.Balance += proof.Amount
-
-	senderUpdated, _ := json.Marshal(sender)
-	receiverUpdated, _ := json.Marshal(receiver)
-	ctx.GetStub().PutState(proof.FromWalletID, senderUpdated)
-	ctx.GetStub().PutState(proof.ToWalletID, receiverUpdated)
-
-	// 4. Record Transaction with unique ID for batch
-	txID := fmt.Sprintf("%s-batch-%d", ctx.GetStub().GetTxID(), index)
-	tx := Transaction{
-		ID:        txID,
-		Type:      "OfflineReconcile",
-		From:      proof.FromWalletID,
-		To:        proof.ToWalletID,
-		Amount:    proof.Amount,
-		Timestamp: time.Now().Unix(),
-		Signature: []byte(proof.Signature),
-	}
-	txBytes, _ := json.Marshal(tx)
-	return ctx.GetStub().PutState(txID, txBytes)
+	_, err = setWalletStatus(ctx, wallet, []string{WalletFrozen}, WalletActive, reasonCode)
+	return err
 }
 
-// GetTotalSupply returns the total CBDC in circulation (sum of all wallet balances)
+// GetTotalSupply returns the total CBDC in circulation (issued minus redeemed)
 func (s *SmartContract) GetTotalSupply(ctx contractapi.TransactionContextInterface) (int64, error) {
-	// In production, maintain a running total. For now, iterate through wallets.
-	// This is expensive and should be cached.
-	resultsIterator, err := ctx.GetStub().GetStateByRange("wallet-", "wallet-~")
-	if err != nil {
+	if _, err := requireMSP(ctx, "reading supply", MSPCentralBank, MSPRegulator); err != nil {
 		return 0, err
 	}
-	defer resultsIterator.Close()
-
-	var total int64
-	for resultsIterator.HasNext() {
-		result, err := resultsIterator.Next()
-		if err != nil {
-			return 0, err
-		}
-
-		var wallet Wallet
-		if err := json.Unmarshal(result.Value, &wallet); err != nil {
-			continue
-		}
-		total += wallet.Balance
+	supply, err := readSupply(ctx, assets.Default)
+	if err != nil {
+		return 0, err
 	}
-
-	return total, nil
+	return supply.Outstanding, nil
 }
@@ -0,0 +1,33 @@
+package chaincode
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
+
+func TestConformancePayments(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	l.fund(p, "alice", "Tier1", 50000)
+	l.fund(p, "bob", "Tier1", 0)
+	l.fund(p, "carol", "Tier1", 0)
+
+	l.submit(p.bank, l.pay("alice", "bob", 1000), false, "Transfer", addr("alice"), addr("bob"), "1000")
+	l.submit(p.otherBank, l.pay("alice", "bob", 1000), true, "Transfer", addr("alice"), addr("bob"), "1000")
+	l.submit(p.bank, l.pay("alice", "alice", 1000), true, "Transfer", addr("alice"), addr("alice"), "1000")
+	legs := fmt.Sprintf(`[{"to_wallet_id":%q,"amount":200},{"to_wallet_id":%q,"amount":300},{"to_wallet_id":%q,"amount":100}]`, addr("bob"), addr("carol"), addr("bob"))
+	l.submit(p.bank, l.signed("alice", intents.Transfer{Legs: intents.LegsHash(legs), Amount: 600}), false, "BatchTransfer", addr("alice"), legs)
+	l.submit(p.centralBank, nil, false, "Redeem", "500", addr("alice"))
+	l.submit(p.regulator, nil, true, "Issue", "500", addr("alice"))
+
+	if got := l.wallet(addr("alice")).Balance; got != 50000-1000-600-500 {
+		t.Errorf("alice balance = %d", got)
+	}
+	if got := l.wallet(addr("bob")).Balance; got != 1300 {
+		t.Errorf("bob balance = %d", got)
+	}
+}
@@ -0,0 +1,201 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// supplyKey returns the key of an asset's supply record. NGN keeps the original SupplyKey.
+func supplyKey(ctx contractapi.TransactionContextInterface, asset string) (string, error) {
+	if asset == assets.Default {
+		return SupplyKey, nil
+	}
+	return ctx.GetStub().CreateCompositeKey(DocTypeAssetSupply, []string{asset})
+}
+
+// readSupply loads an asset's running supply record, returning a zero record before the first issuance
+func readSupply(ctx contractapi.TransactionContextInterface, asset string) (*SupplyRecord, error) {
+	key, err := supplyKey(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	supplyBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read supply: %v", err)
+	}
+
+	var supply SupplyRecord
+	if supplyBytes != nil {
+		if err := json.Unmarshal(supplyBytes, &supply); err != nil {
+			return nil, err
+		}
+	}
+	return &supply, nil
+}
+
+// supplyPeriodKey returns the key of a day's supply period. Periods use simple keys rather
+// than composite ones because range queries over composite keys are not allowed.
+func supplyPeriodKey(day string) string {
+	return DocTypeSupplyPeriod + "~" + day
+}
+
+// recordSupplyChange updates the asset's running total and, for NGN, the day's mint/burn bucket.
+// It is called from IssueAsset and RedeemAsset so both records commit atomically with the balance change.
+func recordSupplyChange(ctx contractapi.TransactionContextInterface, asset string, issued int64, redeemed int64) error {
+	supply, err := readSupply(ctx, asset)
+	if err != nil {
+		return err
+	}
+
+	supply.TotalIssued += issued
+	supply.TotalRedeemed += redeemed
+	supply.Outstanding = supply.TotalIssued - supply.TotalRedeemed
+	if supply.Outstanding < 0 {
+		return fmt.Errorf("redemption would make outstanding supply negative")
+	}
+
+	key, err := supplyKey(ctx, asset)
+	if err != nil {
+		return err
+	}
+	supplyBytes, _ := json.Marshal(supply)
+	if err := ctx.GetStub().PutState(key, supplyBytes); err != nil {
+		return err
+	}
+
+	// Per-period ledger, kept for the retail asset only
+	if asset != assets.Default {
+		return nil
+	}
+	day, err := txDay(ctx)
+	if err != nil {
+		return err
+	}
+	periodKey := supplyPeriodKey(day)
+	periodBytes, err := ctx.GetStub().GetState(periodKey)
+	if err != nil {
+		return err
+	}
+
+	period := SupplyPeriod{Period: day}
+	if periodBytes != nil {
+		if err := json.Unmarshal(periodBytes, &period); err != nil {
+			return err
+		}
+	}
+
+	period.Issued += issued
+	period.Redeemed += redeemed
+	if issued > 0 {
+		period.MintCount++
+	}
+	if redeemed > 0 {
+		period.BurnCount++
+	}
+	period.Outstanding = supply.Outstanding
+
+	periodBytes, _ = json.Marshal(period)
+	return ctx.GetStub().PutState(periodKey, periodBytes)
+}
+
+// GetSupply returns the running totals of issued, redeemed and outstanding NGN
+func (s *SmartContract) GetSupply(ctx contractapi.TransactionContextInterface) (*SupplyRecord, error) {
+	if _, err := requireMSP(ctx, "reading supply", MSPCentralBank, MSPRegulator); err != nil {
+		return nil, err
+	}
+	return readSupply(ctx, assets.Default)
+}
+
+// GetSupplyHistory returns per-day mint/burn totals between two YYYY-MM-DD dates (inclusive).
+// Empty bounds are open-ended; results come back in date order since periods are keyed by day.
+func (s *SmartContract) GetSupplyHistory(ctx contractapi.TransactionContextInterface, fromDay string, toDay string) ([]*SupplyPeriod, error) {
+	if _, err := requireMSP(ctx, "reading supply", MSPCentralBank, MSPRegulator); err != nil {
+		return nil, err
+	}
+	// Only the requested days are read: "~" sorts after every character of a day
+	startKey, endKey := supplyPeriodKey(fromDay), supplyPeriodKey("~")
+	if toDay != "" {
+		endKey = supplyPeriodKey(toDay + "~")
+	}
+	resultsIterator, err := ctx.GetStub().GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	periods := []*SupplyPeriod{}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var period SupplyPeriod
+		if err := json.Unmarshal(result.Value, &period); err != nil {
+			return nil, err
+		}
+		periods = append(periods, &period)
+	}
+
+	return periods, nil
+}
+
+// MigrateSupply seeds the NGN supply counter of a ledger upgraded from before the counter
+// existed. The funds already in circulation are the wallet balances the counter does not
+// account for; they are added as issued so they can be redeemed. A Central Bank admin runs
+// it once, after the upgrade; the day's supply period is not changed.
+func (s *SmartContract) MigrateSupply(ctx contractapi.TransactionContextInterface) (*SupplyRecord, error) {
+	if _, err := requireCentralBankAdmin(ctx, "migrating supply"); err != nil {
+		return nil, err
+	}
+	supply, err := readSupply(ctx, assets.Default)
+	if err != nil {
+		return nil, err
+	}
+	if supply.Seeded != 0 {
+		return nil, fmt.Errorf("supply was already migrated")
+	}
+
+	// Wallets are the simple keys whose record names the key itself and an intermediary
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var balances int64
+	wallets := 0
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var wallet Wallet
+		if err := json.Unmarshal(result.Value, &wallet); err != nil || wallet.ID != result.Key || wallet.IntermediaryID == "" {
+			continue
+		}
+		balances += wallet.Balance
+		wallets++
+	}
+
+	seeded := balances - supply.Outstanding
+	if seeded <= 0 {
+		return nil, fmt.Errorf("no unrecorded supply to migrate: balances %d, outstanding %d", balances, supply.Outstanding)
+	}
+	supply.Seeded = seeded
+	supply.TotalIssued += seeded
+	supply.Outstanding = supply.TotalIssued - supply.TotalRedeemed
+
+	supplyBytes, _ := json.Marshal(supply)
+	if err := ctx.GetStub().PutState(SupplyKey, supplyBytes); err != nil {
+		return nil, err
+	}
+	if err := emitEvent(ctx, events.NameSupplySeed, events.SupplySeed{Seeded: seeded, Outstanding: supply.Outstanding, Wallets: wallets}); err != nil {
+		return nil, err
+	}
+	return supply, nil
+}
@@ -0,0 +1,115 @@
+package chaincode
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/events"
+)
+
+func TestConformanceSupply(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	// 1 March: two mints and a burn
+	l.fund(p, "ivy", "Tier2", 5000)
+	l.submit(p.centralBank, nil, false, "Issue", "3000", addr("ivy"))
+	l.submit(p.centralBank, nil, false, "Redeem", "1000", addr("ivy"))
+	// 2 March: a burn only
+	l.clock = l.clock.Add(24 * time.Hour)
+	l.submit(p.centralBank, nil, false, "Redeem", "2000", addr("ivy"))
+	// 3 March: a mint, then a burn that would take the supply below zero
+	l.clock = l.clock.Add(24 * time.Hour)
+	l.submit(p.centralBank, nil, false, "Issue", "500", addr("ivy"))
+	l.submit(p.centralBank, nil, true, "Redeem", "6000", addr("ivy"))
+
+	var supply SupplyRecord
+	json.Unmarshal(l.endorse(p.centralBank, "query", nil, "GetSupply", nil).payload, &supply)
+	if supply != (SupplyRecord{TotalIssued: 8500, TotalRedeemed: 3000, Outstanding: 5500}) {
+		t.Errorf("supply = %+v", supply)
+	}
+	var total int64
+	json.Unmarshal(l.endorse(p.regulator, "query", nil, "GetTotalSupply", nil).payload, &total)
+	if total != supply.Outstanding {
+		t.Errorf("total supply = %d", total)
+	}
+
+	history := func(from, to string) []SupplyPeriod {
+		var periods []SupplyPeriod
+		json.Unmarshal(l.endorse(p.centralBank, "query", nil, "GetSupplyHistory", []string{from, to}).payload, &periods)
+		return periods
+	}
+	all := history("", "")
+	want := []SupplyPeriod{
+		{Period: "2024-03-01", Issued: 8000, Redeemed: 1000, MintCount: 2, BurnCount: 1, Outstanding: 7000},
+		{Period: "2024-03-02", Redeemed: 2000, BurnCount: 1, Outstanding: 5000},
+		{Period: "2024-03-03", Issued: 500, MintCount: 1, Outstanding: 5500},
+	}
+	if len(all) != len(want) {
+		t.Fatalf("history has %d days, want %d", len(all), len(want))
+	}
+	for i := range want {
+		if all[i] != want[i] {
+			t.Errorf("day %d = %+v, want %+v", i, all[i], want[i])
+		}
+	}
+
+	// Bounds are inclusive and either may be left open
+	for _, r := range []struct {
+		from, to string
+		days     []string
+	}{
+		{"2024-03-02", "2024-03-02", []string{"2024-03-02"}},
+		{"2024-03-02", "", []string{"2024-03-02", "2024-03-03"}},
+		{"", "2024-03-01", []string{"2024-03-01"}},
+		{"2024-03-04", "", nil},
+	} {
+		var days []string
+		for _, period := range history(r.from, r.to) {
+			days = append(days, period.Period)
+		}
+		if !slices.Equal(days, r.days) {
+			t.Errorf("history %q to %q = %v, want %v", r.from, r.to, days, r.days)
+		}
+	}
+}
+
+func TestConformanceSupplyMigration(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	// A ledger upgraded from before the counter: balances exist but no supply is recorded
+	l.fund(p, "uma", "Tier1", 4000)
+	l.fund(p, "vic", "Tier1", 1000)
+	l.stub.MockTransactionStart("upgrade")
+	l.stub.DelState(SupplyKey)
+	l.stub.DelState(supplyPeriodKey("2024-03-01"))
+	l.stub.MockTransactionEnd("upgrade")
+
+	l.submit(p.centralBank, nil, true, "Redeem", "500", addr("uma"))
+	l.submit(p.centralBank, nil, false, "Issue", "300", addr("vic"))
+	for _, other := range []identity{p.regulator, p.bank} {
+		l.submit(other, nil, true, "MigrateSupply")
+	}
+	var supply SupplyRecord
+	json.Unmarshal(l.submit(p.centralBank, nil, false, "MigrateSupply"), &supply)
+	if supply != (SupplyRecord{TotalIssued: 5300, Outstanding: 5300, Seeded: 5000}) {
+		t.Errorf("migrated supply = %+v", supply)
+	}
+	var seed events.SupplySeed
+	if err := l.lastEvent.Decode(&seed); err != nil || seed != (events.SupplySeed{Seeded: 5000, Outstanding: 5300, Wallets: 2}) {
+		t.Errorf("SupplySeed = %+v", seed)
+	}
+	l.submit(p.centralBank, nil, true, "MigrateSupply")
+
+	// The balances from before the counter can now be redeemed
+	l.submit(p.centralBank, nil, false, "Redeem", "4000", addr("uma"))
+	json.Unmarshal(l.endorse(p.regulator, "query", nil, "GetSupply", nil).payload, &supply)
+	if supply.Outstanding != 1300 || supply.TotalRedeemed != 4000 {
+		t.Errorf("supply after redemption = %+v", supply)
+	}
+}
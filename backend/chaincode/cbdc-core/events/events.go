@@ -0,0 +1,254 @@
+// Package events defines the chaincode events emitted by cbdc-core. Every mutating
+// function emits exactly one event, named after its payload type, whose body is an
+// Envelope carrying the schema version. Services decode events with this package
+// instead of depending on chaincode internals.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SchemaVersion is bumped whenever a payload changes incompatibly
+const SchemaVersion = 2
+
+// Chaincode event names
+const (
+	NameWalletCreated  = "WalletCreatedEvent"
+	NameWalletStatus   = "WalletStatusEvent"
+	NameWalletTier     = "WalletTierEvent"
+	NameMint           = "MintEvent"
+	NameRedeem         = "RedeemEvent"
+	NameTransfer       = "TransferEvent"
+	NameBatchTransfer  = "BatchTransferEvent"
+	NameOfflineDevice  = "OfflineDeviceEvent"
+	NameOfflineSettled = "OfflineReconcileEvent"
+	NameBatchReconcile = "BatchReconcileEvent"
+	NameEscrow         = "EscrowEvent"
+	NameHold           = "HoldEvent"
+	NameAsset          = "AssetEvent"
+	NamePause          = "PauseEvent"
+	NameMerchant       = "MerchantEvent"
+	NamePaymentRequest = "PaymentRequestEvent"
+	NameRefund         = "RefundEvent"
+	NamePosition       = "PositionEvent"
+	NameSupplySeed     = "SupplySeedEvent"
+)
+
+// Envelope is the body of every cbdc-core chaincode event
+type Envelope struct {
+	Name      string          `json:"name"`
+	Version   int             `json:"version"`
+	TxID      string          `json:"tx_id"`
+	Timestamp int64           `json:"timestamp"` // Transaction proposal time, Unix seconds
+	Data      json.RawMessage `json:"data"`
+}
+
+// Marshal wraps data in a versioned envelope
+func Marshal(name string, txID string, timestamp int64, data any) ([]byte, error) {
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{Name: name, Version: SchemaVersion, TxID: txID, Timestamp: timestamp, Data: dataBytes})
+}
+
+// Unmarshal parses an event body, rejecting versions newer than this package understands
+func Unmarshal(payload []byte) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil, err
+	}
+	if env.Version > SchemaVersion {
+		return nil, fmt.Errorf("unsupported %s version %d", env.Name, env.Version)
+	}
+	return &env, nil
+}
+
+// Decode unmarshals the envelope data into one of the payload types below
+func (e *Envelope) Decode(v any) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// WalletCreated is emitted by CreateWallet. Since version 2 it no longer carries the
+// owner, which is kept in private data.
+type WalletCreated struct {
+	WalletID       string `json:"wallet_id"`
+	IntermediaryID string `json:"intermediary_id"`
+	Tier           string `json:"tier"`
+}
+
+// WalletStatus is emitted on every lifecycle transition, including freezes
+type WalletStatus struct {
+	WalletID   string `json:"wallet_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	ReasonCode string `json:"reason_code"`
+	Actor      string `json:"actor"`
+	ActorMSP   string `json:"actor_msp"`
+}
+
+// WalletTier is emitted by UpdateWalletTier
+type WalletTier struct {
+	WalletID   string `json:"wallet_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	ReasonCode string `json:"reason_code"`
+	Actor      string `json:"actor"`
+	ActorMSP   string `json:"actor_msp"`
+}
+
+// Mint is emitted by Issue and IssueAsset
+type Mint struct {
+	WalletID string `json:"wallet_id"`
+	Asset    string `json:"asset"`
+	Amount   int64  `json:"amount"`
+}
+
+// Redeem is emitted by Redeem and RedeemAsset
+type Redeem struct {
+	WalletID string `json:"wallet_id"`
+	Asset    string `json:"asset"`
+	Amount   int64  `json:"amount"`
+}
+
+// Transfer is emitted by Transfer, TransferWithType and TransferAsset
+type Transfer struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Asset        string `json:"asset"`
+	Amount       int64  `json:"amount"`
+	PaymentType  string `json:"payment_type"`
+	Fee          int64  `json:"fee"` // Charged to From on top of Amount
+	FeeCollector string `json:"fee_collector,omitempty"`
+}
+
+// BatchTransfer is emitted by BatchTransfer
+type BatchTransfer struct {
+	FromWalletID string   `json:"from_wallet_id"`
+	LegCount     int      `json:"leg_count"`
+	TotalAmount  int64    `json:"total_amount"`
+	TxIDs        []string `json:"tx_ids"`
+}
+
+// OfflineDevice is emitted by RegisterOfflineDevice and DeregisterOfflineDevice; key
+// material stays in the PDC
+type OfflineDevice struct {
+	DeviceID     string `json:"device_id"`
+	WalletID     string `json:"wallet_id"`
+	Deregistered bool   `json:"deregistered,omitempty"`
+	FinalCounter int64  `json:"final_counter,omitempty"` // Last nonce the device may settle
+}
+
+// OfflineSettled is emitted by ReconcileOffline
+type OfflineSettled struct {
+	DeviceID string `json:"device_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   int64  `json:"amount"`
+	Nonce    int64  `json:"nonce"`
+}
+
+// ProofOutcome reports one proof of a BatchReconcile
+type ProofOutcome struct {
+	Index    int    `json:"index"`
+	DeviceID string `json:"device_id"`
+	Nonce    int64  `json:"nonce"`
+	Status   string `json:"status"` // SETTLED, REJECTED
+	TxID     string `json:"tx_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// BatchReconcile is emitted by BatchReconcile
+type BatchReconcile struct {
+	BatchSize     int            `json:"batch_size"`
+	SuccessCount  int            `json:"success_count"`
+	RejectedCount int            `json:"rejected_count"`
+	Results       []ProofOutcome `json:"results"`
+}
+
+// Escrow is emitted when an escrow is created, approved, released or refunded
+type Escrow struct {
+	EscrowID     string `json:"escrow_id"`
+	FromWalletID string `json:"from_wallet_id"`
+	ToWalletID   string `json:"to_wallet_id"`
+	Amount       int64  `json:"amount"`
+	Status       string `json:"status"` // Held, Released, Refunded
+	Approvals    int    `json:"approvals"`
+}
+
+// Hold is emitted when a hold or lien is placed, released or captured
+type Hold struct {
+	HoldID     string `json:"hold_id"`
+	WalletID   string `json:"wallet_id"`
+	Amount     int64  `json:"amount"` // Amount still reserved
+	Purpose    string `json:"purpose"`
+	Status     string `json:"status"` // Active, Released, Captured
+	Captured   int64  `json:"captured,omitempty"`
+	CapturedTo string `json:"captured_to,omitempty"`
+	CaseRef    string `json:"case_ref,omitempty"`
+}
+
+// Asset is emitted by RegisterAsset
+type Asset struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Decimals  int    `json:"decimals"`
+	IssuerMSP string `json:"issuer_msp"`
+}
+
+// Pause is emitted by Pause and Unpause
+type Pause struct {
+	Action   string `json:"action"` // PAUSED, UNPAUSED
+	Scope    string `json:"scope"`  // ALL, INTERMEDIARY, OPERATION
+	Target   string `json:"target,omitempty"`
+	Reason   string `json:"reason"`
+	Actor    string `json:"actor"`
+	ActorMSP string `json:"actor_msp"`
+}
+
+// Merchant is emitted by RegisterMerchant
+type Merchant struct {
+	MerchantID         string `json:"merchant_id"`
+	Name               string `json:"name"`
+	MCC                string `json:"mcc"`
+	SettlementWalletID string `json:"settlement_wallet_id"`
+	FeePlan            string `json:"fee_plan"`
+	IntermediaryID     string `json:"intermediary_id"`
+}
+
+// PaymentRequest is emitted when a payment request is created, paid or cancelled
+type PaymentRequest struct {
+	MerchantID    string `json:"merchant_id"`
+	InvoiceID     string `json:"invoice_id"`
+	Amount        int64  `json:"amount"`
+	Status        string `json:"status"` // Open, Paid, Cancelled
+	PayerWalletID string `json:"payer_wallet_id,omitempty"`
+	TxID          string `json:"tx_id,omitempty"` // Settling transfer
+	Fee           int64  `json:"fee,omitempty"`
+}
+
+// Refund is emitted by Refund for refunds and chargebacks
+type Refund struct {
+	OriginalTxID string `json:"original_tx_id"`
+	Kind         string `json:"kind"` // Refund, Chargeback
+	From         string `json:"from"` // Payee of the original transfer
+	To           string `json:"to"`
+	Asset        string `json:"asset"`
+	Amount       int64  `json:"amount"`
+	Refunded     int64  `json:"refunded"` // Cumulative refunds of the original transfer
+	Reason       string `json:"reason"`
+}
+
+// Position is emitted by RecordIntermediaryPosition. The amounts stay in the
+// pdc-intermediary-positions collection.
+type Position struct {
+	IntermediaryID string `json:"intermediary_id"`
+}
+
+// SupplySeed is emitted by MigrateSupply when it seeds the supply counter of an upgraded ledger
+type SupplySeed struct {
+	Seeded      int64 `json:"seeded"`      // Supply in circulation before the counter
+	Outstanding int64 `json:"outstanding"` // Outstanding supply after the seed
+	Wallets     int   `json:"wallets"`     // Wallets whose balances were summed
+}
@@ -0,0 +1,164 @@
+// Package fees is the transfer fee schedule published by governance-cc and
+// applied by cbdc-core. Amounts are in the smallest NGN unit and percentages
+// are in basis points (1/100 of a percent).
+package fees
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Payment types a fee rule can be keyed by
+const (
+	P2P = "P2P" // Person to person
+	P2B = "P2B" // Person to business (merchant)
+	B2P = "B2P"
+	B2B = "B2B"
+	G2P = "G2P" // Government disbursement
+	P2G = "P2G" // Tax and levy payments
+)
+
+// Types lists every payment type
+var Types = []string{P2P, P2B, B2P, B2B, G2P, P2G}
+
+// Rule kinds
+const (
+	KindExempt     = "Exempt"
+	KindFlat       = "Flat"
+	KindPercentage = "Percentage"
+	KindTiered     = "Tiered"
+)
+
+// Band is one amount range of a tiered rule
+type Band struct {
+	UpTo        int64 `json:"up_to"` // Inclusive upper bound of the band, 0 for no bound
+	Flat        int64 `json:"flat,omitempty" metadata:",optional"`
+	BasisPoints int64 `json:"basis_points,omitempty" metadata:",optional"`
+}
+
+// Rule prices one payment type. An empty Kind is treated as Exempt.
+type Rule struct {
+	Kind        string `json:"kind"`
+	Flat        int64  `json:"flat,omitempty" metadata:",optional"`         // Flat
+	BasisPoints int64  `json:"basis_points,omitempty" metadata:",optional"` // Percentage
+	Bands       []Band `json:"bands,omitempty" metadata:",optional"`        // Tiered, ascending by UpTo
+	Min         int64  `json:"min,omitempty" metadata:",optional"`          // Floor applied to non-zero fees
+	Max         int64  `json:"max,omitempty" metadata:",optional"`          // Cap, 0 for no cap
+}
+
+// Schedule is the fee schedule for transfers
+type Schedule struct {
+	CollectorWalletID string          `json:"collector_wallet_id"`                  // Wallet credited with every fee
+	Default           Rule            `json:"default"`                              // Applies to types without a rule
+	Rules             map[string]Rule `json:"rules,omitempty" metadata:",optional"` // Keyed by payment type
+}
+
+// RuleFor returns the rule that prices a payment type
+func (s *Schedule) RuleFor(paymentType string) Rule {
+	if rule, ok := s.Rules[paymentType]; ok {
+		return rule
+	}
+	return s.Default
+}
+
+// Fee returns the fee charged on a payment of amount
+func (s *Schedule) Fee(paymentType string, amount int64) (int64, error) {
+	return s.RuleFor(paymentType).Fee(amount)
+}
+
+// Fee returns the fee the rule charges on amount
+func (r Rule) Fee(amount int64) (int64, error) {
+	var fee int64
+	switch r.Kind {
+	case "", KindExempt:
+		return 0, nil
+	case KindFlat:
+		fee = r.Flat
+	case KindPercentage:
+		fee = amount * r.BasisPoints / 10000
+	case KindTiered:
+		band, ok := r.band(amount)
+		if !ok {
+			return 0, fmt.Errorf("no fee band covers amount %d", amount)
+		}
+		fee = band.Flat + amount*band.BasisPoints/10000
+	default:
+		return 0, fmt.Errorf("unknown fee rule kind %q", r.Kind)
+	}
+
+	if fee > 0 && fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return fee, nil
+}
+
+func (r Rule) band(amount int64) (Band, bool) {
+	for _, band := range r.Bands {
+		if band.UpTo == 0 || amount <= band.UpTo {
+			return band, true
+		}
+	}
+	return Band{}, false
+}
+
+// Validate checks that every rule is well formed and that a collector is set
+// whenever a rule can charge
+func (s *Schedule) Validate() error {
+	charges := false
+	rules := map[string]Rule{"default": s.Default}
+	for paymentType, rule := range s.Rules {
+		if !slices.Contains(Types, paymentType) {
+			return fmt.Errorf("unknown payment type %q", paymentType)
+		}
+		rules[paymentType] = rule
+	}
+	for name, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%s rule: %v", name, err)
+		}
+		if rule.Kind != "" && rule.Kind != KindExempt {
+			charges = true
+		}
+	}
+	if charges && s.CollectorWalletID == "" {
+		return fmt.Errorf("a collector wallet is required when fees are charged")
+	}
+	return nil
+}
+
+func (r Rule) validate() error {
+	if r.Flat < 0 || r.BasisPoints < 0 || r.Min < 0 || r.Max < 0 {
+		return fmt.Errorf("fees cannot be negative")
+	}
+	if r.BasisPoints > 10000 {
+		return fmt.Errorf("basis points cannot exceed 10000")
+	}
+	if r.Max > 0 && r.Min > r.Max {
+		return fmt.Errorf("min %d exceeds max %d", r.Min, r.Max)
+	}
+	switch r.Kind {
+	case "", KindExempt, KindFlat, KindPercentage:
+		return nil
+	case KindTiered:
+		if len(r.Bands) == 0 {
+			return fmt.Errorf("tiered rule needs at least one band")
+		}
+		for i, band := range r.Bands {
+			if band.Flat < 0 || band.BasisPoints < 0 || band.BasisPoints > 10000 {
+				return fmt.Errorf("band %d has an invalid fee", i)
+			}
+			last := i == len(r.Bands)-1
+			if band.UpTo == 0 && !last {
+				return fmt.Errorf("only the last band can be unbounded")
+			}
+			if i > 0 && band.UpTo != 0 && band.UpTo <= r.Bands[i-1].UpTo {
+				return fmt.Errorf("bands must be in ascending order")
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown fee rule kind %q", r.Kind)
+}
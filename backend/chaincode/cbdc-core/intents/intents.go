@@ -0,0 +1,64 @@
+// Package intents defines the messages wallet owners sign to authorize debits, shared by
+// the cbdc-core chaincode and the services that hold custodial keys. The chaincode
+// verifies the signature against the owner key registered on the wallet, so an
+// intermediary cannot move a customer's funds without the owner's key.
+package intents
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// TransientKey is the transient map entry carrying an Authorization
+const TransientKey = "authorization"
+
+// Transfer is the debit an owner signs. Its JSON encoding, in field order, is the signed
+// message, e.g. {"from":"cb1…","to":"cb1…","asset":"NGN","amount":1000,"nonce":1}.
+type Transfer struct {
+	From   string `json:"from"`
+	To     string `json:"to,omitempty"`     // Recipient; empty for a batch
+	Legs   string `json:"legs,omitempty"`   // Batch only: LegsHash of the BatchTransfer legs JSON
+	Hold   string `json:"hold,omitempty"`   // Holds only: the hold placed, or captured to To
+	Escrow bool   `json:"escrow,omitempty"` // Escrows only: the debit funds an escrow for To
+	Refund string `json:"refund,omitempty"` // Refunds only: the original transaction refunded to To
+	Device string `json:"device,omitempty"` // Offline device registrations only: the device ID
+	Key    string `json:"key,omitempty"`    // Offline device registrations only: the device's hex public key
+	Final  int64  `json:"final,omitempty"`  // Offline device deregistrations only: the device's last used nonce
+	Asset  string `json:"asset"`
+	Amount int64  `json:"amount"` // Batch total for a batch; purse limit for a device; excludes fees
+	Nonce  int64  `json:"nonce"`  // One more than the wallet's last used nonce
+}
+
+// Authorization carries an owner's signature over a Transfer
+type Authorization struct {
+	Nonce     int64  `json:"nonce"`
+	Signature string `json:"signature"` // Hex-encoded Ed25519 signature of Transfer.Message
+}
+
+// Message returns the bytes an owner signs
+func (t Transfer) Message() []byte {
+	message, _ := json.Marshal(t)
+	return message
+}
+
+// LegsHash identifies the legs of a batch in a signed Transfer
+func LegsHash(legsJSON string) string {
+	sum := sha256.Sum256([]byte(legsJSON))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign authorizes t with the owner's private key
+func Sign(privateKey ed25519.PrivateKey, t Transfer) Authorization {
+	return Authorization{Nonce: t.Nonce, Signature: hex.EncodeToString(ed25519.Sign(privateKey, t.Message()))}
+}
+
+// Verify reports whether signature is the owner's signature of t
+func Verify(publicKey ed25519.PublicKey, t Transfer, signature string) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(publicKey, t.Message(), sig)
+}
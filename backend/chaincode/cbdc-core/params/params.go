@@ -0,0 +1,93 @@
+// Package params is the versioned scheme parameter document published by governance-cc:
+// per-tier limits, offline purse caps and the fee schedule. cbdc-core reads it in each
+// transaction and the backend services cache it, so neither hardcodes these values.
+package params
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
+)
+
+// Offline caps the device purses issued by the offline-service (Phase 7). Amounts are in
+// smallest units (cents), like the tier limits.
+type Offline struct {
+	MaxBalance     int64 `json:"max_balance"`     // Largest balance a purse may hold
+	MaxTransaction int64 `json:"max_transaction"` // Largest single offline payment
+	SyncTTLDays    int   `json:"sync_ttl_days"`   // Days a device may stay offline before it must sync
+}
+
+// Document is one version of the scheme parameters
+type Document struct {
+	Version             int64                   `json:"version"`        // Incremented by every executed change, 0 before the first
+	EffectiveFrom       int64                   `json:"effective_from"` // Unix time the version took effect
+	ProposalID          string                  `json:"proposal_id,omitempty" metadata:",optional"`
+	MaxTransactionLimit int64                   `json:"max_transaction_limit"`
+	MinTransactionLimit int64                   `json:"min_transaction_limit"`
+	Tiers               map[string]tiers.Limits `json:"tiers"`
+	Offline             Offline                 `json:"offline"`
+	Fees                fees.Schedule           `json:"fees"`
+}
+
+// Default returns the parameters that apply until governance-cc publishes a document
+func Default() Document {
+	limits := make(map[string]tiers.Limits, len(tiers.DefaultLimits))
+	for tier, l := range tiers.DefaultLimits {
+		limits[tier] = l
+	}
+	return Document{
+		MaxTransactionLimit: 1000000,
+		MinTransactionLimit: 1,
+		Tiers:               limits,
+		Offline:             Offline{MaxBalance: 50000, MaxTransaction: 5000, SyncTTLDays: 7},
+		Fees:                fees.Schedule{Default: fees.Rule{Kind: fees.KindExempt}},
+	}
+}
+
+// TierLimits returns the limits of a tier, and false if the document has none for it
+func (d Document) TierLimits(tier string) (tiers.Limits, bool) {
+	limits, ok := d.Tiers[tier]
+	return limits, ok
+}
+
+// SyncTTL is how long a device may stay offline before it must sync
+func (o Offline) SyncTTL() time.Duration {
+	return time.Duration(o.SyncTTLDays) * 24 * time.Hour
+}
+
+// Cache holds the document for a service, refetching it once it is older than ttl.
+// fetch typically evaluates GetParams on governance-cc.
+type Cache struct {
+	fetch func() ([]byte, error)
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	doc       Document
+	fetchedAt time.Time
+}
+
+// NewCache returns a cache that starts from Default until the first successful fetch
+func NewCache(fetch func() ([]byte, error), ttl time.Duration) *Cache {
+	return &Cache{fetch: fetch, ttl: ttl, doc: Default()}
+}
+
+// Get returns the current document. If a refresh fails, the last document fetched (or
+// Default) is returned and the fetch is retried on the next call.
+func (c *Cache) Get() Document {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fetch != nil && time.Since(c.fetchedAt) >= c.ttl {
+		if data, err := c.fetch(); err == nil {
+			var doc Document
+			if err := json.Unmarshal(data, &doc); err == nil {
+				c.doc = doc
+				c.fetchedAt = time.Now()
+			}
+		}
+	}
+	return c.doc
+}
@@ -0,0 +1,54 @@
+// Package tiers is the canonical wallet tier vocabulary shared by the cbdc-core
+// chaincode and the backend services.
+package tiers
+
+// Wallet tiers, from lightest to fullest KYC
+const (
+	Tier0 = "Tier0" // Phone number only
+	Tier1 = "Tier1" // BVN or NIN verified
+	Tier2 = "Tier2" // Business/corporate, full KYC
+)
+
+// Default is the tier assigned to new wallets
+const Default = Tier0
+
+// Limits holds the protocol-level controls for a wallet tier (Phase 0/8)
+type Limits struct {
+	MaxTransaction int64 `json:"max_transaction"` // Largest single debit
+	DailyLimit     int64 `json:"daily_limit"`     // Cumulative debits per UTC day
+	MaxDailyCount  int64 `json:"max_daily_count"` // Debits per UTC day (velocity)
+	MaxBalance     int64 `json:"max_balance"`     // Balance ceiling, 0 means no ceiling
+}
+
+// DefaultLimits maps each tier to its limits, in smallest units (cents). They apply until
+// governance-cc publishes a parameter document (see package params).
+// Tier 0: $500 balance, $100 daily tx
+// Tier 1: $10,000 balance, $2,000 daily tx
+// Tier 2: business/corporate, no balance ceiling
+var DefaultLimits = map[string]Limits{
+	Tier0: {MaxTransaction: 10000, DailyLimit: 10000, MaxDailyCount: 20, MaxBalance: 50000},
+	Tier1: {MaxTransaction: 100000, DailyLimit: 200000, MaxDailyCount: 100, MaxBalance: 1000000},
+	Tier2: {MaxTransaction: 1000000, DailyLimit: 5000000, MaxDailyCount: 1000, MaxBalance: 0},
+}
+
+// legacyTiers maps the TIER_1/2/3 names previously used by auth-service and wallet-service
+var legacyTiers = map[string]string{
+	"TIER_1": Tier0,
+	"TIER_2": Tier1,
+	"TIER_3": Tier2,
+}
+
+// Valid reports whether tier is a canonical tier
+func Valid(tier string) bool {
+	_, ok := DefaultLimits[tier]
+	return ok
+}
+
+// Normalize returns the canonical name for a canonical or legacy tier, and false if it is unknown
+func Normalize(tier string) (string, bool) {
+	if Valid(tier) {
+		return tier, true
+	}
+	canonical, ok := legacyTiers[tier]
+	return canonical, ok
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/fees"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -12,46 +15,210 @@ type GovernanceContract struct {
 	contractapi.Contract
 }
 
+// GlobalParams is the parameter set stored before versioned documents; it is read
+// only to build version 0 of the document on ledgers that still hold it.
 type GlobalParams struct {
 	MaxTransactionLimit int64 `json:"max_transaction_limit"`
 	MinTransactionLimit int64 `json:"min_transaction_limit"`
 	FeePercentage       int   `json:"fee_percentage"` // Basis points
 }
 
+// ParamsDocument is one version of the scheme parameters: global and per-tier limits,
+// offline purse caps and the fee schedule. It is the cbdc-core params document itself,
+// which cbdc-core and the services read; this chaincode only validates and stores it.
+type ParamsDocument = params.Document
+
+// FeeSchedule prices cbdc-core transfers by payment type. It is the cbdc-core fees
+// schedule itself, so a document is validated by the same rules cbdc-core charges by.
+type FeeSchedule = fees.Schedule
+
+const (
+	paramsKey            = "PARAMS"
+	docTypeParamsVersion = "PARAMS_VERSION"
+
+	// Keys written before versioned documents
+	globalParamsKey = "GLOBAL_PARAMS"
+	feeScheduleKey  = "FEE_SCHEDULE"
+)
+
+// walletTiers are the canonical cbdc-core tiers; a document must set limits for each
+var walletTiers = []string{tiers.Tier0, tiers.Tier1, tiers.Tier2}
+
+// defaultGovernanceConfig applies until a GOVERNANCE_CONFIG proposal is executed
+var defaultGovernanceConfig = GovernanceConfig{Quorum: 2}
+
+// InitLedger publishes the default document as version 1 and sets the governance config.
+// It can run only once, by a Central Bank admin; after that, changes go through proposals
+// (see proposals.go).
 func (c *GovernanceContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	params := GlobalParams{
-		MaxTransactionLimit: 1000000,
-		MinTransactionLimit: 1,
-		FeePercentage:       0,
+	actor, err := getCaller(ctx)
+	if err != nil {
+		return err
+	}
+	if !actor.isCentralBankAdmin() {
+		return fmt.Errorf("unauthorized: only Central Bank admins can initialise governance")
+	}
+	current, err := c.GetParams(ctx)
+	if err != nil {
+		return err
+	}
+	if current.Version > 0 {
+		return fmt.Errorf("ledger already initialised; use ProposeParamChange")
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if err := putParams(ctx, current, now.Unix(), ""); err != nil {
+		return err
+	}
+	configBytes, _ := json.Marshal(defaultGovernanceConfig)
+	return ctx.GetStub().PutState(governanceConfigKey, configBytes)
+}
+
+func validateParams(doc *ParamsDocument) error {
+	if doc.MinTransactionLimit < 1 || doc.MaxTransactionLimit < doc.MinTransactionLimit {
+		return fmt.Errorf("invalid limits: min %d, max %d", doc.MinTransactionLimit, doc.MaxTransactionLimit)
+	}
+	for _, tier := range walletTiers {
+		limits, ok := doc.Tiers[tier]
+		if !ok {
+			return fmt.Errorf("limits for %s are required", tier)
+		}
+		if limits.MaxTransaction < 1 || limits.DailyLimit < 1 || limits.MaxDailyCount < 1 || limits.MaxBalance < 0 {
+			return fmt.Errorf("invalid %s limits", tier)
+		}
+	}
+	if len(doc.Tiers) != len(walletTiers) {
+		return fmt.Errorf("unknown tier in limits; expected %v", walletTiers)
+	}
+	if doc.Offline.MaxBalance < 1 || doc.Offline.MaxTransaction < 1 || doc.Offline.MaxTransaction > doc.Offline.MaxBalance {
+		return fmt.Errorf("invalid offline caps: balance %d, transaction %d", doc.Offline.MaxBalance, doc.Offline.MaxTransaction)
+	}
+	if doc.Offline.SyncTTLDays < 1 {
+		return fmt.Errorf("offline sync TTL must be at least one day")
+	}
+	if err := doc.Fees.Validate(); err != nil {
+		return fmt.Errorf("invalid fee schedule: %v", err)
+	}
+	return nil
+}
+
+// putParams publishes doc as the next version, keeping every version on the ledger
+func putParams(ctx contractapi.TransactionContextInterface, doc *ParamsDocument, effectiveFrom int64, proposalID string) error {
+	doc.Version++
+	doc.EffectiveFrom = effectiveFrom
+	doc.ProposalID = proposalID
+	docBytes, _ := json.Marshal(doc)
+	if err := ctx.GetStub().PutState(paramsKey, docBytes); err != nil {
+		return err
+	}
+	key, err := paramsVersionKey(ctx, doc.Version)
+	if err != nil {
+		return err
 	}
-	paramsBytes, _ := json.Marshal(params)
-	return ctx.GetStub().PutState("GLOBAL_PARAMS", paramsBytes)
+	return ctx.GetStub().PutState(key, docBytes)
 }
 
-func (c *GovernanceContract) UpdateParams(ctx contractapi.TransactionContextInterface, maxLimit int64, minLimit int64, fee int) error {
-	// TODO: Check if caller is Admin of Central Bank
+func paramsVersionKey(ctx contractapi.TransactionContextInterface, version int64) (string, error) {
+	// Zero-padded so versions list in order
+	return ctx.GetStub().CreateCompositeKey(docTypeParamsVersion, []string{fmt.Sprintf("%012d", version)})
+}
+
+// GetParams returns the current parameter document. Before the first version it is
+// built from the defaults and any params and fee schedule stored by earlier releases.
+func (c *GovernanceContract) GetParams(ctx contractapi.TransactionContextInterface) (*ParamsDocument, error) {
+	docBytes, err := ctx.GetStub().GetState(paramsKey)
+	if err != nil {
+		return nil, err
+	}
+	if docBytes != nil {
+		var doc ParamsDocument
+		if err := json.Unmarshal(docBytes, &doc); err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	}
 
-	params := GlobalParams{
-		MaxTransactionLimit: maxLimit,
-		MinTransactionLimit: minLimit,
-		FeePercentage:       fee,
+	doc := params.Default()
+	paramsBytes, err := ctx.GetStub().GetState(globalParamsKey)
+	if err != nil {
+		return nil, err
 	}
-	paramsBytes, _ := json.Marshal(params)
-	return ctx.GetStub().PutState("GLOBAL_PARAMS", paramsBytes)
+	if paramsBytes != nil {
+		var legacy GlobalParams
+		if err := json.Unmarshal(paramsBytes, &legacy); err != nil {
+			return nil, err
+		}
+		doc.MaxTransactionLimit = legacy.MaxTransactionLimit
+		doc.MinTransactionLimit = legacy.MinTransactionLimit
+		if legacy.FeePercentage > 0 {
+			doc.Fees.Default = fees.Rule{Kind: fees.KindPercentage, BasisPoints: int64(legacy.FeePercentage)}
+		}
+	}
+	scheduleBytes, err := ctx.GetStub().GetState(feeScheduleKey)
+	if err != nil {
+		return nil, err
+	}
+	if scheduleBytes != nil {
+		if err := json.Unmarshal(scheduleBytes, &doc.Fees); err != nil {
+			return nil, err
+		}
+	}
+	return &doc, nil
 }
 
-func (c *GovernanceContract) GetParams(ctx contractapi.TransactionContextInterface) (*GlobalParams, error) {
-	paramsBytes, err := ctx.GetStub().GetState("GLOBAL_PARAMS")
+// GetParamsVersion returns a published version of the parameter document
+func (c *GovernanceContract) GetParamsVersion(ctx contractapi.TransactionContextInterface, version int64) (*ParamsDocument, error) {
+	key, err := paramsVersionKey(ctx, version)
 	if err != nil {
 		return nil, err
 	}
-	if paramsBytes == nil {
-		return nil, fmt.Errorf("params not set")
+	docBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, err
 	}
+	if docBytes == nil {
+		return nil, fmt.Errorf("params version %d does not exist", version)
+	}
+	var doc ParamsDocument
+	if err := json.Unmarshal(docBytes, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// GetParamsHistory returns every published version of the parameter document, oldest first
+func (c *GovernanceContract) GetParamsHistory(ctx contractapi.TransactionContextInterface) ([]*ParamsDocument, error) {
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(docTypeParamsVersion, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	history := []*ParamsDocument{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var doc ParamsDocument
+		if err := json.Unmarshal(kv.Value, &doc); err != nil {
+			return nil, err
+		}
+		history = append(history, &doc)
+	}
+	return history, nil
+}
 
-	var params GlobalParams
-	err = json.Unmarshal(paramsBytes, &params)
-	return &params, nil
+// GetFeeSchedule returns the fee schedule of the current parameter document
+func (c *GovernanceContract) GetFeeSchedule(ctx contractapi.TransactionContextInterface) (*FeeSchedule, error) {
+	doc, err := c.GetParams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &doc.Fees, nil
 }
 
 func main() {
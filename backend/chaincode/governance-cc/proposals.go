@@ -0,0 +1,530 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Organisations and certificate attributes, as in cbdc-core
+const (
+	MSPCentralBank = "CentralBankMSP"
+	MSPRegulator   = "RegulatorMSP"
+	AttrRole       = "role"
+	RoleAdmin      = "admin"
+)
+
+// Proposal kinds: the state a proposal replaces when it is executed
+const (
+	KindParams           = "PARAMS" // Publishes the next version of the parameter document
+	KindGovernanceConfig = "GOVERNANCE_CONFIG"
+)
+
+// Proposal statuses. Pending and Approved proposals become Expired once ExpiresAt passes.
+const (
+	ProposalPending  = "Pending"
+	ProposalApproved = "Approved" // Quorum (and regulator co-sign, if required) reached
+	ProposalExecuted = "Executed"
+	ProposalRejected = "Rejected"
+	ProposalExpired  = "Expired"
+)
+
+// Actions recorded in a proposal's history
+const (
+	ActionProposed = "Proposed"
+	ActionApproved = "Approved"
+	ActionRejected = "Rejected"
+	ActionExecuted = "Executed"
+	ActionExpired  = "Expired"
+)
+
+const (
+	docTypeProposal     = "PROPOSAL"
+	governanceConfigKey = "GOVERNANCE_CONFIG"
+	proposalEventName   = "ProposalEvent"
+
+	// defaultProposalLifetime applies when a proposal gives no expiry
+	defaultProposalLifetime = 7 * 24 * time.Hour
+)
+
+// GovernanceConfig sets who must approve a proposal before it can be executed
+type GovernanceConfig struct {
+	Quorum           int      `json:"quorum"`                                   // Distinct Central Bank admin identities
+	RequireRegulator bool     `json:"require_regulator"`                        // A Regulator identity must co-sign
+	Approvers        []string `json:"approvers,omitempty" metadata:",optional"` // Central Bank admin identities that may propose and approve
+}
+
+// Proposal is a pending change to governance state. Proposals are never deleted, so the
+// ledger keeps every change that was proposed and who acted on it.
+type Proposal struct {
+	ID                string           `json:"id"`
+	Kind              string           `json:"kind"`
+	Payload           string           `json:"payload"`                                     // JSON of the new ParamsDocument or GovernanceConfig
+	BaseVersion       int64            `json:"base_version,omitempty" metadata:",optional"` // PARAMS: the document version the change was drafted against
+	Description       string           `json:"description"`
+	Proposer          string           `json:"proposer"`
+	Quorum            int              `json:"quorum"` // Copied from the config when proposed
+	RequireRegulator  bool             `json:"require_regulator"`
+	Approvers         []string         `json:"approvers,omitempty" metadata:",optional"` // Copied from the config; empty under the default config
+	Approvals         []string         `json:"approvals"`                                // Central Bank admin identities, including the proposer, and any Regulator co-signer
+	RegulatorApproved bool             `json:"regulator_approved"`
+	Status            string           `json:"status"`
+	CreatedAt         int64            `json:"created_at"`
+	ActivationTime    int64            `json:"activation_time"` // Earliest execution time
+	ExpiresAt         int64            `json:"expires_at"`
+	ExecutedAt        int64            `json:"executed_at,omitempty" metadata:",optional"`
+	History           []ProposalAction `json:"history"`
+}
+
+// ProposalAction is one step in a proposal's history
+type ProposalAction struct {
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	ActorMSP  string `json:"actor_msp"`
+	Reason    string `json:"reason,omitempty" metadata:",optional"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// ProposalEvent is emitted whenever a proposal changes
+type ProposalEvent struct {
+	ProposalID string `json:"proposal_id"`
+	Kind       string `json:"kind"`
+	Action     string `json:"action"`
+	Status     string `json:"status"`
+}
+
+// caller is the identity submitting the transaction
+type caller struct {
+	ID    string
+	MSPID string
+	Role  string
+}
+
+func getCaller(ctx contractapi.TransactionContextInterface) (*caller, error) {
+	identity := ctx.GetClientIdentity()
+	mspID, err := identity.GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MSP ID: %v", err)
+	}
+	id, err := identity.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client identity: %v", err)
+	}
+	role, _, err := identity.GetAttributeValue(AttrRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s attribute: %v", AttrRole, err)
+	}
+	return &caller{ID: id, MSPID: mspID, Role: role}, nil
+}
+
+func (c *caller) isCentralBankAdmin() bool {
+	return c.MSPID == MSPCentralBank && c.Role == RoleAdmin
+}
+
+// mayApprove reports whether the caller is a Central Bank admin counting towards the quorum.
+// The default config names no approvers, so any admin counts until one is adopted.
+func (c *caller) mayApprove(approvers []string) bool {
+	return c.isCentralBankAdmin() && (len(approvers) == 0 || slices.Contains(approvers, c.ID))
+}
+
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	ts, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get tx timestamp: %v", err)
+	}
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC(), nil
+}
+
+// GetGovernanceConfig returns the approval rules for new proposals
+func (c *GovernanceContract) GetGovernanceConfig(ctx contractapi.TransactionContextInterface) (*GovernanceConfig, error) {
+	configBytes, err := ctx.GetStub().GetState(governanceConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	if configBytes == nil {
+		return &defaultGovernanceConfig, nil
+	}
+	var config GovernanceConfig
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// validatePayload checks a proposal payload and returns it in canonical form
+func validatePayload(kind, payload string) ([]byte, error) {
+	switch kind {
+	case KindParams:
+		var doc ParamsDocument
+		if err := json.Unmarshal([]byte(payload), &doc); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		if err := validateParams(&doc); err != nil {
+			return nil, err
+		}
+		doc.Version, doc.EffectiveFrom, doc.ProposalID = 0, 0, ""
+		return json.Marshal(doc)
+	case KindGovernanceConfig:
+		var config GovernanceConfig
+		if err := json.Unmarshal([]byte(payload), &config); err != nil {
+			return nil, fmt.Errorf("invalid governance config: %v", err)
+		}
+		// A quorum of one would let a single admin change governance on their own word
+		if config.Quorum < 2 || config.Quorum > len(config.Approvers) {
+			return nil, fmt.Errorf("quorum must be between 2 and the number of approvers (%d)", len(config.Approvers))
+		}
+		for i, id := range config.Approvers {
+			if id == "" || slices.Contains(config.Approvers[:i], id) {
+				return nil, fmt.Errorf("approvers must be distinct, non-empty identities")
+			}
+		}
+		return json.Marshal(config)
+	default:
+		return nil, fmt.Errorf("unknown proposal kind %q", kind)
+	}
+}
+
+func proposalKey(ctx contractapi.TransactionContextInterface, id string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(docTypeProposal, []string{id})
+}
+
+func readProposal(ctx contractapi.TransactionContextInterface, id string) (*Proposal, error) {
+	key, err := proposalKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	proposalBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proposal: %v", err)
+	}
+	if proposalBytes == nil {
+		return nil, fmt.Errorf("proposal %s does not exist", id)
+	}
+	var proposal Proposal
+	if err := json.Unmarshal(proposalBytes, &proposal); err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// putProposal saves the proposal and emits a ProposalEvent for the action just recorded
+func putProposal(ctx contractapi.TransactionContextInterface, proposal *Proposal, action string) error {
+	key, err := proposalKey(ctx, proposal.ID)
+	if err != nil {
+		return err
+	}
+	proposalBytes, _ := json.Marshal(proposal)
+	if err := ctx.GetStub().PutState(key, proposalBytes); err != nil {
+		return err
+	}
+	eventBytes, _ := json.Marshal(ProposalEvent{
+		ProposalID: proposal.ID,
+		Kind:       proposal.Kind,
+		Action:     action,
+		Status:     proposal.Status,
+	})
+	return ctx.GetStub().SetEvent(proposalEventName, eventBytes)
+}
+
+// refreshStatus marks an open proposal Expired once its expiry has passed
+func (p *Proposal) refreshStatus(now time.Time) {
+	if (p.Status == ProposalPending || p.Status == ProposalApproved) && now.Unix() >= p.ExpiresAt {
+		p.Status = ProposalExpired
+	}
+}
+
+// adminApprovals counts the Central Bank admin approvals, leaving out the Regulator's co-sign
+func (p *Proposal) adminApprovals() int {
+	if p.RegulatorApproved {
+		return len(p.Approvals) - 1
+	}
+	return len(p.Approvals)
+}
+
+func (p *Proposal) approved() bool {
+	return p.adminApprovals() >= p.Quorum && (!p.RequireRegulator || p.RegulatorApproved)
+}
+
+// openProposal loads a proposal that can still be approved, rejected or executed.
+// The first such transaction after the expiry instead saves the proposal as Expired,
+// with the expiry in its history, and reports expired: the caller returns the proposal
+// without error, since a failed transaction would leave the expiry unrecorded.
+func openProposal(ctx contractapi.TransactionContextInterface, id string, now time.Time, actor *caller) (proposal *Proposal, expired bool, err error) {
+	proposal, err = readProposal(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if proposal.Status != ProposalPending && proposal.Status != ProposalApproved {
+		return nil, false, fmt.Errorf("proposal %s is %s", id, proposal.Status)
+	}
+	proposal.refreshStatus(now)
+	if proposal.Status == ProposalExpired {
+		proposal.History = append(proposal.History, ProposalAction{Action: ActionExpired, Actor: actor.ID, ActorMSP: actor.MSPID, Timestamp: now.Unix()})
+		if err := putProposal(ctx, proposal, ActionExpired); err != nil {
+			return nil, false, err
+		}
+		return proposal, true, nil
+	}
+	return proposal, false, nil
+}
+
+// ProposeParamChange opens a proposal to publish payload as the next parameter document
+// (kind PARAMS) or to replace the governance config. Only Central Bank admins can propose, and the
+// proposer's approval counts towards the quorum. activationTime is the earliest unix
+// time the change can be executed (0 for as soon as it is approved); expiresAt 0 means
+// seven days after activation.
+func (c *GovernanceContract) ProposeParamChange(ctx contractapi.TransactionContextInterface, kind string, payload string, description string, activationTime int64, expiresAt int64) (*Proposal, error) {
+	actor, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.isCentralBankAdmin() {
+		return nil, fmt.Errorf("unauthorized: only Central Bank admins can propose changes")
+	}
+	config, err := c.GetGovernanceConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.mayApprove(config.Approvers) {
+		return nil, fmt.Errorf("unauthorized: %s is not a governance approver", actor.ID)
+	}
+	if description == "" {
+		return nil, fmt.Errorf("a description is required")
+	}
+	canonical, err := validatePayload(kind, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if activationTime < now.Unix() {
+		activationTime = now.Unix()
+	}
+	if expiresAt == 0 {
+		expiresAt = time.Unix(activationTime, 0).Add(defaultProposalLifetime).Unix()
+	}
+	if expiresAt <= activationTime {
+		return nil, fmt.Errorf("expiry must be after the activation time")
+	}
+
+	var baseVersion int64
+	if kind == KindParams {
+		current, err := c.GetParams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		baseVersion = current.Version
+	}
+	proposal := &Proposal{
+		ID:               ctx.GetStub().GetTxID(),
+		Kind:             kind,
+		Payload:          string(canonical),
+		BaseVersion:      baseVersion,
+		Description:      description,
+		Proposer:         actor.ID,
+		Quorum:           config.Quorum,
+		RequireRegulator: config.RequireRegulator,
+		Approvers:        config.Approvers,
+		Approvals:        []string{actor.ID},
+		Status:           ProposalPending,
+		CreatedAt:        now.Unix(),
+		ActivationTime:   activationTime,
+		ExpiresAt:        expiresAt,
+		History: []ProposalAction{
+			{Action: ActionProposed, Actor: actor.ID, ActorMSP: actor.MSPID, Timestamp: now.Unix()},
+		},
+	}
+	if proposal.approved() {
+		proposal.Status = ProposalApproved
+	}
+	if err := putProposal(ctx, proposal, ActionProposed); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// ApproveProposal adds the caller's approval. Central Bank admins named as approvers count
+// towards the quorum once each; a Regulator identity co-signs when the proposal requires it
+// and is recorded in Approvals without counting towards the quorum. An expired proposal
+// is returned with status Expired instead.
+func (c *GovernanceContract) ApproveProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*Proposal, error) {
+	actor, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposal, expired, err := openProposal(ctx, proposalID, now, actor)
+	if err != nil || expired {
+		return proposal, err
+	}
+
+	switch {
+	case actor.mayApprove(proposal.Approvers):
+		if slices.Contains(proposal.Approvals, actor.ID) {
+			return nil, fmt.Errorf("%s has already approved proposal %s", actor.ID, proposalID)
+		}
+		proposal.Approvals = append(proposal.Approvals, actor.ID)
+	case actor.MSPID == MSPRegulator && proposal.RequireRegulator:
+		if proposal.RegulatorApproved {
+			return nil, fmt.Errorf("the Regulator has already approved proposal %s", proposalID)
+		}
+		proposal.Approvals = append(proposal.Approvals, actor.ID)
+		proposal.RegulatorApproved = true
+	default:
+		return nil, fmt.Errorf("unauthorized: %s cannot approve proposal %s", actor.MSPID, proposalID)
+	}
+
+	proposal.History = append(proposal.History, ProposalAction{Action: ActionApproved, Actor: actor.ID, ActorMSP: actor.MSPID, Timestamp: now.Unix()})
+	if proposal.approved() {
+		proposal.Status = ProposalApproved
+	}
+	if err := putProposal(ctx, proposal, ActionApproved); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// RejectProposal closes a proposal without applying it. Any Central Bank admin who may
+// approve it, or the Regulator on proposals that require its co-sign, can reject.
+func (c *GovernanceContract) RejectProposal(ctx contractapi.TransactionContextInterface, proposalID string, reason string) (*Proposal, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+	actor, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposal, expired, err := openProposal(ctx, proposalID, now, actor)
+	if err != nil || expired {
+		return proposal, err
+	}
+	if !actor.mayApprove(proposal.Approvers) && !(actor.MSPID == MSPRegulator && proposal.RequireRegulator) {
+		return nil, fmt.Errorf("unauthorized: %s cannot reject proposal %s", actor.MSPID, proposalID)
+	}
+
+	proposal.Status = ProposalRejected
+	proposal.History = append(proposal.History, ProposalAction{Action: ActionRejected, Actor: actor.ID, ActorMSP: actor.MSPID, Reason: reason, Timestamp: now.Unix()})
+	if err := putProposal(ctx, proposal, ActionRejected); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// ExecuteProposal applies an approved proposal once its activation time has come.
+// Any Central Bank admin can execute.
+func (c *GovernanceContract) ExecuteProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*Proposal, error) {
+	actor, err := getCaller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.isCentralBankAdmin() {
+		return nil, fmt.Errorf("unauthorized: only Central Bank admins can execute proposals")
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposal, expired, err := openProposal(ctx, proposalID, now, actor)
+	if err != nil || expired {
+		return proposal, err
+	}
+	if !proposal.approved() {
+		return nil, fmt.Errorf("proposal %s has %d of %d approvals (regulator co-sign required: %t, given: %t)",
+			proposalID, proposal.adminApprovals(), proposal.Quorum, proposal.RequireRegulator, proposal.RegulatorApproved)
+	}
+	if now.Unix() < proposal.ActivationTime {
+		return nil, fmt.Errorf("proposal %s cannot be executed before %s", proposalID, time.Unix(proposal.ActivationTime, 0).UTC().Format(time.RFC3339))
+	}
+
+	switch proposal.Kind {
+	case KindParams:
+		// A document replaces the whole parameter set, so it must not silently undo a
+		// version published after it was drafted
+		current, err := c.GetParams(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if current.Version != proposal.BaseVersion {
+			return nil, fmt.Errorf("proposal %s was drafted against params version %d, but version %d is now current; propose again", proposalID, proposal.BaseVersion, current.Version)
+		}
+		var doc ParamsDocument
+		if err := json.Unmarshal([]byte(proposal.Payload), &doc); err != nil {
+			return nil, err
+		}
+		doc.Version = current.Version
+		if err := putParams(ctx, &doc, now.Unix(), proposal.ID); err != nil {
+			return nil, err
+		}
+	case KindGovernanceConfig:
+		if err := ctx.GetStub().PutState(governanceConfigKey, []byte(proposal.Payload)); err != nil {
+			return nil, err
+		}
+	}
+
+	proposal.Status = ProposalExecuted
+	proposal.ExecutedAt = now.Unix()
+	proposal.History = append(proposal.History, ProposalAction{Action: ActionExecuted, Actor: actor.ID, ActorMSP: actor.MSPID, Timestamp: now.Unix()})
+	if err := putProposal(ctx, proposal, ActionExecuted); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// GetProposal returns a proposal with its approvals and history
+func (c *GovernanceContract) GetProposal(ctx contractapi.TransactionContextInterface, proposalID string) (*Proposal, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := readProposal(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	proposal.refreshStatus(now)
+	return proposal, nil
+}
+
+// GetProposals lists proposals oldest first, optionally only those with the given status
+func (c *GovernanceContract) GetProposals(ctx contractapi.TransactionContextInterface, status string) ([]*Proposal, error) {
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := ctx.GetStub().GetStateByPartialCompositeKey(docTypeProposal, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	proposals := []*Proposal{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var proposal Proposal
+		if err := json.Unmarshal(kv.Value, &proposal); err != nil {
+			return nil, err
+		}
+		proposal.refreshStatus(now)
+		if status == "" || proposal.Status == status {
+			proposals = append(proposals, &proposal)
+		}
+	}
+	sort.SliceStable(proposals, func(i, j int) bool { return proposals[i].CreatedAt < proposals[j].CreatedAt })
+	return proposals, nil
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// txStub runs one transaction against the committed state of a MockStub, collecting its
+// writes so that, as on a peer, a failed transaction leaves nothing behind
+type txStub struct {
+	*shimtest.MockStub
+	fn     string
+	args   []string
+	writes map[string][]byte
+}
+
+func (s *txStub) GetFunctionAndParameters() (string, []string) {
+	return s.fn, s.args
+}
+
+func (s *txStub) GetArgs() [][]byte {
+	args := [][]byte{[]byte(s.fn)}
+	for _, arg := range s.args {
+		args = append(args, []byte(arg))
+	}
+	return args
+}
+
+func (s *txStub) GetStringArgs() []string {
+	return append([]string{s.fn}, s.args...)
+}
+
+func (s *txStub) PutState(key string, value []byte) error {
+	s.writes[key] = value
+	return nil
+}
+
+// identity is a client certificate with Fabric CA attributes
+type identity struct {
+	creator []byte
+	id      string
+}
+
+type ledger struct {
+	t       *testing.T
+	cc      *contractapi.ContractChaincode
+	stub    *shimtest.MockStub
+	clock   time.Time // Transaction timestamps, advanced one minute per transaction
+	txCount int
+}
+
+func newLedger(t *testing.T) *ledger {
+	t.Helper()
+	cc, err := contractapi.NewChaincode(&GovernanceContract{})
+	if err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+	return &ledger{
+		t:     t,
+		cc:    cc,
+		stub:  shimtest.NewMockStub("governance-cc", cc),
+		clock: time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func newIdentity(t *testing.T, mspID string, name string, attrs map[string]string) identity {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrsJSON, _ := json.Marshal(map[string]map[string]string{"attrs": attrs})
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 2, 3, 4, 5, 6, 7, 8, 1}, Value: attrsJSON},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creator, err := proto.Marshal(&msp.SerializedIdentity{
+		Mspid:   mspID,
+		IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := shimtest.NewMockStub("identity", nil)
+	stub.Creator = creator
+	client, err := cid.New(stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := client.GetID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity{creator: creator, id: id}
+}
+
+// participants are the identities used across the governance tests
+type participants struct {
+	admin1, admin2, admin3 identity // Central Bank admins
+	operator               identity // Central Bank, without the admin role
+	regulator              identity
+}
+
+func newParticipants(t *testing.T) participants {
+	return participants{
+		admin1:    newIdentity(t, MSPCentralBank, "admin-1", map[string]string{AttrRole: RoleAdmin}),
+		admin2:    newIdentity(t, MSPCentralBank, "admin-2", map[string]string{AttrRole: RoleAdmin}),
+		admin3:    newIdentity(t, MSPCentralBank, "admin-3", map[string]string{AttrRole: RoleAdmin}),
+		operator:  newIdentity(t, MSPCentralBank, "operator", map[string]string{}),
+		regulator: newIdentity(t, MSPRegulator, "regulator", map[string]string{}),
+	}
+}
+
+// submit runs fn as caller, commits its writes if it succeeds and returns the response
+// payload. wantErr expects the transaction to fail.
+func (l *ledger) submit(caller identity, wantErr bool, fn string, args ...string) []byte {
+	l.t.Helper()
+	l.txCount++
+	l.clock = l.clock.Add(time.Minute)
+	stub := &txStub{MockStub: l.stub, fn: fn, args: args, writes: map[string][]byte{}}
+	l.stub.TxID = fmt.Sprintf("tx%04d", l.txCount)
+	l.stub.TxTimestamp = timestamppb.New(l.clock)
+	l.stub.Creator = caller.creator
+	response := l.cc.Invoke(stub)
+
+	if wantErr {
+		if response.Status == 200 {
+			l.t.Fatalf("%s: expected an error, got success", fn)
+		}
+		return nil
+	}
+	if response.Status != 200 {
+		l.t.Fatalf("%s failed: %s", fn, response.Message)
+	}
+	for key, value := range stub.writes {
+		l.stub.PutState(key, value)
+	}
+	return response.Payload
+}
+
+// propose opens a proposal and returns it
+func (l *ledger) propose(caller identity, kind string, payload any, expiresAt int64) Proposal {
+	l.t.Helper()
+	payloadBytes, _ := json.Marshal(payload)
+	var proposal Proposal
+	json.Unmarshal(l.submit(caller, false, "ProposeParamChange", kind, string(payloadBytes), "test change", "0", strconv.FormatInt(expiresAt, 10)), &proposal)
+	return proposal
+}
+
+// act runs a proposal transaction that must succeed and returns the proposal
+func (l *ledger) act(caller identity, fn string, args ...string) Proposal {
+	l.t.Helper()
+	var proposal Proposal
+	json.Unmarshal(l.submit(caller, false, fn, args...), &proposal)
+	return proposal
+}
+
+func (l *ledger) params() ParamsDocument {
+	l.t.Helper()
+	var doc ParamsDocument
+	json.Unmarshal(l.submit(l.anyone(), false, "GetParams"), &doc)
+	return doc
+}
+
+// anyone is an identity for queries, which are open to every channel member
+func (l *ledger) anyone() identity {
+	return newIdentity(l.t, "BankConsortiumMSP", "reader", map[string]string{})
+}
+
+// withLimit returns the default document with a different global transaction limit
+func withLimit(limit int64) ParamsDocument {
+	doc := params.Default()
+	doc.MaxTransactionLimit = limit
+	return doc
+}
+
+func TestProposalQuorum(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	payload, _ := json.Marshal(withLimit(2000000))
+	l.submit(p.operator, true, "ProposeParamChange", KindParams, string(payload), "raise the limit", "0", "0")
+	l.submit(p.regulator, true, "ProposeParamChange", KindParams, string(payload), "raise the limit", "0", "0")
+
+	proposal := l.propose(p.admin1, KindParams, withLimit(2000000), 0)
+	if proposal.Status != ProposalPending || len(proposal.Approvals) != 1 {
+		t.Fatalf("proposed %s with %d approvals", proposal.Status, len(proposal.Approvals))
+	}
+	l.submit(p.admin1, true, "ExecuteProposal", proposal.ID) // 1 of 2 approvals
+	l.submit(p.admin1, true, "ApproveProposal", proposal.ID) // The proposer's approval already counts
+	l.submit(p.operator, true, "ApproveProposal", proposal.ID)
+	l.submit(p.regulator, true, "ApproveProposal", proposal.ID) // The default config needs no co-sign
+
+	if proposal = l.act(p.admin2, "ApproveProposal", proposal.ID); proposal.Status != ProposalApproved {
+		t.Fatalf("status after quorum = %s", proposal.Status)
+	}
+	l.submit(p.operator, true, "ExecuteProposal", proposal.ID)
+	if proposal = l.act(p.admin3, "ExecuteProposal", proposal.ID); proposal.Status != ProposalExecuted {
+		t.Fatalf("status after execution = %s", proposal.Status)
+	}
+	// A proposal is executed once
+	l.submit(p.admin1, true, "ExecuteProposal", proposal.ID)
+	l.submit(p.admin3, true, "ApproveProposal", proposal.ID)
+
+	doc := l.params()
+	if doc.Version != 1 || doc.ProposalID != proposal.ID || doc.MaxTransactionLimit != 2000000 {
+		t.Errorf("params = version %d from %q, limit %d", doc.Version, doc.ProposalID, doc.MaxTransactionLimit)
+	}
+	var actions []string
+	for _, action := range proposal.History {
+		actions = append(actions, action.Action)
+	}
+	if fmt.Sprint(actions) != fmt.Sprint([]string{ActionProposed, ActionApproved, ActionExecuted}) {
+		t.Errorf("history = %v", actions)
+	}
+}
+
+func TestProposalRegulatorCoSign(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	config := GovernanceConfig{Quorum: 2, RequireRegulator: true, Approvers: []string{p.admin1.id, p.admin2.id}}
+	adoption := l.propose(p.admin1, KindGovernanceConfig, config, 0)
+	l.act(p.admin2, "ApproveProposal", adoption.ID)
+	l.act(p.admin1, "ExecuteProposal", adoption.ID)
+
+	// admin3 is not a named approver under the adopted config
+	payload, _ := json.Marshal(withLimit(3000000))
+	l.submit(p.admin3, true, "ProposeParamChange", KindParams, string(payload), "raise the limit", "0", "0")
+
+	proposal := l.propose(p.admin1, KindParams, withLimit(3000000), 0)
+	if !proposal.RequireRegulator {
+		t.Fatal("proposal did not copy the co-sign requirement")
+	}
+	l.submit(p.admin3, true, "ApproveProposal", proposal.ID)
+	l.submit(p.admin3, true, "RejectProposal", proposal.ID, "veto") // Nor can admin3 veto it alone
+	if proposal = l.act(p.admin2, "ApproveProposal", proposal.ID); proposal.Status != ProposalPending {
+		t.Fatalf("status without co-sign = %s", proposal.Status)
+	}
+	l.submit(p.admin1, true, "ExecuteProposal", proposal.ID)
+
+	if proposal = l.act(p.regulator, "ApproveProposal", proposal.ID); proposal.Status != ProposalApproved || !proposal.RegulatorApproved {
+		t.Fatalf("status after co-sign = %s", proposal.Status)
+	}
+	l.submit(p.regulator, true, "ApproveProposal", proposal.ID)
+	l.act(p.admin1, "ExecuteProposal", proposal.ID)
+	if doc := l.params(); doc.MaxTransactionLimit != 3000000 {
+		t.Errorf("limit = %d", doc.MaxTransactionLimit)
+	}
+}
+
+func TestProposalBaseVersion(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+	l.submit(p.admin1, false, "InitLedger")
+
+	first := l.propose(p.admin1, KindParams, withLimit(2000000), 0)
+	second := l.propose(p.admin2, KindParams, withLimit(4000000), 0)
+	if first.BaseVersion != 1 || second.BaseVersion != 1 {
+		t.Fatalf("base versions = %d, %d", first.BaseVersion, second.BaseVersion)
+	}
+	l.act(p.admin2, "ApproveProposal", first.ID)
+	l.act(p.admin1, "ApproveProposal", second.ID)
+	l.act(p.admin1, "ExecuteProposal", first.ID)
+
+	// The second was drafted against version 1 and would undo the first
+	l.submit(p.admin1, true, "ExecuteProposal", second.ID)
+	if doc := l.params(); doc.Version != 2 || doc.MaxTransactionLimit != 2000000 {
+		t.Errorf("params = version %d, limit %d", doc.Version, doc.MaxTransactionLimit)
+	}
+
+	var history []*ParamsDocument
+	json.Unmarshal(l.submit(l.anyone(), false, "GetParamsHistory"), &history)
+	if len(history) != 2 || history[0].ProposalID != "" || history[1].ProposalID != first.ID {
+		t.Errorf("history has %d versions", len(history))
+	}
+}
+
+func TestProposalExpiry(t *testing.T) {
+	t.Parallel()
+	p := newParticipants(t)
+	l := newLedger(t)
+
+	proposal := l.propose(p.admin1, KindParams, withLimit(2000000), l.clock.Add(time.Hour).Unix())
+	l.clock = l.clock.Add(2 * time.Hour)
+
+	// The first write after the expiry records it rather than failing, so it is kept
+	if expired := l.act(p.admin2, "ApproveProposal", proposal.ID); expired.Status != ProposalExpired || len(expired.Approvals) != 1 {
+		t.Fatalf("approval after expiry = %s with %d approvals", expired.Status, len(expired.Approvals))
+	}
+	var stored Proposal
+	json.Unmarshal(l.submit(l.anyone(), false, "GetProposal", proposal.ID), &stored)
+	last := stored.History[len(stored.History)-1]
+	if stored.Status != ProposalExpired || last.Action != ActionExpired || last.Actor != p.admin2.id {
+		t.Errorf("stored proposal = %s, last action %s by %s", stored.Status, last.Action, last.Actor)
+	}
+
+	// Once recorded, an expired proposal is closed like any other
+	l.submit(p.admin2, true, "ApproveProposal", proposal.ID)
+	l.submit(p.admin1, true, "ExecuteProposal", proposal.ID)
+	l.submit(p.admin1, true, "RejectProposal", proposal.ID, "too late")
+	if doc := l.params(); doc.Version != 0 {
+		t.Errorf("params version = %d", doc.Version)
+	}
+}
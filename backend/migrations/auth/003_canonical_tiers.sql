@@ -0,0 +1,7 @@
+-- Use the chaincode tier names (Tier0, Tier1, Tier2) instead of TIER_1/2/3
+UPDATE wallet_db.users SET tier = CASE tier
+    WHEN 'TIER_1' THEN 'Tier0'
+    WHEN 'TIER_2' THEN 'Tier1'
+    WHEN 'TIER_3' THEN 'Tier2'
+    ELSE tier
+END;
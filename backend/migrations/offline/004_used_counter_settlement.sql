@@ -0,0 +1,5 @@
+-- Track the on-chain outcome of each offline proof reported by BatchReconcile
+ALTER TABLE offline_db.used_counters ADD COLUMN IF NOT EXISTS status VARCHAR(50) DEFAULT 'PENDING'; -- PENDING, SETTLED, DISPUTED
+ALTER TABLE offline_db.used_counters ADD COLUMN IF NOT EXISTS reason VARCHAR(50); -- Chaincode reason code when disputed
+ALTER TABLE offline_db.used_counters ADD COLUMN IF NOT EXISTS fabric_tx_id VARCHAR(255);
+ALTER TABLE offline_db.used_counters ADD COLUMN IF NOT EXISTS settled_at TIMESTAMP WITH TIME ZONE;
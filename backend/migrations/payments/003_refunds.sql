@@ -0,0 +1,3 @@
+-- Refunds and chargebacks are recorded as their own rows linked to the original payment
+ALTER TABLE payments_db.transactions ADD COLUMN IF NOT EXISTS original_id VARCHAR(255) REFERENCES payments_db.transactions(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_original_id ON payments_db.transactions(original_id);
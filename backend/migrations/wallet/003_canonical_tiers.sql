@@ -0,0 +1,9 @@
+-- Use the chaincode tier names (Tier0, Tier1, Tier2) instead of TIER_1/2/3
+UPDATE wallet_db.wallets SET tier_level = CASE tier_level
+    WHEN 'TIER_1' THEN 'Tier0'
+    WHEN 'TIER_2' THEN 'Tier1'
+    WHEN 'TIER_3' THEN 'Tier2'
+    ELSE tier_level
+END;
+ALTER TABLE wallet_db.wallets ALTER COLUMN tier_level SET DEFAULT 'Tier0';
+ALTER TABLE wallet_db.wallets ALTER COLUMN daily_limit SET DEFAULT 10000;
@@ -0,0 +1,13 @@
+-- Wallet IDs are pseudonymous addresses derived from the owner's public key
+ALTER TABLE wallet_db.wallets ADD COLUMN IF NOT EXISTS public_key VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_address ON wallet_db.wallets(address);
+
+-- Aliases (phone numbers, emails) resolve to addresses here and are never written on-chain
+CREATE TABLE IF NOT EXISTS wallet_db.wallet_aliases (
+    alias_type VARCHAR(20) NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    address VARCHAR(255) NOT NULL REFERENCES wallet_db.wallets(address),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (alias_type, alias)
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_aliases_address ON wallet_db.wallet_aliases(address);
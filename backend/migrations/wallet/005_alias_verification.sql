@@ -0,0 +1,12 @@
+-- Codes sent to a phone number or email before it can be linked as a wallet alias
+CREATE TABLE IF NOT EXISTS wallet_db.alias_verifications (
+    alias_type VARCHAR(20) NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL REFERENCES wallet_db.users(id),
+    wallet_id VARCHAR(255) NOT NULL REFERENCES wallet_db.wallets(id),
+    code_hash VARCHAR(64) NOT NULL, -- SHA-256 of type, alias and code
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (alias_type, alias, user_id)
+);
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// IdempotencyKeyHeader lets clients make a write safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyKey returns the client's Idempotency-Key header, or a fresh random key
+// so that the service's own retries of this request are still deduplicated on chain
+func IdempotencyKey(r *http.Request) string {
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+		return key
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
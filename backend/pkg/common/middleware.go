@@ -1,12 +1,32 @@
 package common
 
 import (
+	"context"
+	"crypto/subtle"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Claims are the auth-service token claims of the authenticated user
+type Claims struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Tier     string `json:"tier"`
+	jwt.RegisteredClaims
+}
+
+type claimsKey struct{}
+
+// ClaimsFrom returns the claims AuthMiddleware injected into the request context
+func ClaimsFrom(r *http.Request) (*Claims, bool) {
+	claims, ok := r.Context().Value(claimsKey{}).(*Claims)
+	return claims, ok && claims.UserID != ""
+}
+
 // AuthMiddleware verifies the JWT token and extracts claims
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -19,7 +39,8 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
 
 		// Parse token (using the same secret as Auth Service - in prod use public key)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		claims := &Claims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 			return []byte("super-secret-key-change-me"), nil
 		})
 
@@ -28,25 +49,47 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// TODO: Inject claims into context
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+	})
+}
+
+// InternalTokenHeader carries the shared secret backend services present on internal routes
+const InternalTokenHeader = "X-Internal-Token"
+
+// InternalToken returns the service-to-service secret configured in INTERNAL_API_TOKEN
+func InternalToken() string {
+	return os.Getenv("INTERNAL_API_TOKEN")
+}
+
+// InternalMiddleware admits only backend services presenting InternalToken. Every request
+// is refused while the token is unset.
+func InternalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := InternalToken()
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(InternalTokenHeader)), []byte(token)) != 1 {
+			http.Error(w, "Internal token required", http.StatusUnauthorized)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RequireRole enforces RBAC
+// User roles issued by the auth-service
+const (
+	RoleCitizen  = "CITIZEN"
+	RoleMerchant = "MERCHANT"
+	RoleAdmin    = "ADMIN" // Intermediary operators
+)
+
+// RequireRole enforces RBAC on a handler wrapped in AuthMiddleware, refusing callers
+// whose token does not carry role
 func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// In a real implementation, we would extract the role from the context
-		// set by the AuthMiddleware.
-		// For now, we assume the middleware passed and we re-parse or rely on context.
-
-		// Mock check:
-		// claims := r.Context().Value("claims").(Claims)
-		// if claims.Role != role {
-		//     http.Error(w, "Forbidden", http.StatusForbidden)
-		//     return
-		// }
-
+		claims, ok := ClaimsFrom(r)
+		if !ok || claims.Role != role {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
 		next(w, r)
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/hyperledger/fabric-sdk-go/pkg/core/config"
 	"github.com/hyperledger/fabric-sdk-go/pkg/gateway"
@@ -54,10 +55,62 @@ func (c *Client) SubmitTransaction(name string, args ...string) ([]byte, error)
 	return c.contract.SubmitTransaction(name, args...)
 }
 
+// SubmitTransactionWithTransient submits a transaction carrying private inputs in the
+// transient map, which is passed to endorsers but never written to the ledger
+func (c *Client) SubmitTransactionWithTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	txn, err := c.contract.CreateTransaction(name, gateway.WithTransient(transient))
+	if err != nil {
+		return nil, err
+	}
+	return txn.Submit(args...)
+}
+
+// submitAttempts bounds SubmitIdempotent retries
+const submitAttempts = 3
+
+// SubmitIdempotent submits a transaction carrying a client idempotency key, retrying
+// on failure. Issue, Redeem and the transfer functions remember the key on the ledger,
+// so a retry of a transaction that did commit returns the original result instead of
+// moving funds again.
+func (c *Client) SubmitIdempotent(name string, key string, args ...string) ([]byte, error) {
+	return c.SubmitIdempotentWithTransient(name, key, nil, args...)
+}
+
+// SubmitIdempotentWithTransient is SubmitIdempotent with further transient entries, such
+// as the wallet owner's authorization of a transfer
+func (c *Client) SubmitIdempotentWithTransient(name string, key string, extra map[string][]byte, args ...string) ([]byte, error) {
+	transient := map[string][]byte{"idempotency_key": []byte(key)}
+	for k, v := range extra {
+		transient[k] = v
+	}
+	var err error
+	for attempt := 1; attempt <= submitAttempts; attempt++ {
+		var result []byte
+		result, err = c.SubmitTransactionWithTransient(name, transient, args...)
+		if err == nil {
+			return result, nil
+		}
+		if attempt < submitAttempts {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+	}
+	return nil, err
+}
+
 func (c *Client) EvaluateTransaction(name string, args ...string) ([]byte, error) {
 	return c.contract.EvaluateTransaction(name, args...)
 }
 
+// EvaluateTransactionWithTransient queries with private inputs in the transient map,
+// such as values disclosed for hash verification
+func (c *Client) EvaluateTransactionWithTransient(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	txn, err := c.contract.CreateTransaction(name, gateway.WithTransient(transient))
+	if err != nil {
+		return nil, err
+	}
+	return txn.Evaluate(args...)
+}
+
 func (c *Client) RegisterChaincodeEventListener(eventName string) (<-chan *gateway.ChaincodeEvent, error) {
 	reg, notifier, err := c.contract.RegisterEvent(eventName)
 	if err != nil {
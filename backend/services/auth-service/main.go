@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
 	"github.com/centralbank/cbdc/backend/pkg/common"
 	"github.com/centralbank/cbdc/backend/pkg/common/api"
 	"github.com/centralbank/cbdc/backend/pkg/common/db"
@@ -44,7 +45,7 @@ func (s *Service) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		INSERT INTO wallet_db.users (
 			id, username, password_hash, full_name, email, phone_number, bvn, nin, tier, role, status
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
-		userID, req.Username, string(hashedPassword), req.FullName, req.Email, req.PhoneNumber, req.BVN, req.NIN, "TIER_1", "CITIZEN", "ACTIVE")
+		userID, req.Username, string(hashedPassword), req.FullName, req.Email, req.PhoneNumber, req.BVN, req.NIN, tiers.Default, "CITIZEN", "ACTIVE")
 
 	if err != nil {
 		log.Printf("Failed to register user: %v", err)
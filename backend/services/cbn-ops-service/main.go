@@ -3,10 +3,14 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
 	"github.com/centralbank/cbdc/backend/pkg/common"
 	"github.com/centralbank/cbdc/backend/pkg/common/api"
 	"github.com/centralbank/cbdc/backend/pkg/common/db"
@@ -17,13 +21,15 @@ import (
 // Service represents the CBN Operations Service
 // As per Phase 3 design: Backend for the Central Bank Operations Console
 type Service struct {
-	db     *sql.DB
-	fabric *fabricclient.Client
+	db         *sql.DB
+	fabric     *fabricclient.Client
+	governance *fabricclient.Client // governance-cc: params and proposals
 }
 
 // IssuanceRequest represents a request to mint new CBDC
 type IssuanceRequest struct {
 	Amount         int64  `json:"amount"`
+	Asset          string `json:"asset"` // Optional asset code, default NGN
 	ToIntermediaryID string `json:"to_intermediary_id"`
 	Reason         string `json:"reason"`
 	ApprovedBy     string `json:"approved_by"`
@@ -32,17 +38,59 @@ type IssuanceRequest struct {
 // RedemptionRequest represents a request to burn CBDC
 type RedemptionRequest struct {
 	Amount           int64  `json:"amount"`
+	Asset            string `json:"asset"` // Optional asset code, default NGN
 	FromIntermediaryID string `json:"from_intermediary_id"`
 	Reason           string `json:"reason"`
 	ApprovedBy       string `json:"approved_by"`
 }
 
+// AssetRequest registers an asset that wallets can hold, e.g. for a cross-border corridor
+type AssetRequest struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Decimals  int    `json:"decimals"`
+	IssuerMSP string `json:"issuer_msp"` // MSP whose admins may issue and redeem the asset
+}
+
 // FreezeRequest represents a request to freeze a wallet
 type FreezeRequest struct {
+	WalletID   string `json:"wallet_id"`
+	ReasonCode string `json:"reason_code"` // Recorded on-chain, e.g. COURT_ORDER, RESOLVED
+	Reason     string `json:"reason"`
+}
+
+// LienRequest represents a request to freeze a specific amount of a wallet
+type LienRequest struct {
 	WalletID string `json:"wallet_id"`
+	Amount   int64  `json:"amount"`
+	CaseRef  string `json:"case_ref"`
 	Reason   string `json:"reason"`
 }
 
+// PauseRequest represents a request to pause or unpause transfers, offline reconciliation and redemptions
+type PauseRequest struct {
+	Scope  string `json:"scope"`  // ALL (default), INTERMEDIARY or OPERATION
+	Target string `json:"target"` // Intermediary ID, or Transfer / OfflineReconcile / Redeem
+	Reason string `json:"reason"`
+}
+
+// VerifyAttributeRequest carries a wallet attribute disclosed off-chain, checked against
+// the hash of the private record without reading it
+type VerifyAttributeRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Salt  string `json:"salt"`
+}
+
+// ProposalRequest proposes a governance change, approved by a quorum of Central Bank admins
+type ProposalRequest struct {
+	Kind           string          `json:"kind"`    // PARAMS or GOVERNANCE_CONFIG
+	Payload        json.RawMessage `json:"payload"` // The new parameter document or governance config
+	Description    string          `json:"description"`
+	ActivationTime int64           `json:"activation_time"` // Unix seconds; 0 to apply once approved
+	ExpiresAt      int64           `json:"expires_at"`      // Unix seconds; 0 for seven days after activation
+}
+
 // IntermediaryStatus represents the status of an intermediary
 type IntermediaryStatus struct {
 	ID            string    `json:"id"`
@@ -90,33 +138,75 @@ func main() {
 		defer fabric.Close()
 	}
 
-	svc := &Service{db: database, fabric: fabric}
+	// Governance chaincode on the same channel
+	var governance *fabricclient.Client
+	governance, err = fabricclient.NewClient(
+		cfg.FabricConfig,
+		"cbdc-main-channel",
+		"governance-cc",
+		cfg.MSP,
+		cfg.CertPath,
+		cfg.KeyPath,
+	)
+	if err != nil {
+		log.Printf("Warning: governance connection failed: %v", err)
+	} else {
+		defer governance.Close()
+	}
+
+	svc := &Service{db: database, fabric: fabric, governance: governance}
 
 	r := mux.NewRouter()
 
 	// Dashboard & Analytics
 	r.HandleFunc("/ops/dashboard", svc.DashboardHandler).Methods("GET")
 	r.HandleFunc("/ops/supply", svc.GetTotalSupplyHandler).Methods("GET")
+	r.HandleFunc("/ops/supply/history", svc.GetSupplyHistoryHandler).Methods("GET")
 
 	// Issuance & Redemption (Mint/Burn)
 	r.HandleFunc("/ops/issue", svc.IssueHandler).Methods("POST")
 	r.HandleFunc("/ops/redeem", svc.RedeemHandler).Methods("POST")
 
+	// Asset Registry (multi-currency)
+	r.HandleFunc("/ops/assets", svc.RegisterAssetHandler).Methods("POST")
+	r.HandleFunc("/ops/assets", svc.ListAssetsHandler).Methods("GET")
+	r.HandleFunc("/ops/assets/{code}/supply", svc.GetAssetSupplyHandler).Methods("GET")
+
 	// Wallet Management
 	r.HandleFunc("/ops/freeze", svc.FreezeWalletHandler).Methods("POST")
 	r.HandleFunc("/ops/unfreeze", svc.UnfreezeWalletHandler).Methods("POST")
+	r.HandleFunc("/ops/liens", svc.PlaceLienHandler).Methods("POST")
+	r.HandleFunc("/ops/liens/lift", svc.LiftLienHandler).Methods("POST")
+	r.HandleFunc("/ops/liens/{wallet_id}/{case_ref}", svc.GetLienHandler).Methods("GET")
+
+	// Emergency Circuit Breaker
+	r.HandleFunc("/ops/pause", svc.GetPausesHandler).Methods("GET")
+	r.HandleFunc("/ops/pause", svc.PauseHandler).Methods("POST")
+	r.HandleFunc("/ops/unpause", svc.UnpauseHandler).Methods("POST")
+	r.HandleFunc("/ops/pause/history", svc.GetPauseHistoryHandler).Methods("GET")
 
 	// Intermediary Management
 	r.HandleFunc("/ops/intermediaries", svc.ListIntermediariesHandler).Methods("GET")
 	r.HandleFunc("/ops/intermediaries/{id}", svc.GetIntermediaryHandler).Methods("GET")
+	r.HandleFunc("/ops/intermediaries/{id}/position", svc.GetPositionHandler).Methods("GET")
+	r.HandleFunc("/ops/intermediaries/{id}/position", svc.RecordPositionHandler).Methods("POST")
+	r.HandleFunc("/ops/wallets/{id}/verify-attribute", svc.VerifyAttributeHandler).Methods("POST")
 
 	// Governance
 	r.HandleFunc("/ops/params", svc.GetGovernanceParamsHandler).Methods("GET")
 	r.HandleFunc("/ops/params", svc.UpdateGovernanceParamsHandler).Methods("PUT")
+	r.HandleFunc("/ops/params/history", svc.GetParamsHistoryHandler).Methods("GET")
+	r.HandleFunc("/ops/governance/proposals", svc.ListProposalsHandler).Methods("GET")
+	r.HandleFunc("/ops/governance/proposals", svc.ProposeHandler).Methods("POST")
+	r.HandleFunc("/ops/governance/proposals/{id}", svc.GetProposalHandler).Methods("GET")
+	// Approving, rejecting and executing need each admin's own identity, so they are made
+	// with infra/fabric/scripts/governance.sh rather than through this service
 
 	// Audit & Compliance
 	r.HandleFunc("/ops/audit/transactions", svc.AuditTransactionsHandler).Methods("GET")
 	r.HandleFunc("/ops/audit/wallets", svc.AuditWalletsHandler).Methods("GET")
+	r.HandleFunc("/ops/audit/wallets/{id}/ledger", svc.AuditWalletLedgerHandler).Methods("GET")
+	r.HandleFunc("/ops/audit/wallets/{id}/status-history", svc.AuditWalletStatusHandler).Methods("GET")
 
 	// Health
 	r.HandleFunc("/health", svc.HealthHandler).Methods("GET")
@@ -186,6 +276,26 @@ func (s *Service) GetTotalSupplyHandler(w http.ResponseWriter, r *http.Request)
 	api.WriteSuccess(w, http.StatusOK, map[string]int64{"total_supply": totalSupply})
 }
 
+// GetSupplyHistoryHandler returns the on-chain per-day mint/burn ledger for reconciliation reports
+func (s *Service) GetSupplyHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	// Optional YYYY-MM-DD bounds
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+
+	result, err := s.fabric.EvaluateTransaction("GetSupplyHistory", from, to)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
 // IssueHandler mints new CBDC to an intermediary
 func (s *Service) IssueHandler(w http.ResponseWriter, r *http.Request) {
 	var req IssuanceRequest
@@ -205,19 +315,21 @@ func (s *Service) IssueHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	walletID := "wallet-" + req.ToIntermediaryID
-	_, err := s.fabric.SubmitTransaction("Issue", string(rune(req.Amount)), walletID)
+	walletID := addresses.ForIntermediary(req.ToIntermediaryID)
+	req.Asset = assets.OrDefault(req.Asset)
+	_, err := s.fabric.SubmitIdempotent("IssueAsset", api.IdempotencyKey(r), req.Asset, fmt.Sprintf("%d", req.Amount), walletID)
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
 		return
 	}
 
 	// Log the issuance
-	log.Printf("CBDC Issued: %d to %s by %s - Reason: %s", req.Amount, req.ToIntermediaryID, req.ApprovedBy, req.Reason)
+	log.Printf("CBDC Issued: %d %s to %s by %s - Reason: %s", req.Amount, req.Asset, req.ToIntermediaryID, req.ApprovedBy, req.Reason)
 
 	api.WriteSuccess(w, http.StatusOK, map[string]interface{}{
 		"status":           "issued",
 		"amount":           req.Amount,
+		"asset":            req.Asset,
 		"to_intermediary":  req.ToIntermediaryID,
 		"timestamp":        time.Now(),
 	})
@@ -241,23 +353,92 @@ func (s *Service) RedeemHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	walletID := "wallet-" + req.FromIntermediaryID
-	_, err := s.fabric.SubmitTransaction("Redeem", string(rune(req.Amount)), walletID)
+	walletID := addresses.ForIntermediary(req.FromIntermediaryID)
+	req.Asset = assets.OrDefault(req.Asset)
+	_, err := s.fabric.SubmitIdempotent("RedeemAsset", api.IdempotencyKey(r), req.Asset, fmt.Sprintf("%d", req.Amount), walletID)
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
 		return
 	}
 
-	log.Printf("CBDC Redeemed: %d from %s by %s - Reason: %s", req.Amount, req.FromIntermediaryID, req.ApprovedBy, req.Reason)
+	log.Printf("CBDC Redeemed: %d %s from %s by %s - Reason: %s", req.Amount, req.Asset, req.FromIntermediaryID, req.ApprovedBy, req.Reason)
 
 	api.WriteSuccess(w, http.StatusOK, map[string]interface{}{
 		"status":             "redeemed",
 		"amount":             req.Amount,
+		"asset":              req.Asset,
 		"from_intermediary":  req.FromIntermediaryID,
 		"timestamp":          time.Now(),
 	})
 }
 
+// RegisterAssetHandler adds an asset to the on-chain registry
+func (s *Service) RegisterAssetHandler(w http.ResponseWriter, r *http.Request) {
+	var req AssetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+
+	if !assets.ValidCode(req.Code) {
+		api.WriteError(w, http.StatusBadRequest, "invalid_asset", "Asset code must be 3-12 upper-case letters or digits", "")
+		return
+	}
+	if req.IssuerMSP == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "issuer_msp is required", "")
+		return
+	}
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction("RegisterAsset", req.Code, req.Name, fmt.Sprintf("%d", req.Decimals), req.IssuerMSP)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	log.Printf("Asset Registered: %s (%s) issued by %s", req.Code, req.Name, req.IssuerMSP)
+
+	api.WriteSuccess(w, http.StatusCreated, json.RawMessage(result))
+}
+
+// ListAssetsHandler returns every asset wallets can hold, NGN first
+func (s *Service) ListAssetsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("ListAssets")
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetAssetSupplyHandler returns the issued, redeemed and outstanding totals of one asset
+func (s *Service) GetAssetSupplyHandler(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetAssetSupply", code)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "asset_not_found", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
 // FreezeWalletHandler freezes a wallet
 func (s *Service) FreezeWalletHandler(w http.ResponseWriter, r *http.Request) {
 	var req FreezeRequest
@@ -266,18 +447,23 @@ func (s *Service) FreezeWalletHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.ReasonCode == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "reason_code is required", "")
+		return
+	}
+
 	if s.fabric == nil {
 		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
 		return
 	}
 
-	_, err := s.fabric.SubmitTransaction("FreezeWallet", req.WalletID)
+	_, err := s.fabric.SubmitTransaction("FreezeWallet", req.WalletID, req.ReasonCode)
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
 		return
 	}
 
-	log.Printf("Wallet Frozen: %s - Reason: %s", req.WalletID, req.Reason)
+	log.Printf("Wallet Frozen: %s - Reason: %s (%s)", req.WalletID, req.ReasonCode, req.Reason)
 
 	api.WriteSuccess(w, http.StatusOK, map[string]interface{}{
 		"status":    "frozen",
@@ -294,18 +480,23 @@ func (s *Service) UnfreezeWalletHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.ReasonCode == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "reason_code is required", "")
+		return
+	}
+
 	if s.fabric == nil {
 		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
 		return
 	}
 
-	_, err := s.fabric.SubmitTransaction("UnfreezeWallet", req.WalletID)
+	_, err := s.fabric.SubmitTransaction("UnfreezeWallet", req.WalletID, req.ReasonCode)
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
 		return
 	}
 
-	log.Printf("Wallet Unfrozen: %s", req.WalletID)
+	log.Printf("Wallet Unfrozen: %s - Reason: %s", req.WalletID, req.ReasonCode)
 
 	api.WriteSuccess(w, http.StatusOK, map[string]interface{}{
 		"status":    "active",
@@ -314,6 +505,179 @@ func (s *Service) UnfreezeWalletHandler(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// PlaceLienHandler freezes an amount of a wallet under a case reference, leaving the rest usable
+func (s *Service) PlaceLienHandler(w http.ResponseWriter, r *http.Request) {
+	var req LienRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+
+	if req.Amount <= 0 {
+		api.WriteError(w, http.StatusBadRequest, "invalid_amount", "Amount must be positive", "")
+		return
+	}
+	if req.CaseRef == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "case_ref is required", "")
+		return
+	}
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction("PlaceLien", req.WalletID, fmt.Sprintf("%d", req.Amount), req.CaseRef)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	log.Printf("Lien Placed: %d on %s - Case: %s - Reason: %s", req.Amount, req.WalletID, req.CaseRef, req.Reason)
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// LiftLienHandler releases the funds held under a case reference
+func (s *Service) LiftLienHandler(w http.ResponseWriter, r *http.Request) {
+	var req LienRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction("LiftLien", req.WalletID, req.CaseRef)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	log.Printf("Lien Lifted: %s - Case: %s - Reason: %s", req.WalletID, req.CaseRef, req.Reason)
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetLienHandler returns the lien on a wallet for a case reference
+func (s *Service) GetLienHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetLien", vars["wallet_id"], vars["case_ref"])
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "lien_not_found", "Lien not found", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// PauseHandler halts transfers and offline reconciliation on-chain
+func (s *Service) PauseHandler(w http.ResponseWriter, r *http.Request) {
+	var req PauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = "ALL"
+	}
+	if req.Reason == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "reason is required", "")
+		return
+	}
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction("Pause", req.Scope, req.Target, req.Reason)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	log.Printf("PAUSED: %s %s - Reason: %s", req.Scope, req.Target, req.Reason)
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// UnpauseHandler lifts a pause
+func (s *Service) UnpauseHandler(w http.ResponseWriter, r *http.Request) {
+	var req PauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = "ALL"
+	}
+	if req.Reason == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "reason is required", "")
+		return
+	}
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	_, err := s.fabric.SubmitTransaction("Unpause", req.Scope, req.Target, req.Reason)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	log.Printf("UNPAUSED: %s %s - Reason: %s", req.Scope, req.Target, req.Reason)
+
+	api.WriteSuccess(w, http.StatusOK, map[string]string{
+		"status": "unpaused",
+		"scope":  req.Scope,
+		"target": req.Target,
+	})
+}
+
+// GetPausesHandler returns the active pauses
+func (s *Service) GetPausesHandler(w http.ResponseWriter, r *http.Request) {
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetPauses")
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetPauseHistoryHandler returns every pause and unpause with its reason and actor
+func (s *Service) GetPauseHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetPauseHistory")
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
 // ListIntermediariesHandler lists all registered intermediaries
 func (s *Service) ListIntermediariesHandler(w http.ResponseWriter, r *http.Request) {
 	// In production, fetch from DB or Fabric
@@ -344,54 +708,183 @@ func (s *Service) GetIntermediaryHandler(w http.ResponseWriter, r *http.Request)
 	api.WriteSuccess(w, http.StatusOK, intermediary)
 }
 
+// RecordPositionHandler snapshots an intermediary's wallet balances into private data
+func (s *Service) RecordPositionHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction("RecordIntermediaryPosition", id)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetPositionHandler returns the last recorded position of an intermediary
+func (s *Service) GetPositionHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetIntermediaryPosition", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "position_not_found", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// VerifyAttributeHandler checks a disclosed KYC attribute against the wallet's private record
+func (s *Service) VerifyAttributeHandler(w http.ResponseWriter, r *http.Request) {
+	walletID := mux.Vars(r)["id"]
+
+	var req VerifyAttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	if req.Name == "" || req.Value == "" || req.Salt == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "name, value and salt are required", "")
+		return
+	}
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	disclosed, _ := json.Marshal(map[string]string{"value": req.Value, "salt": req.Salt})
+	result, err := s.fabric.EvaluateTransactionWithTransient("VerifyWalletAttribute", map[string][]byte{"attribute": disclosed}, walletID, req.Name)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, map[string]interface{}{
+		"wallet_id": walletID,
+		"name":      req.Name,
+		"verified":  string(result) == "true",
+	})
+}
+
 // GetGovernanceParamsHandler returns the current governance parameters
 func (s *Service) GetGovernanceParamsHandler(w http.ResponseWriter, r *http.Request) {
-	if s.fabric == nil {
+	if s.governance == nil {
 		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
 		return
 	}
 
-	result, err := s.fabric.EvaluateTransaction("GetParams")
+	result, err := s.governance.EvaluateTransaction("GetParams")
 	if err != nil {
 		// Return defaults if not set
-		api.WriteSuccess(w, http.StatusOK, map[string]interface{}{
-			"max_transaction_limit": 1000000,
-			"min_transaction_limit": 1,
-			"fee_percentage":        0.001,
-			"tier0_daily_limit":     10000,
-			"tier1_daily_limit":     100000,
-			"tier2_daily_limit":     1000000,
-		})
+		api.WriteSuccess(w, http.StatusOK, params.Default())
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetParamsHistoryHandler returns every published version of the governance parameters
+func (s *Service) GetParamsHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if s.governance == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.governance.EvaluateTransaction("GetParamsHistory")
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
 		return
 	}
 
-	var params map[string]interface{}
-	json.Unmarshal(result, &params)
-	api.WriteSuccess(w, http.StatusOK, params)
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
 }
 
-// UpdateGovernanceParamsHandler updates governance parameters
+// UpdateGovernanceParamsHandler proposes the next version of the parameter document (tier
+// limits, offline caps, fee schedule). It applies only once the proposal is approved and executed.
 func (s *Service) UpdateGovernanceParamsHandler(w http.ResponseWriter, r *http.Request) {
-	var params map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+	var doc params.Document
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
 		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
 		return
 	}
 
-	if s.fabric == nil {
+	docJSON, _ := json.Marshal(doc)
+	s.propose(w, ProposalRequest{Kind: "PARAMS", Payload: docJSON, Description: "Update scheme parameters"})
+}
+
+// ProposeHandler opens a governance proposal as this service's identity, whose approval counts
+// towards the quorum. The other admins approve with their own identities.
+func (s *Service) ProposeHandler(w http.ResponseWriter, r *http.Request) {
+	var req ProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	if req.Kind == "" || len(req.Payload) == 0 || req.Description == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "kind, payload and description are required", "")
+		return
+	}
+	s.propose(w, req)
+}
+
+func (s *Service) propose(w http.ResponseWriter, req ProposalRequest) {
+	if s.governance == nil {
 		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
 		return
 	}
 
-	paramsJSON, _ := json.Marshal(params)
-	_, err := s.fabric.SubmitTransaction("UpdateParams", string(paramsJSON))
+	result, err := s.governance.SubmitTransaction("ProposeParamChange", req.Kind, string(req.Payload), req.Description,
+		fmt.Sprintf("%d", req.ActivationTime), fmt.Sprintf("%d", req.ExpiresAt))
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
 		return
 	}
 
-	log.Printf("Governance params updated: %v", params)
-	api.WriteSuccess(w, http.StatusOK, map[string]string{"status": "updated"})
+	log.Printf("Governance proposal opened: %s %s", req.Kind, req.Description)
+	api.WriteSuccess(w, http.StatusAccepted, json.RawMessage(result))
+}
+
+// ListProposalsHandler lists governance proposals, optionally filtered by ?status=
+func (s *Service) ListProposalsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.governance == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.governance.EvaluateTransaction("GetProposals", r.URL.Query().Get("status"))
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetProposalHandler returns a proposal with its approvals and history
+func (s *Service) GetProposalHandler(w http.ResponseWriter, r *http.Request) {
+	if s.governance == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.governance.EvaluateTransaction("GetProposal", mux.Vars(r)["id"])
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "proposal_not_found", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
 }
 
 // AuditTransactionsHandler returns transactions for audit purposes
@@ -472,3 +965,47 @@ func (s *Service) AuditWalletsHandler(w http.ResponseWriter, r *http.Request) {
 
 	api.WriteSuccess(w, http.StatusOK, wallets)
 }
+
+// AuditWalletLedgerHandler returns a wallet's on-chain transaction history so auditors
+// can verify payments_db.transactions against the ledger
+func (s *Service) AuditWalletLedgerHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	walletID := vars["id"]
+
+	pageSize := r.URL.Query().Get("page_size")
+	if pageSize == "" {
+		pageSize = "100"
+	}
+	bookmark := r.URL.Query().Get("bookmark")
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetWalletHistory", walletID, pageSize, bookmark)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// AuditWalletStatusHandler returns every lifecycle change of a wallet with its reason code and actor
+func (s *Service) AuditWalletStatusHandler(w http.ResponseWriter, r *http.Request) {
+	walletID := mux.Vars(r)["id"]
+
+	if s.fabric == nil {
+		api.WriteError(w, http.StatusServiceUnavailable, "fabric_unavailable", "Fabric not connected", "")
+		return
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetWalletStatusHistory", walletID)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "fabric_error", err.Error(), "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
@@ -9,9 +9,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"time"
 
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
 	"github.com/centralbank/cbdc/backend/pkg/common"
 	"github.com/centralbank/cbdc/backend/pkg/common/api"
 	"github.com/centralbank/cbdc/backend/pkg/common/db"
@@ -21,18 +26,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
-// Risk control constants from Phase 7 design
-const (
-	MaxOfflineBalance     = 500 // $500 max offline balance
-	MaxTransactionAmount  = 50  // $50 max per transaction
-	SyncTTLDays           = 7   // 7 days max before sync required
-)
+// paramsRefresh is how often the offline caps are re-read from governance-cc
+const paramsRefresh = time.Minute
 
 type Service struct {
 	db               *sql.DB
 	fabric           *fabricclient.Client
 	ganache          *GanacheClient
 	walletServiceURL string
+	params           *params.Cache // Risk controls from Phase 7: purse balance, payment and sync TTL caps
 }
 
 func main() {
@@ -66,6 +68,23 @@ func main() {
 		defer fabric.Close()
 	}
 
+	// Offline caps are governed in governance-cc; the Phase 7 defaults apply until it is reachable
+	var fetchParams func() ([]byte, error)
+	governance, err := fabricclient.NewClient(
+		cfg.FabricConfig,
+		"cbdc-main-channel",
+		"governance-cc",
+		cfg.MSP,
+		cfg.CertPath,
+		cfg.KeyPath,
+	)
+	if err != nil {
+		log.Printf("Warning: governance connection failed: %v", err)
+	} else {
+		defer governance.Close()
+		fetchParams = func() ([]byte, error) { return governance.EvaluateTransaction("GetParams") }
+	}
+
 	// Initialize Ganache client for offline voucher verification
 	ganacheURL := os.Getenv("GANACHE_URL")
 	if ganacheURL == "" {
@@ -84,6 +103,7 @@ func main() {
 		fabric:           fabric,
 		ganache:          ganache,
 		walletServiceURL: walletServiceURL,
+		params:           params.NewCache(fetchParams, paramsRefresh),
 	}
 
 	r := mux.NewRouter()
@@ -114,10 +134,16 @@ func (s *Service) RegisterDeviceHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	walletID, err := s.walletAddress(req.UserID)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "wallet_not_found", "User has no wallet", "")
+		return
+	}
+
 	// Store DeviceID <-> UserID mapping in DB
 	deviceID := "dev-" + req.PublicKey[:8] // Simplified ID
 
-	_, err := s.db.Exec(`
+	_, err = s.db.Exec(`
 		INSERT INTO offline_db.devices (
 			id, public_key, user_id, counter, hardware_id, os_version, trusted_status, last_sync_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
@@ -129,6 +155,33 @@ func (s *Service) RegisterDeviceHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Register the device key on-chain so the ledger can verify offline proofs. The
+	// wallet's owner authorizes binding the device to the wallet.
+	if s.fabric != nil {
+		limit := s.params.Get().Offline.MaxBalance
+		transient, err := s.authorize(r.Header.Get("Authorization"), req.Authorization, intents.Transfer{
+			From: walletID, Device: deviceID, Key: req.PublicKey, Asset: assets.Default, Amount: limit,
+		})
+		if err != nil {
+			log.Printf("Failed to authorize device registration: %v", err)
+			api.WriteError(w, http.StatusForbidden, "unauthorized", "Device was not authorized by the wallet owner", "")
+			return
+		}
+		deviceJSON, _ := json.Marshal(map[string]interface{}{
+			"device_id":  deviceID,
+			"wallet_id":  walletID,
+			"public_key": req.PublicKey,
+			"limit":      limit,
+		})
+		transient["device"] = deviceJSON
+		_, err = s.fabric.SubmitTransactionWithTransient("RegisterOfflineDevice", transient)
+		if err != nil {
+			log.Printf("Failed to register device on chain: %v", err)
+			api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to register device on chain", "")
+			return
+		}
+	}
+
 	api.WriteSuccess(w, http.StatusCreated, map[string]string{"status": "registered", "device_id": deviceID})
 }
 
@@ -147,21 +200,30 @@ func (s *Service) FundPurseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if currentBalance+req.Amount > MaxOfflineBalance {
+	maxBalance := s.params.Get().Offline.MaxBalance
+	if currentBalance+req.Amount > maxBalance {
 		api.WriteError(w, http.StatusBadRequest, "balance_limit_exceeded",
-			fmt.Sprintf("Would exceed max offline balance of %d", MaxOfflineBalance), "")
+			fmt.Sprintf("Would exceed max offline balance of %d", maxBalance), "")
 		return
 	}
 
-	// 1. Call Wallet Service to Lock Funds (Real HTTP Call)
+	// 1. Call Wallet Service to Lock Funds (Real HTTP Call) as the authenticated user
 	lockReq := map[string]interface{}{
-		"user_id": req.UserID,
-		"amount":  req.Amount,
-		"reason":  "offline_funding",
+		"device_id": req.DeviceID,
+		"amount":    req.Amount,
+		"reason":    "offline_funding",
 	}
 	lockBody, _ := json.Marshal(lockReq)
 
-	resp, err := http.Post(s.walletServiceURL+"/wallets/lock", "application/json", bytes.NewBuffer(lockBody))
+	lockHTTPReq, err := http.NewRequest(http.MethodPost, s.walletServiceURL+"/wallets/lock", bytes.NewBuffer(lockBody))
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to build wallet service request", "")
+		return
+	}
+	lockHTTPReq.Header.Set("Content-Type", "application/json")
+	lockHTTPReq.Header.Set("Authorization", r.Header.Get("Authorization"))
+
+	resp, err := http.DefaultClient.Do(lockHTTPReq)
 	if err != nil {
 		log.Printf("Failed to call wallet service: %v", err)
 		api.WriteError(w, http.StatusInternalServerError, "upstream_error", "Failed to contact wallet service", "")
@@ -240,7 +302,7 @@ func (s *Service) GetPurseHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if locked due to TTL
-	if time.Since(purse.LastSyncAt) > time.Duration(SyncTTLDays)*24*time.Hour {
+	if time.Since(purse.LastSyncAt) > s.params.Get().Offline.SyncTTL() {
 		purse.Status = "LOCKED_TTL"
 	}
 
@@ -270,13 +332,22 @@ func (s *Service) ReconcileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Submit Batch to Fabric using BatchReconcile
+	var settlement *models.BatchReconcileResult
 	if len(validProofs) > 0 && s.fabric != nil {
 		proofsJSON, _ := json.Marshal(validProofs)
 		log.Printf("Submitting batch to Fabric: %s", proofsJSON)
-		_, err := s.fabric.SubmitTransaction("BatchReconcile", string(proofsJSON))
+		result, err := s.fabric.SubmitTransaction("BatchReconcile", string(proofsJSON))
 		if err != nil {
 			log.Printf("Failed to submit batch to Fabric: %v", err)
 			// Queue for retry in production
+		} else {
+			settlement = &models.BatchReconcileResult{}
+			if err := json.Unmarshal(result, settlement); err != nil {
+				log.Printf("Failed to parse batch result: %v", err)
+				settlement = nil
+			} else {
+				s.recordSettlement(settlement)
+			}
 		}
 	}
 
@@ -289,9 +360,29 @@ func (s *Service) ReconcileHandler(w http.ResponseWriter, r *http.Request) {
 		"failed_count":   failedCount,
 		"batch_size":     len(validProofs),
 		"failed_reasons": failedReasons,
+		"settlement":     settlement,
 	})
 }
 
+// recordSettlement marks each used counter as settled or disputed according to the ledger's verdict
+func (s *Service) recordSettlement(result *models.BatchReconcileResult) {
+	for _, proof := range result.Results {
+		status := "SETTLED"
+		if proof.Status != "SETTLED" {
+			status = "DISPUTED"
+			log.Printf("Offline proof rejected on chain: device %s counter %d - %s (%s)", proof.DeviceID, proof.Nonce, proof.Reason, proof.Message)
+		}
+
+		_, err := s.db.Exec(`UPDATE offline_db.used_counters
+			SET status = $1, reason = $2, fabric_tx_id = $3, settled_at = $4
+			WHERE device_id = $5 AND counter = $6`,
+			status, proof.Reason, proof.TxID, time.Now(), proof.DeviceID, proof.Nonce)
+		if err != nil {
+			log.Printf("Failed to record settlement for device %s counter %d: %v", proof.DeviceID, proof.Nonce, err)
+		}
+	}
+}
+
 func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map[string]interface{}) string {
 	// 1. Verify Signature (Real Ed25519)
 	if tx.Signature == "" {
@@ -313,6 +404,23 @@ func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map
 		return fmt.Sprintf("invalid_signature:%s", tx.PayerID)
 	}
 
+	// 1b. The payee is named by its wallet address, the same one the device signed in the
+	// intent and the one the chaincode pays; the rest of the blob must match the intent too
+	payeeWalletID := tx.PayeeID
+	if err := addresses.Validate(payeeWalletID); err != nil {
+		log.Printf("Invalid payee for tx from %s: %v", tx.PayerID, err)
+		return fmt.Sprintf("invalid_payee:%s", tx.PayeeID)
+	}
+	var intent models.PaymentIntent
+	if err := json.Unmarshal([]byte(tx.Intent), &intent); err != nil || intent.PayeeID != payeeWalletID {
+		log.Printf("Signed intent from %s does not pay %s", tx.PayerID, tx.PayeeID)
+		return fmt.Sprintf("invalid_payee:%s", tx.PayeeID)
+	}
+	if intent.Amount != tx.Amount || intent.Counter != tx.Counter {
+		log.Printf("Signed intent from %s does not match amount %d counter %d", tx.PayerID, tx.Amount, tx.Counter)
+		return fmt.Sprintf("intent_mismatch:%s", tx.PayerID)
+	}
+
 	// Optional: Verify on Ganache (ecrecover equivalent)
 	if s.ganache != nil {
 		if err := s.ganache.VerifyOfflineTransaction(tx); err != nil {
@@ -322,13 +430,14 @@ func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map
 	}
 
 	// 2. Risk Controls
+	offline := s.params.Get().Offline
 	// 2a. Transaction Limit
-	if tx.Amount > MaxTransactionAmount {
-		log.Printf("Transaction amount %d exceeds limit %d", tx.Amount, MaxTransactionAmount)
+	if tx.Amount > offline.MaxTransaction {
+		log.Printf("Transaction amount %d exceeds limit %d", tx.Amount, offline.MaxTransaction)
 		return fmt.Sprintf("amount_exceeded:%d", tx.Amount)
 	}
 
-	// 2b. TTL Check (7 Days)
+	// 2b. TTL Check
 	var lastSyncAt time.Time
 	var currentBalance int64
 	err = s.db.QueryRow("SELECT balance, last_sync_at FROM offline_db.purses WHERE device_id = $1", tx.PayerID).
@@ -338,8 +447,8 @@ func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map
 		return fmt.Sprintf("purse_not_found:%s", tx.PayerID)
 	}
 
-	if time.Since(lastSyncAt) > time.Duration(SyncTTLDays)*24*time.Hour {
-		log.Printf("Device %s has not synced in %d days. Transaction rejected.", tx.PayerID, SyncTTLDays)
+	if time.Since(lastSyncAt) > offline.SyncTTL() {
+		log.Printf("Device %s has not synced in %d days. Transaction rejected.", tx.PayerID, offline.SyncTTLDays)
 		return fmt.Sprintf("ttl_expired:%s", tx.PayerID)
 	}
 
@@ -363,6 +472,15 @@ func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map
 		return fmt.Sprintf("double_spend:%s:%d", tx.PayerID, tx.Counter)
 	}
 
+	// 2e. Map DeviceID to wallet address
+	var payerUserID string
+	s.db.QueryRow("SELECT user_id FROM offline_db.devices WHERE id = $1", tx.PayerID).Scan(&payerUserID)
+	payerWalletID, err := s.walletAddress(payerUserID)
+	if err != nil {
+		log.Printf("No wallet for payer device %s: %v", tx.PayerID, err)
+		return fmt.Sprintf("wallet_not_found:%s", tx.PayerID)
+	}
+
 	// 3. Process Transaction
 	// Insert into used_counters
 	_, err = s.db.Exec("INSERT INTO offline_db.used_counters (device_id, counter, tx_hash, created_at) VALUES ($1, $2, $3, $4)",
@@ -378,29 +496,19 @@ func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map
 		log.Printf("Failed to debit shadow balance: %v", err)
 	}
 
-	// Map DeviceID to WalletID
-	var payerUserID string
-	s.db.QueryRow("SELECT user_id FROM offline_db.devices WHERE id = $1", tx.PayerID).Scan(&payerUserID)
-	payerWalletID := "wallet-" + payerUserID
-
-	var payeeUserID string
-	err = s.db.QueryRow("SELECT user_id FROM offline_db.devices WHERE id = $1", tx.PayeeID).Scan(&payeeUserID)
-	if err != nil {
-		payeeUserID = tx.PayeeID // Fallback if PayeeID is already a UserID
-	}
-	payeeWalletID := "wallet-" + payeeUserID
-
 	proof := map[string]interface{}{
+		"device_id": tx.PayerID,
 		"from":      payerWalletID,
 		"to":        payeeWalletID,
 		"amount":    tx.Amount,
 		"nonce":     tx.Counter,
 		"signature": tx.Signature,
+		"intent":    tx.Intent,
 	}
 	*validProofs = append(*validProofs, proof)
 
 	// Credit payee's wallet in local DB (optimistic)
-	_, err = s.db.Exec("UPDATE wallet_db.wallets SET balance = balance + $1 WHERE id = $2", tx.Amount, payeeWalletID)
+	_, err = s.db.Exec("UPDATE wallet_db.wallets SET balance = balance + $1 WHERE address = $2", tx.Amount, payeeWalletID)
 	if err != nil {
 		log.Printf("Failed to update local wallet balance: %v", err)
 	}
@@ -408,6 +516,62 @@ func (s *Service) processTransaction(tx models.SignedPayment, validProofs *[]map
 	return "" // Success
 }
 
+// walletAddress returns the on-chain address of a user's wallet. Addresses are derived
+// from owner keys and owned by the wallet-service, so they are resolved through its
+// internal route with the shared service token.
+func (s *Service) walletAddress(userID string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, s.walletServiceURL+"/internal/users/"+url.PathEscape(userID)+"/wallet", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(common.InternalTokenHeader, common.InternalToken())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to contact wallet service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("wallet service returned %d", resp.StatusCode)
+	}
+	var wallet struct {
+		Address string `json:"address"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wallet); err != nil {
+		return "", fmt.Errorf("invalid wallet service response: %v", err)
+	}
+	return wallet.Address, nil
+}
+
+// authorize returns the transient owner authorization of intent: the signature supplied by
+// a self-custody client, or one made by wallet-service with the custodial key for the
+// owner whose bearer token is forwarded
+func (s *Service) authorize(bearer string, supplied *intents.Authorization, intent intents.Transfer) (map[string][]byte, error) {
+	if supplied == nil {
+		body, _ := json.Marshal(intent)
+		req, err := http.NewRequest(http.MethodPost, s.walletServiceURL+"/wallets/"+url.PathEscape(intent.From)+"/authorize", bytes.NewBuffer(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearer)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to contact wallet service: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("wallet service did not authorize %s: status %d", intent.From, resp.StatusCode)
+		}
+		supplied = &intents.Authorization{}
+		if err := json.NewDecoder(resp.Body).Decode(supplied); err != nil {
+			return nil, err
+		}
+	}
+	authorization, _ := json.Marshal(supplied)
+	return map[string][]byte{intents.TransientKey: authorization}, nil
+}
+
 func verifySignature(tx models.SignedPayment, pubKeyHex string) bool {
 	pubKey, err := hex.DecodeString(pubKeyHex)
 	if err != nil || len(pubKey) != ed25519.PublicKeySize {
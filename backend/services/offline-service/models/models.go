@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
 
 type Device struct {
 	ID            string    `json:"id"`
@@ -28,6 +32,10 @@ type RegisterDeviceRequest struct {
 	PublicKey  string `json:"public_key"`
 	HardwareID string `json:"hardware_id"`
 	OSVersion  string `json:"os_version"`
+
+	// Authorization is the owner's signature binding the device for self-custody
+	// wallets; custodial wallets are signed by wallet-service
+	Authorization *intents.Authorization `json:"authorization,omitempty"`
 }
 
 // OfflinePurse represents the shadow state of an offline device
@@ -44,7 +52,7 @@ type OfflinePurse struct {
 // PaymentIntent is the data structure signed by the payer
 type PaymentIntent struct {
 	Amount  int64  `json:"amount"`
-	PayeeID string `json:"payee_id"`
+	PayeeID string `json:"payee_id"` // Payee's wallet address (cb1...)
 	Counter int64  `json:"counter"`
 	Nonce   string `json:"nonce"` // Randomness to prevent identical hashes
 }
@@ -52,7 +60,7 @@ type PaymentIntent struct {
 // SignedPayment is the offline transaction blob
 type SignedPayment struct {
 	PayerID   string `json:"payer_id"`
-	PayeeID   string `json:"payee_id"`
+	PayeeID   string `json:"payee_id"` // Payee's wallet address, as in the signed intent
 	Amount    int64  `json:"amount"`
 	Counter   int64  `json:"counter"`
 	Signature string `json:"signature"` // Ed25519 signature of PaymentIntent
@@ -69,3 +77,24 @@ type FundPurseRequest struct {
 	DeviceID string `json:"device_id"`
 	Amount   int64  `json:"amount"`
 }
+
+// ProofResult mirrors the chaincode's per-proof outcome from BatchReconcile
+type ProofResult struct {
+	Index    int    `json:"index"`
+	DeviceID string `json:"device_id"`
+	Nonce    int64  `json:"nonce"`
+	Status   string `json:"status"` // SETTLED, REJECTED
+	TxID     string `json:"tx_id,omitempty"`
+	Reason   string `json:"reason,omitempty"` // INSUFFICIENT_FUNDS, WALLET_FROZEN, BAD_SIGNATURE, DUPLICATE_NONCE, ...
+	Message  string `json:"message,omitempty"`
+}
+
+// BatchReconcileResult mirrors the chaincode's BatchReconcile response
+type BatchReconcileResult struct {
+	BatchID       string        `json:"batch_id"`
+	BatchSize     int           `json:"batch_size"`
+	SuccessCount  int           `json:"success_count"`
+	RejectedCount int           `json:"rejected_count"`
+	Results       []ProofResult `json:"results"`
+	Timestamp     int64         `json:"timestamp"`
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
 )
 
 type Transaction struct {
@@ -26,9 +28,24 @@ type PaymentRequest struct {
 	From        string          `json:"from"`
 	To          string          `json:"to"`
 	Amount      int64           `json:"amount"`
-	Type        string          `json:"type"` // Optional, default P2P
+	Currency    string          `json:"currency"` // Asset code, default NGN
+	Type        string          `json:"type"`     // Optional, default P2P
 	Description string          `json:"description"`
 	Metadata    json.RawMessage `json:"metadata"`
+
+	// Authorization is the owner's signature for self-custody wallets; custodial
+	// wallets are signed by wallet-service
+	Authorization *intents.Authorization `json:"authorization,omitempty"`
+}
+
+// RefundRequest returns part or all of a confirmed payment to the payer
+type RefundRequest struct {
+	Amount int64  `json:"amount"` // Optional, default the full payment amount
+	Reason string `json:"reason"`
+
+	// Authorization is the payee owner's signature for self-custody wallets; custodial
+	// wallets are signed by wallet-service
+	Authorization *intents.Authorization `json:"authorization,omitempty"`
 }
 
 type BatchTransferRequest struct {
@@ -37,5 +54,39 @@ type BatchTransferRequest struct {
 		ToWalletID string `json:"to_wallet_id"`
 		Amount     int64  `json:"amount"`
 	} `json:"transfers"`
-	Description string `json:"description"`
+	Description   string                 `json:"description"`
+	Authorization *intents.Authorization `json:"authorization,omitempty"` // Over the batch total and legs
+}
+
+// EscrowRequest creates an on-chain conditional payment
+type EscrowRequest struct {
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Amount    int64           `json:"amount"`
+	Condition json.RawMessage `json:"condition"` // {"type": "TimeLock|Arbiter|MultiSig", ...}
+	Expiry    int64           `json:"expiry"`    // Unix seconds
+
+	Authorization *intents.Authorization `json:"authorization,omitempty"`
+}
+
+// MerchantRegistration registers a merchant on chain
+type MerchantRegistration struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	MCC                string `json:"mcc"` // Merchant category code
+	SettlementWalletID string `json:"settlement_wallet_id"`
+	FeePlan            string `json:"fee_plan"` // Optional payment type, default P2B
+}
+
+// PaymentRequestCreate issues an on-chain invoice
+type PaymentRequestCreate struct {
+	InvoiceID string `json:"invoice_id"`
+	Amount    int64  `json:"amount"`
+	ExpiresAt int64  `json:"expires_at"` // Unix seconds
+}
+
+// PayRequestBody settles an invoice from the payer's wallet
+type PayRequestBody struct {
+	PayerWalletID string                 `json:"payer_wallet_id"`
+	Authorization *intents.Authorization `json:"authorization,omitempty"`
 }
@@ -429,9 +429,10 @@ func (s *Service) InterbankTransferHandler(w http.ResponseWriter, r *http.Reques
 
 	// 2. Update CBDC balances on Fabric
 	if s.fabric != nil {
-		fromWallet := "wallet-" + req.FromBankID
-		toWallet := "wallet-" + req.ToBankID
-		_, err := s.fabric.SubmitTransaction("Transfer", fromWallet, toWallet, fmt.Sprintf("%d", req.Amount))
+		// The adapter submits as a Central Bank admin, which may move funds between the two
+		// banks' settlement wallets only. The settlement ID keys the move, so a retry cannot
+		// move funds twice.
+		_, err := s.fabric.SubmitIdempotent("SettleInterbank", settlementID, req.FromBankID, req.ToBankID, fmt.Sprintf("%d", req.Amount))
 		if err != nil {
 			log.Printf("Fabric transfer failed: %v", err)
 			// Mark settlement as pending Fabric confirmation
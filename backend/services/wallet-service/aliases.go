@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/pkg/common/api"
+	"github.com/centralbank/cbdc/backend/services/wallet-service/models"
+	"github.com/gorilla/mux"
+)
+
+// Alias types accepted by the resolver
+const (
+	AliasPhone = "PHONE"
+	AliasEmail = "EMAIL"
+)
+
+var (
+	phonePattern = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// normalizeAlias returns the canonical form of an alias so lookups match however it was typed
+func normalizeAlias(aliasType string, value string) (string, error) {
+	switch strings.ToUpper(aliasType) {
+	case AliasPhone:
+		phone := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(value)
+		if !phonePattern.MatchString(phone) {
+			return "", fmt.Errorf("invalid phone number")
+		}
+		return phone, nil
+	case AliasEmail:
+		email := strings.ToLower(strings.TrimSpace(value))
+		if !emailPattern.MatchString(email) {
+			return "", fmt.Errorf("invalid email address")
+		}
+		return email, nil
+	default:
+		return "", fmt.Errorf("alias type must be %s or %s", AliasPhone, AliasEmail)
+	}
+}
+
+// walletAddress returns the on-chain address of a user's wallet
+func (s *Service) walletAddress(userID string) (string, error) {
+	var address string
+	err := s.db.QueryRow("SELECT address FROM wallet_db.wallets WHERE user_id = $1 ORDER BY created_at LIMIT 1", userID).Scan(&address)
+	return address, err
+}
+
+// aliasCodeTTL is how long an alias verification code stays valid
+const aliasCodeTTL = 10 * time.Minute
+
+// maxAliasCodeAttempts caps wrong guesses before the code must be requested again
+const maxAliasCodeAttempts = 5
+
+// hashAliasCode binds a verification code to the alias it was sent to
+func hashAliasCode(aliasType string, alias string, code string) string {
+	sum := sha256.Sum256([]byte(aliasType + ":" + alias + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAliasCode returns a random six-digit verification code
+func newAliasCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// sendAliasCode delivers a verification code to the phone number or email through the
+// notification service, proving the requester controls the alias before it is linked
+func (s *Service) sendAliasCode(aliasType string, alias string, code string) error {
+	if s.notificationURL == "" {
+		return fmt.Errorf("alias verification is not configured (NOTIFICATION_SERVICE_URL)")
+	}
+	channel := "SMS"
+	if aliasType == AliasEmail {
+		channel = "EMAIL"
+	}
+	body, _ := json.Marshal(map[string]string{
+		"channel": channel,
+		"to":      alias,
+		"message": fmt.Sprintf("Your CBDC wallet verification code is %s. It expires in %d minutes.", code, int(aliasCodeTTL.Minutes())),
+	})
+	resp, err := http.Post(s.notificationURL+"/notifications", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to contact notification service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification service returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RegisterAliasHandler starts linking a phone number or email to the authenticated user's
+// wallet by sending a verification code to it. The alias is linked by VerifyAliasHandler
+// once the code comes back, so nobody can claim a number or address they do not control.
+func (s *Service) RegisterAliasHandler(w http.ResponseWriter, r *http.Request) {
+	walletID := mux.Vars(r)["id"]
+
+	var req models.AliasRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	aliasType := strings.ToUpper(req.Type)
+	alias, err := normalizeAlias(aliasType, req.Value)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_alias", err.Error(), "")
+		return
+	}
+
+	// Only the wallet's owner may link an alias to it
+	userID := authenticatedUser(r)
+	owns, err := s.ownsWallet(userID, walletID)
+	if err != nil {
+		log.Printf("DB Error: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Database error", "")
+		return
+	}
+	if !owns {
+		api.WriteError(w, http.StatusForbidden, "not_wallet_owner", "Aliases can only be linked by the wallet's owner", "")
+		return
+	}
+	var taken bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM wallet_db.wallet_aliases WHERE alias_type = $1 AND alias = $2)",
+		aliasType, alias).Scan(&taken); err != nil {
+		log.Printf("DB Error: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Database error", "")
+		return
+	}
+	if taken {
+		api.WriteError(w, http.StatusConflict, "alias_taken", "Alias is already linked to a wallet", "")
+		return
+	}
+
+	code, err := newAliasCode()
+	if err != nil {
+		log.Printf("Failed to generate alias code: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to generate verification code", "")
+		return
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO wallet_db.alias_verifications (alias_type, alias, user_id, wallet_id, code_hash, attempts, expires_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6)
+		ON CONFLICT (alias_type, alias, user_id) DO UPDATE
+		SET wallet_id = EXCLUDED.wallet_id, code_hash = EXCLUDED.code_hash, attempts = 0, expires_at = EXCLUDED.expires_at`,
+		aliasType, alias, userID, walletID, hashAliasCode(aliasType, alias, code), time.Now().Add(aliasCodeTTL)); err != nil {
+		log.Printf("Failed to store alias verification: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "db_error", "Failed to start alias verification", "")
+		return
+	}
+	if err := s.sendAliasCode(aliasType, alias, code); err != nil {
+		log.Printf("Failed to send alias code: %v", err)
+		api.WriteError(w, http.StatusServiceUnavailable, "verification_unavailable", "Failed to send verification code", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusAccepted, map[string]string{"type": aliasType, "alias": alias, "status": "verification_sent"})
+}
+
+// VerifyAliasHandler links an alias to the wallet once the authenticated user returns the
+// code sent to it by RegisterAliasHandler
+func (s *Service) VerifyAliasHandler(w http.ResponseWriter, r *http.Request) {
+	walletID := mux.Vars(r)["id"]
+
+	var req models.AliasVerification
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	aliasType := strings.ToUpper(req.Type)
+	alias, err := normalizeAlias(aliasType, req.Value)
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_alias", err.Error(), "")
+		return
+	}
+
+	userID := authenticatedUser(r)
+	var codeHash string
+	var attempts int
+	var expiresAt time.Time
+	err = s.db.QueryRow(`
+		SELECT code_hash, attempts, expires_at FROM wallet_db.alias_verifications
+		WHERE alias_type = $1 AND alias = $2 AND user_id = $3 AND wallet_id = $4`,
+		aliasType, alias, userID, walletID).Scan(&codeHash, &attempts, &expiresAt)
+	if err == sql.ErrNoRows {
+		api.WriteError(w, http.StatusNotFound, "verification_not_found", "No pending verification for this alias", "")
+		return
+	}
+	if err != nil {
+		log.Printf("DB Error: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Database error", "")
+		return
+	}
+	if time.Now().After(expiresAt) || attempts >= maxAliasCodeAttempts {
+		api.WriteError(w, http.StatusGone, "verification_expired", "Verification code expired; request a new one", "")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAliasCode(aliasType, alias, req.Code)), []byte(codeHash)) != 1 {
+		s.db.Exec(`UPDATE wallet_db.alias_verifications SET attempts = attempts + 1 WHERE alias_type = $1 AND alias = $2 AND user_id = $3`,
+			aliasType, alias, userID)
+		api.WriteError(w, http.StatusForbidden, "invalid_code", "Verification code is incorrect", "")
+		return
+	}
+
+	// The address comes from the wallet row, which the user still has to own
+	var address string
+	err = s.db.QueryRow(`
+		INSERT INTO wallet_db.wallet_aliases (alias_type, alias, address)
+		SELECT $1, $2, address FROM wallet_db.wallets WHERE id = $3 AND user_id = $4
+		ON CONFLICT (alias_type, alias) DO NOTHING
+		RETURNING address`,
+		aliasType, alias, walletID, userID).Scan(&address)
+	if err == sql.ErrNoRows {
+		api.WriteError(w, http.StatusConflict, "alias_taken", "Alias is already linked to a wallet", "")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to register alias: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "db_error", "Failed to register alias", "")
+		return
+	}
+	if _, err := s.db.Exec(`DELETE FROM wallet_db.alias_verifications WHERE alias_type = $1 AND alias = $2 AND user_id = $3`,
+		aliasType, alias, userID); err != nil {
+		log.Printf("Failed to clear alias verification: %v", err)
+	}
+
+	api.WriteSuccess(w, http.StatusCreated, map[string]string{"type": aliasType, "alias": alias, "address": address})
+}
+
+// UserWalletHandler returns the address of the authenticated user's own wallet
+func (s *Service) UserWalletHandler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if authenticatedUser(r) != userID {
+		api.WriteError(w, http.StatusForbidden, "forbidden", "Users can only look up their own wallet", "")
+		return
+	}
+	s.writeUserWallet(w, userID)
+}
+
+// InternalUserWalletHandler returns the address of any user's wallet to other backend
+// services, so they need not read wallet_db themselves
+func (s *Service) InternalUserWalletHandler(w http.ResponseWriter, r *http.Request) {
+	s.writeUserWallet(w, mux.Vars(r)["id"])
+}
+
+func (s *Service) writeUserWallet(w http.ResponseWriter, userID string) {
+	address, err := s.walletAddress(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			api.WriteError(w, http.StatusNotFound, "wallet_not_found", "User has no wallet", "")
+		} else {
+			log.Printf("DB Error: %v", err)
+			api.WriteError(w, http.StatusInternalServerError, "internal_error", "Database error", "")
+		}
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, map[string]string{"address": address})
+}
+
+// ResolveAliasHandler returns the wallet address behind an alias (?type=PHONE&value=...) to
+// an authenticated user, e.g. to pay by phone number
+func (s *Service) ResolveAliasHandler(w http.ResponseWriter, r *http.Request) {
+	aliasType := r.URL.Query().Get("type")
+	alias, err := normalizeAlias(aliasType, r.URL.Query().Get("value"))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_alias", err.Error(), "")
+		return
+	}
+
+	var address string
+	err = s.db.QueryRow("SELECT address FROM wallet_db.wallet_aliases WHERE alias_type = $1 AND alias = $2",
+		strings.ToUpper(aliasType), alias).Scan(&address)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			api.WriteError(w, http.StatusNotFound, "alias_not_found", "No wallet for this alias", "")
+		} else {
+			log.Printf("DB Error: %v", err)
+			api.WriteError(w, http.StatusInternalServerError, "internal_error", "Database error", "")
+		}
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, map[string]string{"address": address})
+}
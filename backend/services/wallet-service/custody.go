@@ -0,0 +1,210 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/pkg/common"
+	"github.com/centralbank/cbdc/backend/pkg/common/api"
+	"github.com/gorilla/mux"
+)
+
+// custodyKey derives the AES-256 key sealing custodial wallet keys from WALLET_KEY_SECRET.
+// It returns nil when the secret is unset, in which case only self-custody wallets
+// (created with a public_key) can be opened.
+func custodyKey() []byte {
+	secret := os.Getenv("WALLET_KEY_SECRET")
+	if secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// ownerKey returns the Ed25519 public key a new wallet's address is derived from. Owners
+// may bring their own key (hex); otherwise a custodial key is generated and its private
+// key returned sealed for wallet_db.wallets.encrypted_keys.
+func (s *Service) ownerKey(publicKeyHex string) (ed25519.PublicKey, string, error) {
+	if publicKeyHex != "" {
+		publicKey, err := hex.DecodeString(publicKeyHex)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return nil, "", fmt.Errorf("public_key must be a hex-encoded Ed25519 public key")
+		}
+		return publicKey, "", nil
+	}
+
+	if s.custodyKey == nil {
+		return nil, "", errors.New("custodial keys are not configured (WALLET_KEY_SECRET)")
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+	sealed, err := seal(s.custodyKey, privateKey.Seed())
+	if err != nil {
+		return nil, "", err
+	}
+	return publicKey, sealed, nil
+}
+
+// open reverses seal
+func open(key []byte, sealed string) ([]byte, error) {
+	data, err := hex.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("sealed key is too short")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+// seal encrypts plaintext with AES-GCM, returning hex(nonce || ciphertext)
+func seal(key []byte, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Errors returned by signDebit
+var (
+	errWalletNotFound = errors.New("wallet not found")
+	errSelfCustody    = errors.New("debits of this wallet must be signed by its owner")
+	errNotOwner       = errors.New("the authenticated user does not own this wallet")
+)
+
+// authenticatedUser returns the user ID of the token AuthMiddleware verified, or "" when
+// the request was not authenticated
+func authenticatedUser(r *http.Request) string {
+	if claims, ok := common.ClaimsFrom(r); ok {
+		return claims.UserID
+	}
+	return ""
+}
+
+// ownsWallet reports whether userID is the owner of walletID; false for an unauthenticated user
+func (s *Service) ownsWallet(userID string, walletID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	var owner string
+	err := s.db.QueryRow("SELECT user_id FROM wallet_db.wallets WHERE id = $1", walletID).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return owner == userID, nil
+}
+
+// signDebit signs a debit of a custodial wallet with its owner key, using the next on-chain
+// nonce. Only the authenticated user who owns the wallet can have it signed.
+func (s *Service) signDebit(userID string, walletID string, intent intents.Transfer) (*intents.Authorization, error) {
+	var sealed sql.NullString
+	var owner string
+	err := s.db.QueryRow("SELECT encrypted_keys, user_id FROM wallet_db.wallets WHERE id = $1", walletID).Scan(&sealed, &owner)
+	if err == sql.ErrNoRows {
+		return nil, errWalletNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID == "" || owner != userID {
+		return nil, errNotOwner
+	}
+	if !sealed.Valid || s.custodyKey == nil {
+		return nil, errSelfCustody
+	}
+	seed, err := open(s.custodyKey, sealed.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open custodial key of %s: %v", walletID, err)
+	}
+
+	result, err := s.fabric.EvaluateTransaction("GetWallet", walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet nonce: %v", err)
+	}
+	var wallet struct {
+		Nonce int64 `json:"nonce"`
+	}
+	if err := json.Unmarshal(result, &wallet); err != nil {
+		return nil, fmt.Errorf("invalid wallet %s: %v", walletID, err)
+	}
+
+	intent.From = walletID
+	intent.Nonce = wallet.Nonce + 1
+	authorization := intents.Sign(ed25519.NewKeyFromSeed(seed), intent)
+	return &authorization, nil
+}
+
+// authorize returns the transient owner authorization of a debit: the signature supplied
+// by a self-custody client, or one made here with the custodial key for its owner userID
+func (s *Service) authorize(userID string, walletID string, supplied *intents.Authorization, intent intents.Transfer) (map[string][]byte, error) {
+	if supplied == nil {
+		var err error
+		if supplied, err = s.signDebit(userID, walletID, intent); err != nil {
+			return nil, err
+		}
+	}
+	authorization, _ := json.Marshal(supplied)
+	return map[string][]byte{intents.TransientKey: authorization}, nil
+}
+
+// AuthorizeHandler signs a debit of a custodial wallet with its owner key, using the next
+// on-chain nonce, for the authenticated user who owns it. Self-custody wallets are
+// refused: only their owner can sign.
+func (s *Service) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	walletID := mux.Vars(r)["id"]
+
+	var intent intents.Transfer
+	if err := json.NewDecoder(r.Body).Decode(&intent); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+
+	authorization, err := s.signDebit(authenticatedUser(r), walletID, intent)
+	switch {
+	case err == errWalletNotFound:
+		api.WriteError(w, http.StatusNotFound, "wallet_not_found", "Wallet not found", "")
+	case err == errNotOwner:
+		api.WriteError(w, http.StatusForbidden, "not_wallet_owner", "Only the wallet's owner can authorize its debits", "")
+	case err == errSelfCustody:
+		api.WriteError(w, http.StatusForbidden, "self_custody", "Debits of this wallet must be signed by its owner", "")
+	case err != nil:
+		log.Printf("Failed to sign debit of %s: %v", walletID, err)
+		api.WriteError(w, http.StatusInternalServerError, "custody_error", "Failed to sign debit", "")
+	default:
+		api.WriteSuccess(w, http.StatusOK, authorization)
+	}
+}
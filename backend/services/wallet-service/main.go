@@ -1,12 +1,22 @@
 package main
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-
+	"os"
+	"sort"
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/addresses"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/assets"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/params"
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/tiers"
 	"github.com/centralbank/cbdc/backend/pkg/common"
 	"github.com/centralbank/cbdc/backend/pkg/common/api"
 	"github.com/centralbank/cbdc/backend/pkg/common/db"
@@ -19,8 +29,18 @@ import (
 type Service struct {
 	fabric *fabricclient.Client
 	db     *sql.DB
+	// intermediaryID must match the intermediary_id attribute (or MSP) of the
+	// service's Fabric identity; cbdc-core rejects wallets opened for anyone else
+	intermediaryID string
+	custodyKey     []byte        // Seals custodial owner keys; nil disables custodial wallets
+	params         *params.Cache // Tier limits published by governance-cc
+	// notificationURL delivers alias verification codes; empty disables alias registration
+	notificationURL string
 }
 
+// paramsRefresh is how often tier limits are re-read from governance-cc
+const paramsRefresh = time.Minute
+
 func (s *Service) CreateWalletHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateWalletRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -33,14 +53,37 @@ func (s *Service) CreateWalletHandler(w http.ResponseWriter, r *http.Request) {
 		req.Type = "RETAIL"
 	}
 	if req.Tier == "" {
-		req.Tier = "TIER_1"
+		req.Tier = tiers.Default
+	}
+	tier, ok := tiers.Normalize(req.Tier)
+	if !ok {
+		api.WriteError(w, http.StatusBadRequest, "invalid_tier", "Tier must be Tier0, Tier1 or Tier2", "")
+		return
 	}
+	req.Tier = tier
 
-	// 1. Generate Wallet ID
-	walletID := "wallet-" + req.UserID
+	// 1. Derive the wallet address from the owner's key, so the ledger never sees the user ID
+	publicKey, sealedKey, err := s.ownerKey(req.PublicKey)
+	if err != nil {
+		if req.PublicKey != "" {
+			api.WriteError(w, http.StatusBadRequest, "invalid_public_key", err.Error(), "")
+			return
+		}
+		log.Printf("Failed to generate custodial key: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "custody_error", "Failed to generate wallet key", "")
+		return
+	}
+	walletID := addresses.FromPublicKey(publicKey)
 
-	// 2. Call Fabric to register wallet on-chain
-	_, err := s.fabric.SubmitTransaction("CreateWallet", walletID, req.UserID, "BankConsortiumMSP", req.Tier)
+	// 2. Call Fabric to register wallet on-chain. The owner and KYC attributes travel as
+	// transient data so only their salted hashes reach the public ledger.
+	owner, err := walletOwner(req)
+	if err != nil {
+		log.Printf("Failed to salt owner attributes: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to prepare wallet owner", "")
+		return
+	}
+	_, err = s.fabric.SubmitTransactionWithTransient("CreateWallet", map[string][]byte{"wallet_owner": owner}, walletID, s.intermediaryID, req.Tier, hex.EncodeToString(publicKey))
 	if err != nil {
 		log.Printf("Failed to create wallet on chain: %v", err)
 		api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to create wallet on chain", "")
@@ -48,18 +91,14 @@ func (s *Service) CreateWalletHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 3. Save metadata to local DB
-	dailyLimit := int64(50000) // Default Tier 1
-	if req.Tier == "TIER_2" {
-		dailyLimit = 200000
-	} else if req.Tier == "TIER_3" {
-		dailyLimit = 5000000
-	}
+	limits, _ := s.params.Get().TierLimits(req.Tier)
+	dailyLimit := limits.DailyLimit
 
 	_, err = s.db.Exec(`
 		INSERT INTO wallet_db.wallets (
-			id, user_id, address, type, status, currency, tier_level, daily_limit
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
-		walletID, req.UserID, walletID, req.Type, "ACTIVE", "NGN", req.Tier, dailyLimit)
+			id, user_id, address, type, status, currency, tier_level, daily_limit, public_key, encrypted_keys
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))`,
+		walletID, req.UserID, walletID, req.Type, "ACTIVE", assets.Default, req.Tier, dailyLimit, hex.EncodeToString(publicKey), sealedKey)
 
 	if err != nil {
 		log.Printf("Failed to save wallet to DB: %v", err)
@@ -67,7 +106,36 @@ func (s *Service) CreateWalletHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.WriteSuccess(w, http.StatusCreated, map[string]string{"wallet_id": walletID, "status": "created"})
+	api.WriteSuccess(w, http.StatusCreated, map[string]string{"wallet_id": walletID, "address": walletID, "status": "created"})
+}
+
+// walletOwner builds the private owner record of a new wallet, giving every attribute a
+// random salt so its on-chain hash cannot be matched against guessed values
+func walletOwner(req models.CreateWalletRequest) ([]byte, error) {
+	names := []string{"owner_id"}
+	values := map[string]string{"owner_id": req.UserID}
+	for name, value := range req.KYC {
+		if name == "owner_id" {
+			continue
+		}
+		names = append(names, name)
+		values[name] = value
+	}
+	sort.Strings(names[1:])
+
+	attributes := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, map[string]string{
+			"name":  name,
+			"value": values[name],
+			"salt":  hex.EncodeToString(salt),
+		})
+	}
+	return json.Marshal(map[string]interface{}{"attributes": attributes})
 }
 
 func (s *Service) GetWalletHandler(w http.ResponseWriter, r *http.Request) {
@@ -95,19 +163,24 @@ func (s *Service) GetWalletHandler(w http.ResponseWriter, r *http.Request) {
 	result, err := s.fabric.EvaluateTransaction("GetWallet", id)
 	if err == nil {
 		var chainWallet struct {
-			Balance int64 `json:"balance"`
+			Balance  int64            `json:"balance"`
+			Balances map[string]int64 `json:"balances"`
 		}
 		if err := json.Unmarshal(result, &chainWallet); err == nil {
 			wallet.Balance = chainWallet.Balance
+			wallet.Balances = chainWallet.Balances
 		}
 	}
 
 	api.WriteSuccess(w, http.StatusOK, wallet)
 }
 
+// GetBalanceHandler returns the wallet's on-chain balance of one asset (?asset=, default NGN).
+// Holds only reserve NGN, so other assets are fully available.
 func (s *Service) GetBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
+	asset := assets.OrDefault(r.URL.Query().Get("asset"))
 
 	// Call Fabric to get state
 	result, err := s.fabric.EvaluateTransaction("GetWallet", id)
@@ -117,50 +190,303 @@ func (s *Service) GetBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var wallet struct {
-		Balance int64 `json:"balance"`
+		Balance   int64            `json:"balance"`
+		Held      int64            `json:"held"`
+		Available int64            `json:"available"`
+		Balances  map[string]int64 `json:"balances"`
 	}
 	if err := json.Unmarshal(result, &wallet); err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "data_error", "Failed to parse chain data", "")
 		return
 	}
 
-	api.WriteSuccess(w, http.StatusOK, models.WalletBalance{Balance: wallet.Balance, Currency: "NGN"})
+	if asset != assets.Default {
+		balance := wallet.Balances[asset]
+		api.WriteSuccess(w, http.StatusOK, models.WalletBalance{Balance: balance, Available: balance, Currency: asset})
+		return
+	}
+	api.WriteSuccess(w, http.StatusOK, models.WalletBalance{Balance: wallet.Balance, Held: wallet.Held, Available: wallet.Available, Currency: asset})
 }
 
-func (s *Service) LockFundsHandler(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		UserID string `json:"user_id"`
-		Amount int64  `json:"amount"`
-		Reason string `json:"reason"`
+// GetUsageHandler returns today's on-chain spend and the remaining tier headroom
+func (s *Service) GetUsageHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	result, err := s.fabric.EvaluateTransaction("GetWalletUsage", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "wallet_not_found", "Wallet not found on chain", "")
+		return
 	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// LockFundsHandler reserves wallet funds with an on-chain hold. Offline funding uses one
+// hold per device ("offline-<device_id>") which the chaincode tops up and draws down on reconcile.
+func (s *Service) LockFundsHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.LockFundsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
 		return
 	}
 
-	// 1. Get Wallet ID
-	walletID := "wallet-" + req.UserID
+	// 1. Get the authenticated user's wallet
+	userID := authenticatedUser(r)
+	walletID, err := s.walletAddress(userID)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "wallet_not_found", "User has no wallet", "")
+		return
+	}
 
-	// 2. Call Fabric to Transfer (Debit User, Credit "OfflineReserve" or Burn)
-	// For this phase, we'll simulate locking by transferring to a "Reserve" wallet.
-	// Assuming "ReserveWallet" exists or we just burn it.
-	// Let's use a "Burn" or "Lock" chaincode method if available, or just Transfer to a known reserve.
-	// We'll use "Transfer" to "offline-reserve-wallet" for now.
-	reserveWallet := "offline-reserve-wallet"
+	// 2. Pick the hold purpose and ID
+	purpose := "Authorization"
+	holdID := req.HoldID
+	if req.Reason == "offline_funding" {
+		if req.DeviceID == "" {
+			api.WriteError(w, http.StatusBadRequest, "invalid_request", "device_id is required for offline funding", "")
+			return
+		}
+		purpose = "OfflineFunding"
+		holdID = "offline-" + req.DeviceID
+	}
+	if holdID == "" {
+		// Hold IDs are written on-chain, so they must not carry the user ID
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			api.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to generate hold ID", "")
+			return
+		}
+		holdID = "hold-" + hex.EncodeToString(random)
+	}
 
-	amountStr := fmt.Sprintf("%d", req.Amount)
-	_, err := s.fabric.SubmitTransaction("Transfer", walletID, reserveWallet, amountStr)
-	// Note: SubmitTransaction takes strings. string(req.Amount) is wrong, it converts rune. Need strconv.
-	// But wait, my previous code used string(req.Amount) which is definitely a bug if Amount is int64.
-	// I should fix that.
+	// 3. The owner authorizes the reservation
+	authorization, err := s.authorize(userID, walletID, req.Authorization, intents.Transfer{Hold: holdID, Asset: assets.Default, Amount: req.Amount})
+	if err != nil {
+		log.Printf("Hold authorization failed: %v", err)
+		api.WriteError(w, http.StatusForbidden, "unauthorized", "Hold was not authorized by the wallet owner", "")
+		return
+	}
 
+	// 4. Place the hold on chain
+	amountStr := fmt.Sprintf("%d", req.Amount)
+	result, err := s.fabric.SubmitTransactionWithTransient("PlaceHold", authorization, walletID, amountStr, purpose, holdID)
 	if err != nil {
 		log.Printf("Failed to lock funds: %v", err)
 		api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to lock funds on chain", "")
 		return
 	}
 
-	api.WriteSuccess(w, http.StatusOK, map[string]string{"status": "locked", "tx_id": "simulated-tx-id"})
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// ReleaseHoldHandler returns held funds to the wallet's available balance. The owner of
+// the held wallet can release their own reservations, but not an authorization hold: the
+// merchant relies on it until capture, so only an intermediary operator can void it.
+func (s *Service) ReleaseHoldHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	holdJSON, err := s.fabric.EvaluateTransaction("GetHold", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "hold_not_found", "Hold not found", "")
+		return
+	}
+	var hold struct {
+		WalletID string `json:"wallet_id"`
+		Purpose  string `json:"purpose"`
+	}
+	json.Unmarshal(holdJSON, &hold)
+	if claims, ok := common.ClaimsFrom(r); ok && claims.Role == common.RoleAdmin {
+		s.releaseHold(w, id)
+		return
+	}
+	if hold.Purpose == "Authorization" {
+		api.WriteError(w, http.StatusForbidden, "authorization_hold", "Authorization holds are released by the merchant's side, not the payer", "")
+		return
+	}
+	owns, err := s.ownsWallet(authenticatedUser(r), hold.WalletID)
+	if err != nil {
+		log.Printf("DB Error: %v", err)
+		api.WriteError(w, http.StatusInternalServerError, "internal_error", "Database error", "")
+		return
+	}
+	if !owns {
+		api.WriteError(w, http.StatusForbidden, "not_wallet_owner", "Only the wallet's owner can release its holds", "")
+		return
+	}
+	s.releaseHold(w, id)
+}
+
+// InternalReleaseHoldHandler voids a hold for another backend service, such as the
+// acquiring side cancelling a card-style authorization it will not capture
+func (s *Service) InternalReleaseHoldHandler(w http.ResponseWriter, r *http.Request) {
+	s.releaseHold(w, mux.Vars(r)["id"])
+}
+
+func (s *Service) releaseHold(w http.ResponseWriter, id string) {
+	result, err := s.fabric.SubmitTransaction("ReleaseHold", id)
+	if err != nil {
+		log.Printf("Failed to release hold %s: %v", id, err)
+		api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to release hold on chain", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// CaptureHoldHandler pays held funds to another wallet, releasing any remainder
+func (s *Service) CaptureHoldHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req models.CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+
+	// The owner of the held wallet authorizes the payee and amount
+	holdJSON, err := s.fabric.EvaluateTransaction("GetHold", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "hold_not_found", "Hold not found", "")
+		return
+	}
+	var hold struct {
+		WalletID string `json:"wallet_id"`
+	}
+	json.Unmarshal(holdJSON, &hold)
+	authorization, err := s.authorize(authenticatedUser(r), hold.WalletID, req.Authorization, intents.Transfer{To: req.ToWalletID, Hold: id, Asset: assets.Default, Amount: req.Amount})
+	if err != nil {
+		log.Printf("Capture authorization failed: %v", err)
+		api.WriteError(w, http.StatusForbidden, "unauthorized", "Capture was not authorized by the wallet owner", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransactionWithTransient("CaptureHold", authorization, id, req.ToWalletID, fmt.Sprintf("%d", req.Amount))
+	if err != nil {
+		log.Printf("Failed to capture hold %s: %v", id, err)
+		api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to capture hold on chain", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// UpdateTierHandler moves a wallet to a new tier on-chain and mirrors it on the wallet and its owner
+func (s *Service) UpdateTierHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req models.UpdateTierRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	tier, ok := tiers.Normalize(req.Tier)
+	if !ok {
+		api.WriteError(w, http.StatusBadRequest, "invalid_tier", "Tier must be Tier0, Tier1 or Tier2", "")
+		return
+	}
+	if req.ReasonCode == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "reason_code is required", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction("UpdateWalletTier", id, tier, req.ReasonCode)
+	if err != nil {
+		log.Printf("Failed to update tier of wallet %s: %v", id, err)
+		api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to update wallet tier on chain", "")
+		return
+	}
+
+	limits, _ := s.params.Get().TierLimits(tier)
+	if _, err := s.db.Exec(`UPDATE wallet_db.wallets SET tier_level = $1, daily_limit = $2, updated_at = NOW() WHERE id = $3`,
+		tier, limits.DailyLimit, id); err != nil {
+		log.Printf("Failed to update tier of wallet %s in DB: %v", id, err)
+	}
+	if _, err := s.db.Exec(`UPDATE wallet_db.users SET tier = $1 WHERE id = (SELECT user_id FROM wallet_db.wallets WHERE id = $2)`, tier, id); err != nil {
+		log.Printf("Failed to update tier of owner of wallet %s: %v", id, err)
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// walletStatusFunctions maps the status requested by an intermediary to the chaincode
+// transition. Freezing is reserved to cbn-ops-service.
+var walletStatusFunctions = map[string]string{
+	"ACTIVE":    "ReactivateWallet",
+	"SUSPENDED": "SuspendWallet",
+	"DORMANT":   "MarkWalletDormant",
+	"CLOSED":    "CloseWallet",
+}
+
+// ChangeStatusHandler moves a wallet through its lifecycle on-chain and mirrors the status locally
+func (s *Service) ChangeStatusHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req models.WalletStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid request body", "")
+		return
+	}
+	fn, ok := walletStatusFunctions[req.Status]
+	if !ok {
+		api.WriteError(w, http.StatusBadRequest, "invalid_status", "Status must be ACTIVE, SUSPENDED, DORMANT or CLOSED", "")
+		return
+	}
+	if req.ReasonCode == "" {
+		api.WriteError(w, http.StatusBadRequest, "invalid_request", "reason_code is required", "")
+		return
+	}
+
+	result, err := s.fabric.SubmitTransaction(fn, id, req.ReasonCode)
+	if err != nil {
+		log.Printf("Failed to change status of wallet %s: %v", id, err)
+		api.WriteError(w, http.StatusInternalServerError, "chain_error", "Failed to change wallet status on chain", "")
+		return
+	}
+
+	if _, err := s.db.Exec(`UPDATE wallet_db.wallets SET status = $1, updated_at = NOW() WHERE id = $2`, req.Status, id); err != nil {
+		log.Printf("Failed to update status of wallet %s in DB: %v", id, err)
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetStatusHistoryHandler returns the wallet's on-chain lifecycle changes
+func (s *Service) GetStatusHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	result, err := s.fabric.EvaluateTransaction("GetWalletStatusHistory", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "wallet_not_found", "Wallet not found on chain", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+// GetTierHistoryHandler returns the wallet's on-chain tier changes
+func (s *Service) GetTierHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	result, err := s.fabric.EvaluateTransaction("GetWalletTierHistory", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "wallet_not_found", "Wallet not found on chain", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
+}
+
+func (s *Service) GetHoldHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	result, err := s.fabric.EvaluateTransaction("GetHold", id)
+	if err != nil {
+		api.WriteError(w, http.StatusNotFound, "hold_not_found", "Hold not found", "")
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, json.RawMessage(result))
 }
 
 func main() {
@@ -192,13 +518,51 @@ func main() {
 		defer fabric.Close()
 	}
 
-	svc := &Service{fabric: fabric, db: database}
+	// Tier limits are governed in governance-cc; the defaults apply until it is reachable
+	var fetchParams func() ([]byte, error)
+	governance, err := fabricclient.NewClient(
+		cfg.FabricConfig,
+		"cbdc-main-channel",
+		"governance-cc",
+		cfg.MSP,
+		cfg.CertPath,
+		cfg.KeyPath,
+	)
+	if err != nil {
+		log.Printf("Warning: governance connection failed: %v", err)
+	} else {
+		defer governance.Close()
+		fetchParams = func() ([]byte, error) { return governance.EvaluateTransaction("GetParams") }
+	}
+
+	intermediaryID := os.Getenv("INTERMEDIARY_ID")
+	if intermediaryID == "" {
+		intermediaryID = cfg.MSP
+	}
+
+	svc := &Service{fabric: fabric, db: database, intermediaryID: intermediaryID, custodyKey: custodyKey(),
+		params: params.NewCache(fetchParams, paramsRefresh), notificationURL: os.Getenv("NOTIFICATION_SERVICE_URL")}
 
 	r := mux.NewRouter()
 	r.HandleFunc("/wallets", svc.CreateWalletHandler).Methods("POST")
-	r.HandleFunc("/wallets/lock", svc.LockFundsHandler).Methods("POST") // New Endpoint
+	r.Handle("/wallets/lock", common.AuthMiddleware(http.HandlerFunc(svc.LockFundsHandler))).Methods("POST")
+	r.HandleFunc("/wallets/holds/{id}", svc.GetHoldHandler).Methods("GET")
+	r.Handle("/wallets/holds/{id}/release", common.AuthMiddleware(http.HandlerFunc(svc.ReleaseHoldHandler))).Methods("POST")
+	r.Handle("/wallets/holds/{id}/capture", common.AuthMiddleware(http.HandlerFunc(svc.CaptureHoldHandler))).Methods("POST")
+	r.Handle("/wallets/resolve", common.AuthMiddleware(http.HandlerFunc(svc.ResolveAliasHandler))).Methods("GET")
 	r.HandleFunc("/wallets/{id}", svc.GetWalletHandler).Methods("GET")
 	r.HandleFunc("/wallets/{id}/balance", svc.GetBalanceHandler).Methods("GET")
+	r.HandleFunc("/wallets/{id}/usage", svc.GetUsageHandler).Methods("GET")
+	r.Handle("/wallets/{id}/status", common.AuthMiddleware(common.RequireRole(common.RoleAdmin, svc.ChangeStatusHandler))).Methods("POST")
+	r.Handle("/wallets/{id}/tier", common.AuthMiddleware(common.RequireRole(common.RoleAdmin, svc.UpdateTierHandler))).Methods("PUT")
+	r.HandleFunc("/wallets/{id}/status-history", svc.GetStatusHistoryHandler).Methods("GET")
+	r.HandleFunc("/wallets/{id}/tier-history", svc.GetTierHistoryHandler).Methods("GET")
+	r.Handle("/wallets/{id}/aliases", common.AuthMiddleware(http.HandlerFunc(svc.RegisterAliasHandler))).Methods("POST")
+	r.Handle("/wallets/{id}/aliases/verify", common.AuthMiddleware(http.HandlerFunc(svc.VerifyAliasHandler))).Methods("POST")
+	r.Handle("/wallets/{id}/authorize", common.AuthMiddleware(http.HandlerFunc(svc.AuthorizeHandler))).Methods("POST")
+	r.Handle("/users/{id}/wallet", common.AuthMiddleware(http.HandlerFunc(svc.UserWalletHandler))).Methods("GET")
+	r.Handle("/internal/users/{id}/wallet", common.InternalMiddleware(http.HandlerFunc(svc.InternalUserWalletHandler))).Methods("GET")
+	r.Handle("/internal/holds/{id}/release", common.InternalMiddleware(http.HandlerFunc(svc.InternalReleaseHoldHandler))).Methods("POST")
 
 	log.Printf("Wallet Service running on :%s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
@@ -1,29 +1,83 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/centralbank/cbdc/backend/chaincode/cbdc-core/intents"
+)
 
 type Wallet struct {
-	ID            string    `json:"id"`
-	UserID        string    `json:"user_id"`
-	Address       string    `json:"address"`
-	Type          string    `json:"type"`     // RETAIL, MERCHANT, IOT, GOV
-	Status        string    `json:"status"`   // ACTIVE, FROZEN, CLOSED
-	Currency      string    `json:"currency"` // e.g., NGN
-	Balance       int64     `json:"balance"`  // Cached balance
-	TierLevel     string    `json:"tier_level"`
-	DailyLimit    int64     `json:"daily_limit"`
-	EncryptedKeys string    `json:"-"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string           `json:"id"`
+	UserID        string           `json:"user_id"`
+	Address       string           `json:"address"`
+	Type          string           `json:"type"`               // RETAIL, MERCHANT, IOT, GOV
+	Status        string           `json:"status"`             // ACTIVE, FROZEN, SUSPENDED, DORMANT, CLOSED
+	Currency      string           `json:"currency"`           // e.g., NGN
+	Balance       int64            `json:"balance"`            // Cached balance
+	Balances      map[string]int64 `json:"balances,omitempty"` // On-chain balance per asset code
+	TierLevel     string           `json:"tier_level"`
+	DailyLimit    int64            `json:"daily_limit"`
+	EncryptedKeys string           `json:"-"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
 }
 
 type CreateWalletRequest struct {
-	UserID string `json:"user_id"`
-	Tier   string `json:"tier"`
-	Type   string `json:"type"` // Optional, default RETAIL
+	UserID string            `json:"user_id"`
+	Tier   string            `json:"tier"` // Tier0, Tier1, Tier2; optional, default Tier0
+	Type   string            `json:"type"` // Optional, default RETAIL
+	KYC    map[string]string `json:"kyc"`  // Optional owner attributes, kept in private data on-chain
+
+	// PublicKey is the owner's hex Ed25519 key for self-custody wallets; when empty the
+	// service generates a custodial key. The wallet address is derived from it.
+	PublicKey string `json:"public_key"`
 }
 
 type WalletBalance struct {
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Balance   int64  `json:"balance"`
+	Held      int64  `json:"held"`
+	Available int64  `json:"available"`
+	Currency  string `json:"currency"`
+}
+
+type LockFundsRequest struct {
+	Amount   int64  `json:"amount"`
+	Reason   string `json:"reason"`              // offline_funding, authorization
+	DeviceID string `json:"device_id,omitempty"` // Required for offline_funding
+	HoldID   string `json:"hold_id,omitempty"`   // Optional client-chosen hold ID
+
+	// Authorization is the owner's signature for self-custody wallets; custodial
+	// wallets are signed by the service
+	Authorization *intents.Authorization `json:"authorization,omitempty"`
+}
+
+type CaptureHoldRequest struct {
+	ToWalletID    string                 `json:"to_wallet_id"`
+	Amount        int64                  `json:"amount"`
+	Authorization *intents.Authorization `json:"authorization,omitempty"` // Over the payee and amount
+}
+
+type WalletStatusRequest struct {
+	Status     string `json:"status"`      // ACTIVE, SUSPENDED, DORMANT, CLOSED
+	ReasonCode string `json:"reason_code"` // e.g. KYC_REVIEW, INACTIVITY, CUSTOMER_REQUEST
+}
+
+type UpdateTierRequest struct {
+	Tier       string `json:"tier"`        // Tier0, Tier1, Tier2
+	ReasonCode string `json:"reason_code"` // e.g. KYC_VERIFIED, KYC_REVIEW, CUSTOMER_REQUEST
+}
+
+// AliasRequest links a human-friendly alias to a wallet address. Aliases stay in the
+// intermediary's database and never reach the ledger.
+// The authenticated user must own the wallet and confirm the alias with an AliasVerification.
+type AliasRequest struct {
+	Type  string `json:"type"`  // PHONE or EMAIL
+	Value string `json:"value"` // e.g. +2348012345678
+}
+
+// AliasVerification returns the code sent to an alias to prove the user controls it
+type AliasVerification struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Code  string `json:"code"`
 }
@@ -439,7 +439,7 @@ export default function CBNConsole() {
                                         value={freezeWallet}
                                         onChange={(e) => setFreezeWallet(e.target.value)}
                                         className="mt-1 block w-full rounded-md border-gray-300 shadow-sm p-2 border"
-                                        placeholder="wallet-xxx-yyy"
+                                        placeholder="cb1..."
                                     />
                                 </div>
                                 <button
@@ -259,7 +259,7 @@ export default function Home() {
                         <h2 className="text-xl font-bold mb-4">Send Money</h2>
                         <div className="space-y-4">
                             <Input
-                                placeholder="wallet-recipient-id"
+                                placeholder="cb1..."
                                 label="Recipient Wallet ID"
                                 value={recipient}
                                 onChange={(e) => setRecipient(e.target.value)}
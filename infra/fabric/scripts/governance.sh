@@ -0,0 +1,43 @@
+#!/bin/bash
+
+# Governance proposal decisions, signed by each admin's own enrolled identity.
+# governance-cc counts one approval per distinct identity, so these cannot go through
+# a service that submits as a single identity.
+#
+# Usage: ./governance.sh [approve|reject|execute] <proposal-id> [reason]
+#   ADMIN_MSP_PATH  the admin's MSP directory (default: Admin@centralbank.cbdc)
+#   ADMIN_MSP_ID    CentralBankMSP, or RegulatorMSP to co-sign an approval
+
+# Environment variables for Fabric binaries
+export PATH=${PWD}/../bin:$PATH
+export FABRIC_CFG_PATH=${PWD}/../config
+
+CHANNEL_NAME="cbdc-main-channel"
+CC_NAME="governance-cc"
+
+ACTION=$1
+PROPOSAL_ID=$2
+REASON=$3
+
+export CORE_PEER_LOCALMSPID=${ADMIN_MSP_ID:-CentralBankMSP}
+export CORE_PEER_MSPCONFIGPATH=${ADMIN_MSP_PATH:-${PWD}/../crypto-config/peerOrganizations/centralbank.cbdc/users/Admin@centralbank.cbdc/msp}
+export CORE_PEER_ADDRESS=peer0.centralbank.cbdc:7051
+
+if [ -z "$PROPOSAL_ID" ]; then
+    echo "A proposal ID is required"
+    exit 1
+fi
+
+if [ "$ACTION" == "approve" ]; then
+    ARGS="{\"function\":\"ApproveProposal\",\"Args\":[\"${PROPOSAL_ID}\"]}"
+elif [ "$ACTION" == "reject" ] && [ -n "$REASON" ]; then
+    ARGS="{\"function\":\"RejectProposal\",\"Args\":[\"${PROPOSAL_ID}\",\"${REASON}\"]}"
+elif [ "$ACTION" == "execute" ]; then
+    ARGS="{\"function\":\"ExecuteProposal\",\"Args\":[\"${PROPOSAL_ID}\"]}"
+else
+    echo "Usage: ./governance.sh [approve|reject|execute] <proposal-id> [reason]"
+    exit 1
+fi
+
+echo "Submitting ${ACTION} of proposal ${PROPOSAL_ID} as ${CORE_PEER_LOCALMSPID}"
+peer chaincode invoke -o orderer.centralbank.cbdc:7050 --ordererTLSHostnameOverride orderer.centralbank.cbdc --channelID ${CHANNEL_NAME} --name ${CC_NAME} --peerAddresses peer0.centralbank.cbdc:7051 --peerAddresses peer0.consortium.cbdc:9051 -c "${ARGS}"